@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/db"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// rollupOlderThanFlag stockera la valeur du flag --older-than (ex: 90d)
+var rollupOlderThanFlag string
+
+// ClicksCmd regroupe les commandes d'administration de la table des clics.
+var ClicksCmd = &cobra.Command{
+	Use:   "clicks",
+	Short: "Commandes d'administration des clics enregistrés.",
+}
+
+// ClicksRollupCmd représente la commande 'clicks rollup'
+var ClicksRollupCmd = &cobra.Command{
+	Use:   "rollup",
+	Short: "Agrège les anciens clics en lignes journalières et supprime les clics bruts.",
+	Long: `Cette commande exécute manuellement le compactage de la table des clics :
+les clics plus anciens que le seuil sont résumés par lien et par jour dans 'click_daily',
+puis supprimés de 'clicks'. Les statistiques restent identiques.
+
+Exemples:
+  url-shortener clicks rollup
+  url-shortener clicks rollup --older-than=30d`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Charger la configuration chargée globalement via cmd.cfg
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalf("FATAL: La configuration n'a pas été chargée correctement.")
+		}
+		refuseIfReadOnly(cfg, "clicks rollup")
+
+		// Seuil par défaut issu de la configuration
+		olderThan := time.Duration(cfg.Analytics.Rollup.OlderThanDays) * 24 * time.Hour
+		if rollupOlderThanFlag != "" {
+			d, err := parseDuration(rollupOlderThanFlag)
+			if err != nil {
+				log.Fatalf("FATAL: Valeur de --older-than invalide: %v", err)
+			}
+			olderThan = d
+		}
+
+		// Initialiser la connexion à la BDD
+		conn, err := db.Connect(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+
+		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+		defer db.Close(conn)
+
+		clickService := services.NewClickService(repository.NewClickRepository(conn))
+		rolledUp, err := clickService.RollupClicks(olderThan)
+		if err != nil {
+			log.Fatalf("FATAL: Erreur lors du compactage des clics: %v", err)
+		}
+
+		fmt.Printf("%d clic(s) de plus de %v agrégé(s) en lignes journalières.\n", rolledUp, olderThan)
+	},
+}
+
+// init() s'exécute automatiquement lors de l'importation du package.
+// Il est utilisé pour définir les flags et enregistrer les commandes.
+func init() {
+	ClicksRollupCmd.Flags().StringVar(&rollupOlderThanFlag, "older-than", "", "Âge minimum des clics à agréger (ex: 90d, 720h). Par défaut: analytics.rollup.older_than_days")
+
+	ClicksCmd.AddCommand(ClicksRollupCmd)
+	cmd2.RootCmd.AddCommand(ClicksCmd)
+}
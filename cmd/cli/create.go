@@ -3,16 +3,16 @@ package cli
 import (
 	"fmt"
 	"log"
-	"net/url" // Pour valider le format de l'URL
+	"strconv"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/db"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
-	"gorm.io/gorm"
 )
 
 // longURLFlag stockera la valeur du flag --url
@@ -21,8 +21,24 @@ var longURLFlag string
 // customAliasFlag stockera la valeur du flag --alias (optionnel, feature bonus)
 var customAliasFlag string
 
-// expirationMinutesFlag stockera la durée d'expiration en minutes (optionnel, feature bonus)
-var expirationMinutesFlag int
+// expiresFlag stockera la durée d'expiration (optionnel, feature bonus) :
+// un nombre de minutes ("60") ou une durée ("24h", "7d")
+var expiresFlag string
+
+// expiresAtFlag stockera la date d'expiration absolue au format RFC 3339 (optionnel, exclusif avec --expires)
+var expiresAtFlag string
+
+// codeLengthFlag stockera la longueur du code court généré (optionnel, 0 = longueur par défaut)
+var codeLengthFlag int
+
+// maxClicksFlag stockera le nombre de clics après lequel le lien expire (optionnel, 0 = illimité)
+var maxClicksFlag int
+
+// noTrackFlag désactive l'enregistrement des clics pour le lien créé
+var noTrackFlag bool
+
+// skipPreviewFlag fait rediriger le lien directement, sans la page intermédiaire (server.preview_redirect)
+var skipPreviewFlag bool
 
 // CreateCmd représente la commande 'create'
 var CreateCmd = &cobra.Command{
@@ -34,72 +50,101 @@ Vous pouvez optionnellement spécifier un alias personnalisé avec --alias ou un
 Exemples:
   url-shortener create --url="https://www.google.com/search?q=go+lang"
   url-shortener create --url="https://www.google.com" --alias="mon-google"
-  url-shortener create --url="https://www.google.com" --expires=60  # Expire dans 60 minutes`,
+  url-shortener create --url="https://www.google.com" --expires=60  # Expire dans 60 minutes
+  url-shortener create --url="https://www.google.com" --expires=7d  # Expire dans 7 jours
+  url-shortener create --url="https://www.google.com" --expires-at=2025-12-31T23:59:00+01:00`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Valider que le flag --url a été fourni.
 		if longURLFlag == "" {
 			log.Fatalf("FATAL: Le flag --url est requis")
 		}
 
-		// Validation basique du format de l'URL avec le package url et la fonction ParseRequestURI
-		if _, err := url.ParseRequestURI(longURLFlag); err != nil {
-			log.Fatalf("FATAL: URL invalide: %v", err)
+		// La limite de clics s'appuie sur le compteur de redirections du lien, tenu même avec --no-track.
+		if maxClicksFlag < 0 {
+			log.Fatalf("FATAL: Le flag --max-clicks doit être positif")
 		}
 
-		// Charger la configuration
-		cfg, err := config.LoadConfig()
+		// Valider la durée d'expiration avant même de se connecter à la base de données
+		expirationMinutes, err := parseExpiration(expiresFlag)
 		if err != nil {
-			log.Fatalf("FATAL: Impossible de charger la configuration: %v", err)
+			log.Fatalf("FATAL: Durée d'expiration invalide: %v", err)
+		}
+		var expiresAt time.Time
+		if expiresAtFlag != "" {
+			if expiresFlag != "" {
+				log.Fatalf("FATAL: --expires et --expires-at ne peuvent pas être utilisés ensemble")
+			}
+			if expiresAt, err = time.Parse(time.RFC3339, expiresAtFlag); err != nil {
+				log.Fatalf("FATAL: Date d'expiration invalide, format RFC 3339 attendu (ex: 2025-12-31T23:59:00Z): %v", err)
+			}
 		}
 
-		// Initialiser la connexion à la base de données SQLite.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		// Charger la configuration
+		cfg, err := config.LoadConfig()
 		if err != nil {
-			log.Fatalf("FATAL: Impossible de se connecter à la base de données: %v", err)
+			log.Fatalf("FATAL: Impossible de charger la configuration: %v", err)
 		}
+		refuseIfReadOnly(cfg, "create")
 
-		sqlDB, err := db.DB()
+		// Initialiser la connexion à la base de données configurée.
+		conn, err := db.Connect(cfg)
 		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			log.Fatalf("FATAL: %v", err)
 		}
 
 		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
-		defer func() {
-			if err := sqlDB.Close(); err != nil {
-				log.Printf("Attention: Erreur lors de la fermeture de la connexion: %v", err)
-			}
-		}()
+		defer db.Close(conn)
 
 		// Initialiser les repositories et services nécessaires NewLinkRepository & NewLinkService
-		linkRepo := repository.NewLinkRepository(db)
-		linkService := services.NewLinkService(linkRepo)
+		linkRepo := repository.NewLinkRepository(conn)
+		linkService := services.NewLinkService(linkRepo, cfg)
+
+		// Options de création communes à tous les types de liens
+		opts := services.CreateLinkOptions{Source: models.LinkSourceCLI, CodeLength: codeLengthFlag, MaxClicks: maxClicksFlag,
+			SkipPreview: skipPreviewFlag}
+		if noTrackFlag {
+			trackClicks := false
+			opts.TrackClicks = &trackClicks
+		}
 
 		// Vérifier si un alias personnalisé ou une durée d'expiration a été fournie (features bonus)
 		var link *models.Link
+		var reused bool
 		if customAliasFlag != "" {
 			// Créer le lien avec l'alias personnalisé
 			fmt.Printf("Création d'un lien avec l'alias personnalisé: %s\n", customAliasFlag)
-			link, err = linkService.CreateLinkWithCustomAlias(longURLFlag, customAliasFlag)
+			link, err = linkService.CreateLinkWithCustomAlias(longURLFlag, customAliasFlag, opts)
 			if err != nil {
 				log.Fatalf("FATAL: Échec de la création du lien avec alias personnalisé: %v", err)
 			}
-		} else if expirationMinutesFlag > 0 {
+		} else if expirationMinutes > 0 {
 			// Créer le lien avec expiration
-			fmt.Printf("Création d'un lien avec expiration: %d minutes\n", expirationMinutesFlag)
-			link, err = linkService.CreateLinkWithExpiration(longURLFlag, expirationMinutesFlag)
+			fmt.Printf("Création d'un lien avec expiration: %d minutes\n", expirationMinutes)
+			link, err = linkService.CreateLinkWithExpiration(longURLFlag, expirationMinutes, opts)
+			if err != nil {
+				log.Fatalf("FATAL: Échec de la création du lien avec expiration: %v", err)
+			}
+		} else if !expiresAt.IsZero() {
+			// Créer le lien avec une date d'expiration absolue
+			fmt.Printf("Création d'un lien expirant le %s\n", expiresAt.Format(time.RFC3339))
+			link, err = linkService.CreateLinkExpiringAt(longURLFlag, expiresAt, opts)
 			if err != nil {
 				log.Fatalf("FATAL: Échec de la création du lien avec expiration: %v", err)
 			}
 		} else {
-			// Créer le lien sans options spéciales
-			link, err = linkService.CreateLink(longURLFlag)
+			// Créer le lien sans options spéciales (ou réutiliser un lien existant si server.dedupe_urls)
+			link, reused, err = linkService.CreateOrReuseLink(longURLFlag, opts)
 			if err != nil {
 				log.Fatalf("FATAL: Échec de la création du lien court: %v", err)
 			}
 		}
 
 		fullShortURL := fmt.Sprintf("%s/%s", cfg.Server.BaseURL, link.ShortCode)
-		fmt.Printf("URL courte créée avec succès:\n")
+		if reused {
+			fmt.Printf("URL déjà raccourcie, lien existant réutilisé:\n")
+		} else {
+			fmt.Printf("URL courte créée avec succès:\n")
+		}
 		fmt.Printf("Code: %s\n", link.ShortCode)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
 		if link.IsCustom {
@@ -108,9 +153,43 @@ Exemples:
 		if link.ExpiresAt != nil {
 			fmt.Printf("Expire le: %s \u23f0\n", link.ExpiresAt.Format("2006-01-02 15:04:05"))
 		}
+		if link.MaxClicks != nil {
+			fmt.Printf("Expire après: %d clic(s)\n", *link.MaxClicks)
+		}
 	},
 }
 
+// parseExpiration convertit la valeur de --expires en minutes (0 si absente).
+// Accepte un nombre de minutes ("60") ou une durée ("90m", "24h", "7d"), avec les mêmes règles
+// que le service : strictement positive, en minutes entières, et au plus 1 an.
+func parseExpiration(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	var minutes int
+	if n, err := strconv.Atoi(value); err == nil {
+		minutes = n
+	} else {
+		d, err := parseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' n'est ni un nombre de minutes ni une durée (ex: 60, 24h, 7d)", value)
+		}
+		if d%time.Minute != 0 {
+			return 0, fmt.Errorf("la durée doit être un nombre entier de minutes: '%s'", value)
+		}
+		minutes = int(d / time.Minute)
+	}
+
+	if minutes <= 0 {
+		return 0, fmt.Errorf("la durée d'expiration doit être supérieure à 0 minutes: '%s'", value)
+	}
+	if minutes > services.MaxExpirationMinutes {
+		return 0, fmt.Errorf("la durée d'expiration ne peut pas dépasser 1 an (%d minutes): '%s'", services.MaxExpirationMinutes, value)
+	}
+	return minutes, nil
+}
+
 // init() s'exécute automatiquement lors de l'importation du package.
 // Il est utilisé pour définir les flags que cette commande accepte.
 func init() {
@@ -120,8 +199,23 @@ func init() {
 	// Définir le flag --alias pour spécifier un alias personnalisé (optionnel, feature bonus)
 	CreateCmd.Flags().StringVarP(&customAliasFlag, "alias", "a", "", "Alias personnalisé pour l'URL courte (optionnel)")
 
-	// Définir le flag --expires pour spécifier la durée d'expiration en minutes (optionnel, feature bonus)
-	CreateCmd.Flags().IntVarP(&expirationMinutesFlag, "expires", "e", 0, "Durée de vie du lien en minutes (optionnel)")
+	// Définir le flag --expires pour spécifier la durée d'expiration (optionnel, feature bonus)
+	CreateCmd.Flags().StringVarP(&expiresFlag, "expires", "e", "", "Durée de vie du lien en minutes ou en durée, ex: 60, 24h, 7d (optionnel)")
+
+	// Définir le flag --expires-at pour spécifier une date d'expiration absolue (optionnel)
+	CreateCmd.Flags().StringVar(&expiresAtFlag, "expires-at", "", "Date d'expiration au format RFC 3339, ex: 2025-12-31T23:59:00Z (optionnel)")
+
+	// Définir le flag --length pour choisir la longueur du code court généré (optionnel)
+	CreateCmd.Flags().IntVarP(&codeLengthFlag, "length", "l", 0, "Longueur du code court généré, dans les bornes configurées (optionnel)")
+
+	// Définir le flag --max-clicks pour faire expirer le lien après N clics (optionnel)
+	CreateCmd.Flags().IntVar(&maxClicksFlag, "max-clicks", 0, "Nombre de clics après lequel le lien expire (optionnel)")
+
+	// Définir le flag --no-track pour ne pas enregistrer les clics de ce lien (optionnel)
+	CreateCmd.Flags().BoolVar(&noTrackFlag, "no-track", false, "Ne pas enregistrer les clics de ce lien (optionnel)")
+
+	// Définir le flag --skip-preview pour rediriger sans la page intermédiaire (optionnel)
+	CreateCmd.Flags().BoolVar(&skipPreviewFlag, "skip-preview", false, "Rediriger directement, sans la page intermédiaire de server.preview_redirect (optionnel)")
 
 	// Marquer le flag --url comme requis
 	CreateCmd.MarkFlagRequired("url")
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/db"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// listLimitFlag stockera la valeur du flag --limit (0 = tous les liens)
+var listLimitFlag int
+
+// listSortFlag stockera la valeur du flag --sort (clicks ou date)
+var listSortFlag string
+
+// ListCmd représente la commande 'list'
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Affiche tous les liens courts avec leur nombre de clics.",
+	Long: `Cette commande affiche un tableau de tous les liens (code court, URL longue,
+date de création, nombre de clics, alias personnalisé), sans nécessiter que le serveur API soit lancé.
+
+Exemples:
+  url-shortener list
+  url-shortener list --limit=50 --sort=clicks`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if listLimitFlag < 0 {
+			log.Fatalf("FATAL: Le flag --limit ne peut pas être négatif")
+		}
+		if listSortFlag != "date" && listSortFlag != "clicks" {
+			log.Fatalf("FATAL: Le flag --sort doit valoir 'clicks' ou 'date'")
+		}
+
+		// Charger la configuration
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatalf("FATAL: Impossible de charger la configuration: %v", err)
+		}
+
+		// Initialiser la connexion à la BDD.
+		conn, err := db.Connect(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+
+		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+		defer db.Close(conn)
+
+		linkRepo := repository.NewLinkRepository(conn)
+		linkService := services.NewLinkService(linkRepo, cfg)
+
+		// Les nombres de clics sont calculés dans la même requête que la liste (pas une requête par lien)
+		links, err := linkService.GetAllLinksWithClickCounts(listSortFlag == "clicks", listLimitFlag)
+		if err != nil {
+			log.Fatalf("FATAL: Erreur lors de la récupération des liens: %v", err)
+		}
+
+		if len(links) == 0 {
+			fmt.Println("Aucun lien enregistré.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tURL LONGUE\tCRÉÉ LE\tCLICS\tPERSONNALISÉ")
+		for _, l := range links {
+			custom := "non"
+			if l.IsCustom {
+				custom = "oui"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", l.ShortCode, l.LongURL, l.CreatedAt.Format("2006-01-02 15:04"), l.ClickCount, custom)
+		}
+		w.Flush()
+	},
+}
+
+// init() s'exécute automatiquement lors de l'importation du package.
+// Il est utilisé pour définir les flags que cette commande accepte.
+func init() {
+	ListCmd.Flags().IntVarP(&listLimitFlag, "limit", "l", 0, "Nombre maximum de liens à afficher (0 = tous)")
+	ListCmd.Flags().StringVar(&listSortFlag, "sort", "date", "Ordre du tableau: 'date' (plus récents d'abord) ou 'clicks' (plus cliqués d'abord)")
+
+	// Ajouter la commande à RootCmd
+	cmd2.RootCmd.AddCommand(ListCmd)
+}
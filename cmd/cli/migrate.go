@@ -5,50 +5,53 @@ import (
 	"log"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/db"
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
-	"gorm.io/gorm"
 )
 
 // MigrateCmd représente la commande 'migrate'
 var MigrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Exécute les migrations de la base de données pour créer ou mettre à jour les tables.",
-	Long: `Cette commande se connecte à la base de données configurée (SQLite)
-et exécute les migrations automatiques de GORM pour créer les tables 'links' et 'clicks'
-basées sur les modèles Go.`,
+	Long: `Cette commande se connecte à la base de données configurée (SQLite ou PostgreSQL, database.driver)
+et exécute les migrations automatiques de GORM pour créer les tables 'links', 'clicks'
+et 'click_daily' basées sur les modèles Go.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Charger la configuration chargée globalement via cmd.cfg
 		cfg := cmd2.Cfg
 		if cfg == nil {
 			log.Fatalf("FATAL: La configuration n'a pas été chargée correctement.")
 		}
+		refuseIfReadOnly(cfg, "migrate")
 
 		// Initialiser la connexion à la BDD
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		conn, err := db.Connect(cfg)
 		if err != nil {
-			log.Fatalf("FATAL: Impossible de se connecter à la base de données: %v", err)
+			log.Fatalf("FATAL: %v", err)
 		}
 
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
-		}
-		// Assurez-vous que la connexion est fermée après la migration grâce à defer
-		defer func() {
-			if err := sqlDB.Close(); err != nil {
-				log.Printf("Attention: Erreur lors de la fermeture de la connexion à la base de données: %v", err)
-			}
-		}()
+		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+		defer db.Close(conn)
 
 		// Exécuter les migrations automatiques de GORM.
-		// Utilisez db.AutoMigrate() et passez-lui les pointeurs vers tous vos modèles.
+		// Utilisez conn.AutoMigrate() et passez-lui les pointeurs vers tous vos modèles.
+		// AutoMigrate compare aussi la taille des colonnes existantes et les élargit si besoin
+		// (ex: short_code passé de 10 à 30 caractères) ; SQLite stocke les chaînes en TEXT sans limite.
 		log.Println("Exécution des migrations de la base de données...")
-		if err := db.AutoMigrate(&models.Link{}, &models.Click{}); err != nil {
+		addsRedirectCount := conn.Migrator().HasTable(&models.Link{}) && !conn.Migrator().HasColumn(&models.Link{}, "redirect_count")
+		if err := conn.AutoMigrate(&models.Link{}, &models.Click{}, &models.ClickDaily{}); err != nil {
 			log.Fatalf("FATAL: Erreur lors de l'exécution des migrations: %v", err)
 		}
 
+		// La colonne redirect_count vient d'être ajoutée : l'initialiser à partir des clics déjà enregistrés
+		if addsRedirectCount {
+			if err := repository.NewLinkRepository(conn).BackfillRedirectCounts(); err != nil {
+				log.Fatalf("FATAL: Erreur lors de l'initialisation des compteurs de redirections: %v", err)
+			}
+		}
+
 		// Pas touche au log
 		fmt.Println("Migrations de la base de données exécutées avec succès.")
 	},
@@ -0,0 +1,14 @@
+package cli
+
+import (
+	"log"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// refuseIfReadOnly arrête une commande d'écriture lorsque le service est configuré en lecture seule.
+func refuseIfReadOnly(cfg *config.Config, command string) {
+	if cfg.Server.ReadOnly {
+		log.Fatalf("FATAL: La commande '%s' est désactivée: le service est en lecture seule (server.read_only).", command)
+	}
+}
@@ -4,14 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/db"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
 
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
 	"gorm.io/gorm"
 )
 
@@ -41,26 +42,17 @@ Exemple:
 		}
 
 		// Initialiser la connexion à la BDD.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		conn, err := db.Connect(cfg)
 		if err != nil {
-			log.Fatalf("FATAL: Impossible de se connecter à la base de données: %v", err)
+			log.Fatalf("FATAL: %v", err)
 		}
 
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
-		}
-
-		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande grâce à defer
-		defer func() {
-			if err := sqlDB.Close(); err != nil {
-				log.Printf("Attention: Erreur lors de la fermeture de la connexion: %v", err)
-			}
-		}()
+		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+		defer db.Close(conn)
 
 		// Initialiser les repositories et services nécessaires NewLinkRepository & NewLinkService
-		linkRepo := repository.NewLinkRepository(db)
-		linkService := services.NewLinkService(linkRepo)
+		linkRepo := repository.NewLinkRepository(conn)
+		linkService := services.NewLinkService(linkRepo, cfg)
 
 		// Appeler GetLinkStats pour récupérer le lien et ses statistiques.
 		// Attention, la fonction retourne 3 valeurs
@@ -75,7 +67,41 @@ Exemple:
 
 		fmt.Printf("Statistiques pour le code court: %s\n", link.ShortCode)
 		fmt.Printf("URL longue: %s\n", link.LongURL)
+		if !cfg.Analytics.Enabled {
+			fmt.Println("Analytics désactivées: les clics ne sont pas enregistrés.")
+			return
+		}
+		if !link.TracksClicks() {
+			fmt.Println("Suivi des clics désactivé pour ce lien.")
+			return
+		}
 		fmt.Printf("Total de clics: %d\n", totalClicks)
+		uniqueVisitors, err := linkService.CountUniqueVisitors(link.ID)
+		if err != nil {
+			log.Fatalf("FATAL: Erreur lors du comptage des visiteurs uniques: %v", err)
+		}
+		fmt.Printf("Visiteurs uniques: %d\n", uniqueVisitors)
+		clicksByBrowser, err := linkService.GetBrowserBreakdown(link.ID)
+		if err != nil {
+			log.Fatalf("FATAL: Erreur lors de la répartition des clics par navigateur: %v", err)
+		}
+		if len(clicksByBrowser) > 0 {
+			browsers := make([]string, 0, len(clicksByBrowser))
+			for browser := range clicksByBrowser {
+				browsers = append(browsers, browser)
+			}
+			sort.Slice(browsers, func(i, j int) bool {
+				return clicksByBrowser[browsers[i]] > clicksByBrowser[browsers[j]] ||
+					(clicksByBrowser[browsers[i]] == clicksByBrowser[browsers[j]] && browsers[i] < browsers[j])
+			})
+			fmt.Println("Clics par navigateur:")
+			for _, browser := range browsers {
+				fmt.Printf("  %s: %d\n", browser, clicksByBrowser[browser])
+			}
+		}
+		if link.MaxClicks != nil {
+			fmt.Printf("Clics restants: %d (limite: %d)\n", max(*link.MaxClicks-link.RedirectCount, 0), *link.MaxClicks)
+		}
 	},
 }
 
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/db"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// topLimitFlag stockera la valeur du flag --limit
+var topLimitFlag int
+
+// topSinceFlag stockera la valeur du flag --since (ex: 7d, 24h)
+var topSinceFlag string
+
+// topJSONFlag indique si la sortie doit être au format JSON
+var topJSONFlag bool
+
+// TopCmd représente la commande 'top'
+var TopCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Affiche les liens les plus cliqués.",
+	Long: `Cette commande affiche le classement des liens courts par nombre de clics,
+sans nécessiter que le serveur API soit lancé.
+
+Exemples:
+  url-shortener top
+  url-shortener top --limit=5 --since=7d
+  url-shortener top --json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if topLimitFlag <= 0 {
+			log.Fatalf("FATAL: Le flag --limit doit être supérieur à 0")
+		}
+
+		// Calculer le début de la période (zéro = tout l'historique)
+		var since time.Time
+		if topSinceFlag != "" {
+			period, err := parseDuration(topSinceFlag)
+			if err != nil {
+				log.Fatalf("FATAL: Valeur de --since invalide: %v", err)
+			}
+			since = time.Now().Add(-period)
+		}
+
+		// Charger la configuration
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatalf("FATAL: Impossible de charger la configuration: %v", err)
+		}
+
+		// Initialiser la connexion à la BDD.
+		conn, err := db.Connect(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+
+		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+		defer db.Close(conn)
+
+		linkRepo := repository.NewLinkRepository(conn)
+		linkService := services.NewLinkService(linkRepo, cfg)
+
+		topLinks, err := linkService.GetTopLinks(topLimitFlag, since)
+		if err != nil {
+			log.Fatalf("FATAL: Erreur lors de la récupération du classement: %v", err)
+		}
+
+		if topJSONFlag {
+			printTopLinksJSON(topLinks, cfg.Server.BaseURL)
+			return
+		}
+
+		if len(topLinks) == 0 {
+			fmt.Println("Aucun clic enregistré pour la période demandée.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RANG\tCODE\tCLICS\tURL LONGUE")
+		for i, l := range topLinks {
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", i+1, l.ShortCode, l.ClickCount, l.LongURL)
+		}
+		w.Flush()
+	},
+}
+
+// topLinkJSON est la représentation JSON d'une entrée du classement.
+type topLinkJSON struct {
+	ShortCode    string `json:"short_code"`
+	LongURL      string `json:"long_url"`
+	FullShortURL string `json:"full_short_url"`
+	Clicks       int    `json:"clicks"`
+}
+
+// printTopLinksJSON affiche le classement au format JSON (tableau vide si aucun lien).
+func printTopLinksJSON(topLinks []repository.LinkClickCount, baseURL string) {
+	entries := make([]topLinkJSON, 0, len(topLinks))
+	for _, l := range topLinks {
+		entries = append(entries, topLinkJSON{
+			ShortCode:    l.ShortCode,
+			LongURL:      l.LongURL,
+			FullShortURL: baseURL + "/" + l.ShortCode,
+			Clicks:       l.ClickCount,
+		})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(entries); err != nil {
+		log.Fatalf("FATAL: Erreur lors de l'encodage JSON: %v", err)
+	}
+}
+
+// parseDuration analyse une durée au format Go (ex: 24h, 90m) ou en jours avec le suffixe 'd' (ex: 7d).
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("nombre de jours invalide: '%s'", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("la durée doit être positive: '%s'", value)
+	}
+	return d, nil
+}
+
+// init() s'exécute automatiquement lors de l'importation du package.
+// Il est utilisé pour définir les flags que cette commande accepte.
+func init() {
+	TopCmd.Flags().IntVarP(&topLimitFlag, "limit", "l", 20, "Nombre de liens à afficher")
+	TopCmd.Flags().StringVarP(&topSinceFlag, "since", "s", "", "Ne compter que les clics récents (ex: 7d, 24h)")
+	TopCmd.Flags().BoolVar(&topJSONFlag, "json", false, "Afficher le résultat au format JSON")
+
+	// Ajouter la commande à RootCmd
+	cmd2.RootCmd.AddCommand(TopCmd)
+}
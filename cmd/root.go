@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -57,11 +58,10 @@ func initConfig() {
 	var err error
 	Cfg, err = config.LoadConfig()
 	if err != nil {
-		// Loggue l'erreur mais ne fait pas un os.Exit(1) ici si LoadConfig()
-		// gère déjà l'absence de fichier avec des valeurs par défaut.
-		// Si LoadConfig() termine le programme en cas d'erreur fatale,
-		// cette vérification est surtout pour les avertissements.
-		log.Printf("Attention: Problème lors du chargement de la configuration: %v. Utilisation des valeurs par défaut.", err)
+		// LoadConfig gère déjà l'absence de fichier avec les valeurs par défaut : une erreur signifie
+		// un fichier illisible ou une valeur invalide, on s'arrête dès le démarrage.
+		log.Fatalf("FATAL: %v", err)
 	}
+	logging.Setup(Cfg.Logging)
 	// La configuration est maintenant disponible via la variable globale 'cmd.cfg'.
 }
@@ -1,16 +1,22 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/api"
+	"github.com/axellelanca/urlshortener/internal/db"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/middleware"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/monitor"
@@ -18,9 +24,8 @@ import (
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
-	"gorm.io/gorm"
 )
 
 // RunServerCmd représente la commande 'run-server' de Cobra.
@@ -39,57 +44,177 @@ puis lance le serveur HTTP.`,
 		}
 
 		// Initialiser la connexion à la BDD
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		conn, err := db.Connect(cfg)
 		if err != nil {
-			log.Fatalf("FATAL: Impossible de se connecter à la base de données: %v", err)
+			log.Fatalf("FATAL: %v", err)
 		}
+		// Fermée après l'arrêt du serveur et l'enregistrement des clics restants
+		defer db.Close(conn)
+
+		// Un client Redis par adresse, partagé par le cache, la déduplication des clics et le rate limiting,
+		// et fermé comme la base une fois les clics restants enregistrés
+		redisPool := redisClients{}
+		defer redisPool.closeAll()
 
 		// Initialiser les repositories.
-		linkRepo := repository.NewLinkRepository(db)
-		clickRepo := repository.NewClickRepository(db)
+		var linkRepo repository.LinkRepository = repository.NewLinkRepository(conn)
+		clickRepo := repository.NewClickRepository(conn)
+		// Cache optionnel (Redis ou LRU en mémoire) devant les lectures par code court des redirections
+		// Avec cache.serve_stale_on_error, les entrées expirées sont conservées pour être servies si la base est en erreur
+		cacheTTL := time.Duration(cfg.Cache.TTLSeconds) * time.Second
+		var staleTTL time.Duration
+		if cfg.Cache.ServeStaleOnError {
+			staleTTL = time.Duration(cfg.Cache.StaleTTLSeconds) * time.Second
+		}
+		if cfg.Cache.RedisAddr != "" {
+			linkRepo = repository.NewCachedLinkRepository(linkRepo,
+				repository.NewRedisLinkCache(redisPool.get(cfg.Cache.RedisAddr), staleTTL), cacheTTL, cfg.Cache.ServeStaleOnError)
+			slog.Info("Cache Redis des liens activé", "addr", cfg.Cache.RedisAddr, "ttl_seconds", cfg.Cache.TTLSeconds)
+		} else if cfg.Cache.MaxEntries > 0 {
+			linkRepo = repository.NewCachedLinkRepository(linkRepo,
+				repository.NewLRULinkCache(cfg.Cache.MaxEntries, staleTTL), cacheTTL, cfg.Cache.ServeStaleOnError)
+			slog.Info("Cache en mémoire des liens activé", "max_entries", cfg.Cache.MaxEntries, "ttl_seconds", cfg.Cache.TTLSeconds)
+		}
 
 		// Laissez le log
-		log.Println("Repositories initialisés.")
+		slog.Info("Repositories initialisés.")
 
 		// Initialiser les services métiers.
-		linkService := services.NewLinkService(linkRepo)
-		_ = services.NewClickService(clickRepo) // clickService n'est pas utilisé directement ici
+		linkService := services.NewLinkService(linkRepo, cfg)
+		clickService := services.NewClickService(clickRepo)
 
 		// Laissez le log
-		log.Println("Services métiers initialisés.")
+		slog.Info("Services métiers initialisés.")
 
-		// Initialiser le channel ClickEventsChannel (api/handlers) des événements de clic et lancer les workers (StartClickWorkers).
-		api.ClickEventsChannel = make(chan models.ClickEvent, cfg.Analytics.BufferSize)
-		workers.StartClickWorkers(cfg.Analytics.WorkerCount, api.ClickEventsChannel, clickRepo)
+		// Initialiser le channel des événements de clic, injecté dans les routes, et lancer les workers (StartClickWorkers).
+		// Aucun channel ni worker n'est créé si les analytics sont désactivées.
+		var clickEvents chan models.ClickEvent
+		var clickWorkers *sync.WaitGroup
+		if cfg.Server.ReadOnly {
+			slog.Info("Mode lecture seule: les écritures sont refusées et aucun clic ne sera enregistré.")
+		} else if cfg.Analytics.Enabled {
+			clickEvents = make(chan models.ClickEvent, cfg.Analytics.BufferSize)
+			// Déduplication des clics (optionnelle), partagée entre répliques avec Redis
+			var dedup services.ClickDeduplicator
+			if cfg.Analytics.DedupWindowSeconds > 0 {
+				window := time.Duration(cfg.Analytics.DedupWindowSeconds) * time.Second
+				if cfg.Analytics.DedupBackend == "redis" {
+					dedup = services.NewRedisClickDeduplicator(redisPool.get(cfg.Analytics.RedisAddr), window)
+				} else {
+					dedup = services.NewMemoryClickDeduplicator(window)
+				}
+				slog.Info("Déduplication des clics activée", "backend", cfg.Analytics.DedupBackend, "window", window.String())
+			}
+			// Résolution du pays des clics (optionnelle) : une base absente ou illisible désactive la résolution sans bloquer le démarrage
+			var geo services.CountryResolver
+			if cfg.Analytics.GeoIPDB != "" {
+				resolver, err := services.NewGeoIPCountryResolver(cfg.Analytics.GeoIPDB)
+				if err != nil {
+					slog.Warn("Base GeoIP inutilisable, les clics seront enregistrés sans pays", "path", cfg.Analytics.GeoIPDB, "error", err)
+				} else {
+					defer resolver.Close()
+					geo = resolver
+					slog.Info("Résolution du pays des clics activée", "path", cfg.Analytics.GeoIPDB)
+				}
+			}
+			clickWorkers = workers.StartClickWorkers(cfg.Analytics.WorkerCount, clickEvents, clickRepo, linkRepo, dedup, geo,
+				cfg.Analytics.BatchSize, time.Duration(cfg.Analytics.FlushIntervalMs)*time.Millisecond)
 
-		log.Printf("Channel d'événements de clic initialisé avec un buffer de %d. %d worker(s) de clics démarré(s).",
-			cfg.Analytics.BufferSize, cfg.Analytics.WorkerCount)
+			slog.Info("Channel d'événements de clic initialisé, workers de clics démarrés",
+				"buffer_size", cfg.Analytics.BufferSize, "worker_count", cfg.Analytics.WorkerCount)
+		} else {
+			slog.Info("Analytics désactivées: aucun clic ne sera enregistré.")
+		}
 
 		// Initialiser et lancer le moniteur d'URLs.
 		monitorInterval := time.Duration(cfg.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval)
+		tlsVersion, err := cfg.Monitor.TLSVersion()
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		// Service de vérification de sécurité des destinations (optionnel).
+		// En mode fail-closed : une indisponibilité est remontée au moniteur, qui l'ignore.
+		var safetyChecker *services.URLChecker
+		if cfg.Monitor.SafetyCheckEndpoint != "" {
+			safetyChecker = services.NewURLChecker(cfg.Monitor.SafetyCheckEndpoint,
+				time.Duration(cfg.Monitor.SafetyCheckTimeoutMs)*time.Millisecond, false)
+		}
+		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval, tlsVersion, cfg.Monitor.InsecureSkipVerify, safetyChecker,
+			cfg.Monitor.DryRun, cfg.Monitor.Unreachable != "off", cfg.Monitor.UnreachableThreshold)
+		if cfg.Monitor.DryRun {
+			slog.Info("Moniteur en mode observation (monitor.dry_run): aucun lien ne sera désactivé.")
+		}
+		if cfg.Monitor.InsecureSkipVerify {
+			slog.Warn("La vérification des certificats TLS du moniteur est désactivée.")
+		}
 
-		// Lancez le moniteur dans sa propre goroutine.
-		go urlMonitor.Start()
+		// Lancez le moniteur dans sa propre goroutine (il met à jour les liens, inutile en lecture seule).
+		if !cfg.Server.ReadOnly {
+			go urlMonitor.Start()
+			slog.Info("Moniteur d'URLs démarré", "interval", monitorInterval.String())
+		}
+
+		// Lancer la purge périodique des liens expirés si activée, au rythme du moniteur.
+		if cfg.Monitor.PurgeExpired != "off" && !cfg.Server.ReadOnly {
+			go workers.StartExpiredLinkPurge(linkService, monitorInterval, cfg.Monitor.PurgeExpired == "hard")
+		}
 
-		log.Printf("Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
+		// Lancer le compactage périodique des anciens clics si activé.
+		if cfg.Analytics.Rollup.Enabled && !cfg.Server.ReadOnly {
+			go workers.StartClickRollup(clickService,
+				time.Duration(cfg.Analytics.Rollup.IntervalHours)*time.Hour,
+				time.Duration(cfg.Analytics.Rollup.OlderThanDays)*24*time.Hour)
+		}
 
 		// Initialiser le rate limiter si activé (feature bonus)
-		var rateLimiter *middleware.IPRateLimiter
+		var rateLimiter middleware.RateLimiter
+		var rateLimitRules []middleware.RouteRateLimit
 		if cfg.RateLimiter.Enabled {
-			rateLimiter = middleware.NewIPRateLimiter(cfg.RateLimiter.MaxRequests, cfg.RateLimiter.WindowMinutes)
-			log.Printf("Rate limiter activé: %d requêtes max par IP toutes les %d minute(s)",
-				cfg.RateLimiter.MaxRequests, cfg.RateLimiter.WindowMinutes)
+			// Compteurs en mémoire ou partagés dans Redis (rate_limiter.backend), un nom distinct par limiter
+			newLimiter := func(name string, maxRequests, windowMinutes int) middleware.RateLimiter {
+				if cfg.RateLimiter.Backend == "redis" {
+					return middleware.NewRedisRateLimiter(redisPool.get(cfg.RateLimiter.RedisAddr), name, maxRequests, windowMinutes)
+				}
+				return middleware.NewRateLimiter(cfg.RateLimiter.Algorithm, maxRequests, windowMinutes)
+			}
+			rateLimiter = newLimiter("default", cfg.RateLimiter.MaxRequests, cfg.RateLimiter.WindowMinutes)
+			// Limites par route (rate_limiter.rules), chacune avec son propre limiter
+			for _, rule := range cfg.RateLimiter.Rules {
+				rateLimitRules = append(rateLimitRules, middleware.RouteRateLimit{
+					Method:  strings.ToUpper(rule.Method),
+					Path:    rule.Path,
+					Limiter: newLimiter(strings.TrimSpace(strings.ToUpper(rule.Method)+" "+rule.Path), rule.MaxRequests, rule.WindowMinutes),
+				})
+			}
+			slog.Info("Rate limiter activé", "algorithm", cfg.RateLimiter.Algorithm, "backend", cfg.RateLimiter.Backend, "scope", cfg.RateLimiter.Scope, "rules", len(rateLimitRules),
+				"max_requests", cfg.RateLimiter.MaxRequests, "window_minutes", cfg.RateLimiter.WindowMinutes)
 		} else {
-			log.Println("Rate limiter désactivé")
+			slog.Info("Rate limiter désactivé")
+		}
+
+		// Métriques Prometheus (optionnelles), exposées sur /metrics
+		var appMetrics *metrics.Metrics
+		if cfg.Monitor.MetricsEnabled {
+			appMetrics = metrics.New()
+			appMetrics.ObserveDroppedOrphanClicks(workers.DroppedOrphanClicks)
+			appMetrics.ObserveStaleCacheServes(repository.StaleCacheServes)
+			if cfg.CircuitBreaker.Enabled {
+				appMetrics.ObserveCircuitBreaker(linkService.CircuitBreakerState,
+					services.BreakerClosed, services.BreakerOpen, services.BreakerHalfOpen)
+			}
+			slog.Info("Métriques Prometheus exposées sur /metrics.")
 		}
 
 		// Configurer le routeur Gin et les handlers API.
-		router := gin.Default()
-		api.SetupRoutes(router, linkService, cfg, rateLimiter)
+		// Pas de logger Gin : le log d'accès passe par slog (middleware.AccessLogMiddleware) et respecte logging.format
+		router := gin.New()
+		router.Use(gin.Recovery())
+		if err := api.SetupRoutes(router, linkService, cfg, rateLimiter, rateLimitRules, clickEvents, appMetrics); err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
 
 		// Pas toucher au log
-		log.Println("Routes API configurées.")
+		slog.Info("Routes API configurées.")
 
 		// Créer le serveur HTTP Gin
 		serverAddr := fmt.Sprintf(":%d", cfg.Server.Port)
@@ -100,7 +225,7 @@ puis lance le serveur HTTP.`,
 
 		// Démarrer le serveur Gin dans une goroutine anonyme pour ne pas bloquer.
 		go func() {
-			log.Printf("Serveur HTTP démarré sur %s", serverAddr)
+			slog.Info("Serveur HTTP démarré", "addr", serverAddr)
 			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 				log.Fatalf("FATAL: Erreur du serveur: %v", err)
 			}
@@ -113,16 +238,60 @@ puis lance le serveur HTTP.`,
 
 		// Bloquer jusqu'à ce qu'un signal d'arrêt soit reçu.
 		<-quit
-		log.Println("Signal d'arrêt reçu. Arrêt du serveur...")
+		slog.Info("Signal d'arrêt reçu. Arrêt du serveur...")
+
+		// Arrêt propre du serveur HTTP avec un timeout : plus aucune nouvelle requête,
+		// les requêtes en cours (et donc les derniers clics mis en file) se terminent.
+		slog.Info("Arrêt en cours... Donnez un peu de temps aux workers pour finir.")
+		shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		shutdownErr := srv.Shutdown(ctx)
+		if shutdownErr != nil {
+			slog.Warn("Arrêt du serveur HTTP interrompu", "error", shutdownErr)
+		}
 
-		// Arrêt propre du serveur HTTP avec un timeout.
-		log.Println("Arrêt en cours... Donnez un peu de temps aux workers pour finir.")
-		time.Sleep(5 * time.Second)
+		// Vider le channel pour que les workers enregistrent les clics restants puis s'arrêtent, avec son propre délai.
+		// Si des requêtes sont encore en cours, elles peuvent encore mettre un clic en file : fermer le channel
+		// les ferait paniquer, les clics en attente sont alors abandonnés.
+		if clickWorkers != nil {
+			switch {
+			case shutdownErr != nil:
+				slog.Warn("Requêtes encore en cours, clics en attente non enregistrés", "pending", len(clickEvents))
+			case workers.DrainClickWorkers(clickEvents, clickWorkers, shutdownTimeout):
+				slog.Info("Tous les clics en attente ont été enregistrés.")
+			default:
+				slog.Warn("Clics en attente non enregistrés à l'expiration du délai d'arrêt", "pending", len(clickEvents))
+			}
+		}
 
-		log.Println("Serveur arrêté proprement.")
+		slog.Info("Serveur arrêté proprement.")
 	},
 }
 
+// redisClients associe à chaque adresse Redis configurée le client partagé par tous les composants qui l'utilisent,
+// pour n'ouvrir qu'un pool de connexions par instance Redis.
+type redisClients map[string]*redis.Client
+
+// get retourne le client de l'adresse donnée (host:port), créé au premier appel.
+func (r redisClients) get(addr string) *redis.Client {
+	client, ok := r[addr]
+	if !ok {
+		client = redis.NewClient(&redis.Options{Addr: addr})
+		r[addr] = client
+	}
+	return client
+}
+
+// closeAll ferme tous les clients créés.
+func (r redisClients) closeAll() {
+	for addr, client := range r {
+		if err := client.Close(); err != nil {
+			slog.Warn("Fermeture du client Redis impossible", "addr", addr, "error", err)
+		}
+	}
+}
+
 func init() {
 	// Ajouter la commande run-server à RootCmd
 	cmd2.RootCmd.AddCommand(RunServerCmd)
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ListLinksByCreatorHandler liste tous les liens créés depuis une IP (GET /api/v1/admin/links/by-creator?ip=<ip>),
+// avec leur nombre de clics, pour les investigations d'abus. Route réservée aux administrateurs.
+func ListLinksByCreatorHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.Query("ip")
+		if net.ParseIP(ip) == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Le paramètre ip doit être une adresse IP valide"})
+			return
+		}
+
+		links, err := linkService.GetLinksByCreatorIP(ip)
+		if err != nil {
+			slog.Error("Error listing links for creator", "creator_ip", ip, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		now := time.Now()
+		items := make([]gin.H, 0, len(links))
+		for i := range links {
+			item := linkResponse(&links[i].Link, cfg.Server.BaseURL, now)
+			item["created_at"] = links[i].CreatedAt.Format(time.RFC3339)
+			item["is_active"] = links[i].IsActive
+			if links[i].InactiveReason != "" {
+				item["inactive_reason"] = links[i].InactiveReason
+			}
+			item["total_clicks"] = links[i].ClickCount
+			addMonitorStatus(item, &links[i].Link)
+			if links[i].Source != "" {
+				item["source"] = links[i].Source
+			}
+			items = append(items, item)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"creator_ip": ip,
+			"count":      len(items),
+			"links":      items,
+		})
+	}
+}
+
+// LinkSourcesStatsHandler retourne le nombre de liens par chemin de création (GET /api/v1/admin/stats/sources).
+// Route réservée aux administrateurs.
+func LinkSourcesStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		counts, err := linkService.CountLinksBySource()
+		if err != nil {
+			slog.Error("Error counting links by source", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		total := 0
+		for _, count := range counts {
+			total += count
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"total":   total,
+			"sources": counts,
+		})
+	}
+}
+
+// reportBatchSize est le nombre de liens lus par requête lors de la génération d'un rapport.
+const reportBatchSize = 500
+
+// reportRow est une ligne du rapport de statistiques.
+type reportRow struct {
+	ShortCode   string `json:"short_code"`
+	LongURL     string `json:"long_url"`
+	CreatedAt   string `json:"created_at"`
+	TotalClicks int    `json:"total_clicks"`
+	Status      string `json:"status"` // active, expired ou la raison de désactivation
+}
+
+// newReportRow construit la ligne de rapport d'un lien.
+func newReportRow(link repository.LinkClickCount) reportRow {
+	status := "active"
+	switch {
+	case !link.IsActive && link.InactiveReason != "":
+		status = link.InactiveReason
+	case !link.IsActive:
+		status = "inactive"
+	case link.IsExpired():
+		status = "expired"
+	}
+	return reportRow{
+		ShortCode:   link.ShortCode,
+		LongURL:     link.LongURL,
+		CreatedAt:   link.CreatedAt.Format(time.RFC3339),
+		TotalClicks: link.ClickCount,
+		Status:      status,
+	}
+}
+
+// StatsReportHandler génère le rapport de tous les liens avec leur nombre de clics, leur date de création
+// et leur statut (GET /api/v1/admin/stats/report?format=csv|json&since=YYYY-MM-DD). Route réservée aux administrateurs.
+// Le rapport est lu par pages (curseur sur l'ID) et écrit au fil de l'eau : il n'est jamais chargé en mémoire en entier.
+func StatsReportHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "csv" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Le paramètre format doit valoir csv ou json"})
+			return
+		}
+
+		// Filtre sur la date de création : date (YYYY-MM-DD) ou horodatage RFC 3339
+		var since time.Time
+		if value := c.Query("since"); value != "" {
+			var err error
+			if since, err = time.Parse("2006-01-02", value); err != nil {
+				if since, err = time.Parse(time.RFC3339, value); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Le paramètre since doit être une date (YYYY-MM-DD) ou un horodatage RFC 3339"})
+					return
+				}
+			}
+		}
+
+		// Lire la première page avant d'écrire quoi que ce soit, pour pouvoir encore répondre 500 en cas d'erreur
+		links, err := linkService.GetLinksWithClickCountsAfter(0, since, reportBatchSize)
+		if err != nil {
+			slog.Error("Error generating stats report", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		var csvWriter *csv.Writer
+		if format == "csv" {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="links-report.csv"`)
+			csvWriter = csv.NewWriter(c.Writer)
+			csvWriter.Write([]string{"short_code", "long_url", "created_at", "total_clicks", "status"})
+		} else {
+			c.Header("Content-Type", "application/json; charset=utf-8")
+			c.Writer.WriteString("[")
+		}
+		c.Status(http.StatusOK)
+
+		first := true
+		for len(links) > 0 {
+			for _, link := range links {
+				row := newReportRow(link)
+				if csvWriter != nil {
+					csvWriter.Write([]string{row.ShortCode, row.LongURL, row.CreatedAt, strconv.Itoa(row.TotalClicks), row.Status})
+					continue
+				}
+				encoded, err := json.Marshal(row)
+				if err != nil {
+					slog.Error("Error encoding report row", "short_code", row.ShortCode, "error", err)
+					continue
+				}
+				if !first {
+					c.Writer.WriteString(",")
+				}
+				c.Writer.Write(encoded)
+				first = false
+			}
+			if csvWriter != nil {
+				csvWriter.Flush()
+			}
+			c.Writer.Flush()
+
+			if len(links) < reportBatchSize {
+				break
+			}
+			// Les en-têtes sont déjà envoyés : une erreur ne peut plus qu'interrompre le rapport
+			links, err = linkService.GetLinksWithClickCountsAfter(links[len(links)-1].ID, since, reportBatchSize)
+			if err != nil {
+				slog.Error("Error generating stats report, report truncated", "error", err)
+				return
+			}
+		}
+
+		if csvWriter == nil {
+			c.Writer.WriteString("]")
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+func TestStatsReportIsAnAdminRoute(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.Security.AdminToken = "s3cret" })
+	api.createLink(t, "abc123", "https://example.com")
+	admin := []string{"Authorization", "Bearer s3cret"}
+
+	if res := api.do(http.MethodGet, "/api/v1/admin/stats/report?format=csv", ""); res.Code != http.StatusUnauthorized {
+		t.Errorf("sans jeton: statut %d, attendu 401", res.Code)
+	}
+	res := api.do(http.MethodGet, "/api/v1/admin/stats/report?format=csv", "", admin...)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "abc123") {
+		t.Errorf("rapport CSV: statut %d, corps %q", res.Code, res.Body.String())
+	}
+	// Le rapport n'est servi que sous /api/v1/admin, avec les autres routes d'administration
+	if res := api.do(http.MethodGet, "/api/v1/stats/report", "", admin...); res.Code != http.StatusNotFound {
+		t.Errorf("ancien chemin: statut %d, attendu 404", res.Code)
+	}
+}
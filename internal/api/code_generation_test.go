@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// collidingRepo simule un espace de codes saturé : chaque code tiré est déjà pris.
+type collidingRepo struct {
+	repository.LinkRepository
+}
+
+func (r *collidingRepo) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	return &models.Link{ShortCode: shortCode, LongURL: "https://example.com/existing"}, nil
+}
+
+func TestCreateLinkCodeGenerationFailureReturns503(t *testing.T) {
+	api := newTestAPI(t, nil)
+	cfg := testConfig(t, nil)
+	service := services.NewLinkService(&collidingRepo{LinkRepository: repository.NewLinkRepository(api.db)}, cfg)
+	router := gin.New()
+	if err := SetupRoutes(router, service, cfg, nil, nil, nil, nil); err != nil {
+		t.Fatalf("SetupRoutes: %v", err)
+	}
+	api.router = router
+
+	rec := api.do(http.MethodPost, "/api/v1/links", `{"long_url":"https://example.com/page"}`)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /api/v1/links: statut %d, attendu 503 (corps %s)", rec.Code, rec.Body.String())
+	}
+	var count int64
+	api.db.Model(&models.Link{}).Count(&count)
+	if count != 0 {
+		t.Errorf("liens en base = %d, attendu aucun", count)
+	}
+}
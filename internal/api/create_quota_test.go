@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+func TestCreateQuotaNotConsumedByReuse(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) {
+		cfg.Server.DedupeURLs = true
+		cfg.Security.StoreCreatorIP = true
+		cfg.Security.CreateQuota.Enabled = true
+		cfg.Security.CreateQuota.MaxLinks = 2
+	})
+
+	steps := []struct {
+		url           string
+		wantStatus    int
+		wantRemaining string
+	}{
+		{"https://example.com/a", http.StatusCreated, "1"},
+		{"https://example.com/a", http.StatusOK, "1"}, // réutilisation : quota inchangé
+		{"https://example.com/b", http.StatusCreated, "0"},
+		{"https://example.com/a", http.StatusOK, "0"}, // quota atteint, la réutilisation reste servie
+		{"https://example.com/c", http.StatusTooManyRequests, "0"},
+	}
+
+	for i, step := range steps {
+		rec := api.do(http.MethodPost, "/api/v1/links", `{"long_url":"`+step.url+`"}`)
+		if rec.Code != step.wantStatus {
+			t.Fatalf("étape %d (%s): statut %d, attendu %d (corps %s)", i+1, step.url, rec.Code, step.wantStatus, rec.Body.String())
+		}
+		if got := rec.Header().Get("X-Create-Quota-Remaining"); got != step.wantRemaining {
+			t.Errorf("étape %d (%s): X-Create-Quota-Remaining = %q, attendu %q", i+1, step.url, got, step.wantRemaining)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+func TestDeleteLinkHandler(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.Security.AdminToken = "s3cret" })
+	link := api.createLink(t, "abc123", "https://example.com")
+	api.db.Create(&models.Click{LinkID: link.ID, Timestamp: time.Now()})
+	admin := []string{"Authorization", "Bearer s3cret"}
+
+	if res := api.do(http.MethodDelete, "/api/v1/links/missing", "", admin...); res.Code != http.StatusNotFound {
+		t.Errorf("code inconnu: statut %d, attendu 404", res.Code)
+	}
+
+	if res := api.do(http.MethodDelete, "/api/v1/links/abc123", "", admin...); res.Code != http.StatusNoContent {
+		t.Fatalf("suppression: statut %d, attendu 204", res.Code)
+	}
+	if res := api.do(http.MethodGet, "/abc123", ""); res.Code != http.StatusNotFound {
+		t.Errorf("redirection après suppression: statut %d, attendu 404", res.Code)
+	}
+	var clicks int64
+	api.db.Model(&models.Click{}).Where("link_id = ?", link.ID).Count(&clicks)
+	if clicks != 0 {
+		t.Errorf("%d clics restants après suppression, attendu 0", clicks)
+	}
+}
+
+func TestDeleteLinkRequiresAdminWithoutAuth(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.Security.AdminToken = "s3cret" })
+	api.createLink(t, "abc123", "https://example.com")
+
+	for _, headers := range [][]string{nil, {"Authorization", "Bearer wrong"}} {
+		if res := api.do(http.MethodDelete, "/api/v1/links/abc123", "", headers...); res.Code != http.StatusUnauthorized {
+			t.Errorf("en-têtes %v: statut %d, attendu 401", headers, res.Code)
+		}
+	}
+	if res := api.do(http.MethodGet, "/abc123", ""); res.Code != http.StatusFound {
+		t.Errorf("le lien doit toujours exister: statut %d, attendu 302", res.Code)
+	}
+}
+
+func TestDeleteLinkWithoutAdminTokenIsDisabled(t *testing.T) {
+	api := newTestAPI(t, nil)
+	api.createLink(t, "abc123", "https://example.com")
+
+	if res := api.do(http.MethodDelete, "/api/v1/links/abc123", "", "Authorization", "Bearer "); res.Code != http.StatusUnauthorized {
+		t.Errorf("statut %d, attendu 401", res.Code)
+	}
+}
+
+func TestDeleteLinkWithAPIKeys(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) {
+		cfg.Auth.Enabled = true
+		cfg.Auth.APIKeys = []string{"key-a", "key-b"}
+	})
+
+	create := api.do(http.MethodPost, "/api/v1/links", `{"long_url":"https://example.com/a","custom_alias":"owned"}`,
+		"Authorization", "Bearer key-a")
+	if create.Code != http.StatusCreated {
+		t.Fatalf("création: statut %d, corps %s", create.Code, create.Body.String())
+	}
+
+	if res := api.do(http.MethodDelete, "/api/v1/links/owned", ""); res.Code != http.StatusUnauthorized {
+		t.Errorf("sans clé: statut %d, attendu 401", res.Code)
+	}
+	if res := api.do(http.MethodDelete, "/api/v1/links/owned", "", "Authorization", "Bearer key-b"); res.Code != http.StatusForbidden {
+		t.Errorf("autre clé: statut %d, attendu 403", res.Code)
+	}
+	if res := api.do(http.MethodDelete, "/api/v1/links/owned", "", "Authorization", "Bearer key-a"); res.Code != http.StatusNoContent {
+		t.Errorf("clé propriétaire: statut %d, attendu 204", res.Code)
+	}
+}
+
+func TestUpdateLinkRequiresAdminWithoutAuth(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.Security.AdminToken = "s3cret" })
+	api.createLink(t, "abc123", "https://example.com")
+	body := `{"long_url":"https://example.org/new"}`
+
+	if res := api.do(http.MethodPut, "/api/v1/links/abc123", body); res.Code != http.StatusUnauthorized {
+		t.Errorf("sans jeton: statut %d, attendu 401", res.Code)
+	}
+	if res := api.do(http.MethodPut, "/api/v1/links/abc123", body, "Authorization", "Bearer s3cret"); res.Code != http.StatusOK {
+		t.Fatalf("jeton admin: statut %d, corps %s", res.Code, res.Body.String())
+	}
+	if res := api.do(http.MethodGet, "/abc123", ""); res.Header().Get("Location") != "https://example.org/new" {
+		t.Errorf("Location = %q, attendu la nouvelle destination", res.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorPageData contient le contexte transmis aux templates HTML d'erreur.
+type ErrorPageData struct {
+	Status    int    // Code HTTP de la réponse (404, 410, 500)
+	ShortCode string // Code court demandé
+	ExpiredAt string // Date d'expiration au format RFC3339 (pages 410 uniquement)
+}
+
+// ErrorPages contient les templates HTML d'erreur indexés par code HTTP. Un navigateur (Accept: text/html)
+// reçoit la page HTML, les clients API continuent de recevoir du JSON.
+type ErrorPages struct {
+	templates map[int]*template.Template
+}
+
+// errorPageStatuses liste les codes HTTP pour lesquels un template '<code>.html' est recherché.
+var errorPageStatuses = []int{404, 410, 500}
+
+// defaultNotFoundTemplate et defaultGoneTemplate sont les pages servies aux navigateurs
+// quand aucun template n'est configuré pour ces codes.
+var (
+	defaultNotFoundTemplate = template.Must(template.New("404").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Lien introuvable</title></head>
+<body>
+<h1>Lien introuvable</h1>
+<p>Le lien court <code>{{.ShortCode}}</code> n'existe pas. Vérifiez qu'il a été copié en entier.</p>
+</body>
+</html>
+`))
+	defaultGoneTemplate = template.Must(template.New("410").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Lien indisponible</title></head>
+<body>
+<h1>Ce lien n'est plus disponible</h1>
+<p>Le lien court <code>{{.ShortCode}}</code> a expiré{{if .ExpiredAt}} le {{.ExpiredAt}}{{end}} ou a été désactivé.</p>
+</body>
+</html>
+`))
+)
+
+// DefaultErrorPages retourne les pages 404 et 410 intégrées, sans page 500 (JSON).
+func DefaultErrorPages() *ErrorPages {
+	return &ErrorPages{templates: map[int]*template.Template{
+		404: defaultNotFoundTemplate,
+		410: defaultGoneTemplate,
+	}}
+}
+
+// LoadErrorPages part des pages intégrées (DefaultErrorPages) et les remplace par les templates '404.html',
+// '410.html' et '500.html' présents dans 'dir' (les fichiers absents sont ignorés), puis par les fichiers
+// de 'files' indexés par code HTTP (server.not_found_template, server.expired_template), qui doivent exister.
+// Tout template présent mais illisible ou invalide est une erreur : aucune page n'est alors retournée.
+func LoadErrorPages(dir string, files map[int]string) (*ErrorPages, error) {
+	pages := DefaultErrorPages()
+	if dir != "" {
+		loaded := 0
+		for _, status := range errorPageStatuses {
+			path := filepath.Join(dir, fmt.Sprintf("%d.html", status))
+			tmpl, err := template.ParseFiles(path)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return nil, fmt.Errorf("erreur lors du chargement du template %s: %w", path, err)
+			}
+			pages.templates[status] = tmpl
+			loaded++
+		}
+		slog.Info("Templates HTML d'erreur chargés", "templates", loaded, "dir", dir)
+	}
+
+	for status, path := range files {
+		if path == "" {
+			continue
+		}
+		tmpl, err := template.ParseFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("erreur lors du chargement du template %s: %w", path, err)
+		}
+		pages.templates[status] = tmpl
+		slog.Info("Template HTML d'erreur chargé", "status", status, "path", path)
+	}
+	return pages, nil
+}
+
+// respondError envoie une réponse d'erreur : la page HTML correspondante si elle existe et que le client
+// préfère le HTML, sinon le corps JSON fourni.
+func respondError(c *gin.Context, pages *ErrorPages, status int, data ErrorPageData, jsonBody gin.H) {
+	if pages != nil && c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		if tmpl, ok := pages.templates[status]; ok {
+			data.Status = status
+			c.Status(status)
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			if err := tmpl.Execute(c.Writer, data); err != nil {
+				slog.Error("Error rendering error page", "status", status, "error", err)
+			}
+			return
+		}
+	}
+	c.JSON(status, jsonBody)
+}
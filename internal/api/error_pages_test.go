@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// writeTemplate écrit un template dans 'dir' et retourne son chemin.
+func writeTemplate(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadErrorPagesRejectsBrokenTemplates(t *testing.T) {
+	dir := t.TempDir()
+	invalid := writeTemplate(t, dir, "invalide.html", "<p>{{.ShortCode</p>")
+
+	tests := []struct {
+		name  string
+		dir   string
+		files map[int]string
+	}{
+		{"fichier configuré absent", "", map[int]string{http.StatusNotFound: filepath.Join(dir, "absent.html")}},
+		{"fichier configuré invalide", "", map[int]string{http.StatusGone: invalid}},
+	}
+	for _, tt := range tests {
+		if _, err := LoadErrorPages(tt.dir, tt.files); err == nil {
+			t.Errorf("%s: aucune erreur, attendu un échec du chargement", tt.name)
+		}
+	}
+
+	// Un 500.html invalide fait échouer le chargement, au lieu d'écarter sans bruit le 404.html valide
+	templatesDir := t.TempDir()
+	writeTemplate(t, templatesDir, "404.html", "<p>Introuvable : {{.ShortCode}}</p>")
+	writeTemplate(t, templatesDir, "500.html", "<p>{{if}}</p>")
+	if _, err := LoadErrorPages(templatesDir, nil); err == nil || !strings.Contains(err.Error(), "500.html") {
+		t.Errorf("erreur = %v, attendu une erreur sur 500.html", err)
+	}
+}
+
+func TestLoadErrorPagesFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "404.html", "<p>Introuvable : {{.ShortCode}}</p>")
+
+	pages, err := LoadErrorPages(dir, nil)
+	if err != nil {
+		t.Fatalf("LoadErrorPages: %v", err)
+	}
+	if pages.templates[http.StatusNotFound] == defaultNotFoundTemplate {
+		t.Error("404.html du dossier non chargé")
+	}
+	if pages.templates[http.StatusGone] != defaultGoneTemplate {
+		t.Error("page 410 intégrée remplacée alors que 410.html est absent")
+	}
+}
+
+func TestSetupRoutesFailsOnBrokenErrorTemplate(t *testing.T) {
+	cfg := testConfig(t, func(cfg *config.Config) {
+		cfg.Server.NotFoundTemplate = filepath.Join(t.TempDir(), "absent.html")
+	})
+	service := services.NewLinkService(nil, cfg)
+	if err := SetupRoutes(gin.New(), service, cfg, nil, nil, nil, nil); err == nil {
+		t.Error("SetupRoutes: aucune erreur, attendu un échec du démarrage")
+	}
+}
+
+func TestRedirectErrorContentNegotiation(t *testing.T) {
+	dir := t.TempDir()
+	notFound := writeTemplate(t, dir, "introuvable.html", "<p>Introuvable : {{.ShortCode}}</p>")
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.Server.NotFoundTemplate = notFound })
+
+	res := api.do(http.MethodGet, "/missing", "", "Accept", "text/html,application/xhtml+xml")
+	if res.Code != http.StatusNotFound || !strings.Contains(res.Body.String(), "Introuvable : missing") {
+		t.Errorf("navigateur: statut %d, corps %q, attendu la page 404 configurée", res.Code, res.Body.String())
+	}
+	res = api.do(http.MethodGet, "/missing", "", "Accept", "application/json")
+	if res.Code != http.StatusNotFound || !strings.HasPrefix(res.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("client API: statut %d, Content-Type %q, attendu du JSON", res.Code, res.Header().Get("Content-Type"))
+	}
+	// Les endpoints de l'API répondent toujours en JSON
+	res = api.do(http.MethodGet, "/api/v1/links/missing/stats", "", "Accept", "text/html")
+	if !strings.HasPrefix(res.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("API: Content-Type %q, attendu du JSON", res.Header().Get("Content-Type"))
+	}
+}
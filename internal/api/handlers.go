@@ -2,11 +2,17 @@ package api
 
 import (
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/axellelanca/urlshortener/internal/config"
+	apperrors "github.com/axellelanca/urlshortener/internal/errors"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/middleware"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/services"
@@ -14,58 +20,226 @@ import (
 	"gorm.io/gorm" // Pour gérer gorm.ErrRecordNotFound
 )
 
-// ClickEventsChannel est le channel global (ou injecté) utilisé pour envoyer les événements de clic
-// aux workers asynchrones. Il est bufferisé pour ne pas bloquer les requêtes de redirection.
-var ClickEventsChannel chan models.ClickEvent
+// hopsHeader est l'en-tête utilisé pour compter les redirections successives passant par ce service.
+const hopsHeader = "X-Shortener-Hops"
 
 // SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires.
 // Le rate limiter est optionnel (feature bonus) et peut être nil si désactivé.
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService, cfg *config.Config, rateLimiter *middleware.IPRateLimiter) {
-	// Le channel est initialisé ici.
-	if ClickEventsChannel == nil {
-		// Créer le channel bufferisé
-		// La taille du buffer doit être configurable via la donnée récupérée avec Viper
-		ClickEventsChannel = make(chan models.ClickEvent, 1000)
+// clickEvents est le channel bufferisé (analytics.buffer_size) lu par les workers de clics ;
+// il est nil quand aucun clic ne doit être enregistré (analytics désactivées ou lecture seule).
+// appMetrics est nil si monitor.metrics_enabled est désactivé : aucune métrique n'est alors collectée ni exposée.
+// Une erreur est retournée, avant tout enregistrement, si une page d'erreur HTML configurée ne peut pas être chargée.
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, cfg *config.Config, rateLimiter middleware.RateLimiter,
+	rateLimitRules []middleware.RouteRateLimit, clickEvents chan<- models.ClickEvent, appMetrics *metrics.Metrics) error {
+	// Charger les pages d'erreur HTML personnalisées (optionnel). Un template configuré mais illisible ou invalide
+	// empêche le démarrage plutôt que d'être remplacé sans bruit par les pages intégrées.
+	errorPages, err := LoadErrorPages(cfg.Server.ErrorTemplatesDir, map[int]string{
+		http.StatusNotFound: cfg.Server.NotFoundTemplate,
+		http.StatusGone:     cfg.Server.ExpiredTemplate,
+	})
+	if err != nil {
+		return err
 	}
 
-	// Route de Health Check , /health
-	router.GET("/health", HealthCheckHandler)
+	// Identifiant de requête (X-Request-ID) et log d'accès de toutes les requêtes.
+	// Les middlewares doivent être enregistrés avant les routes pour s'y appliquer.
+	router.Use(middleware.RequestIDMiddleware(), middleware.AccessLogMiddleware())
+
+	// CORS pour les clients navigateur d'une autre origine, désactivé sans origine configurée.
+	// Enregistré sur le routeur (et non le groupe /api/v1) pour traiter aussi les preflight OPTIONS sans route dédiée,
+	// et avant le rate limiting pour que les réponses 429 portent elles aussi les en-têtes CORS.
+	if len(cfg.Server.CORSOrigins) > 0 {
+		router.Use(middleware.CORSMiddleware(cfg.Server.CORSOrigins, cfg.Server.CORSAllowCredentials))
+	}
+
+	// Rate limiting (feature bonus) : chaque route de rate_limiter.rules a sa propre limite, et la limite par défaut
+	// ne couvre que les autres routes de rate_limiter.scope (par défaut le groupe /api/v1, redirections exclues).
+	if rateLimiter != nil || len(rateLimitRules) > 0 {
+		router.Use(middleware.RouteRateLimitMiddleware(rateLimitRules, rateLimiter,
+			rateLimitScope(cfg.RateLimiter.Scope), cfg.RateLimiter.Allowlist))
+	}
+
+	// Route de Health Check, /health par défaut (configurable via server.health_path).
+	// Elle est enregistrée avant la route de redirection pour ne pas être capturée comme un short code.
+	healthPath := cfg.Server.HealthPath
+	if healthPath == "" {
+		healthPath = "/health"
+	}
+	if !strings.HasPrefix(healthPath, "/") {
+		healthPath = "/" + healthPath
+	}
+	router.GET(healthPath, HealthCheckHandler(linkService))
+
+	// Métriques Prometheus, enregistrées elles aussi avant la route de redirection
+	if appMetrics != nil {
+		router.GET("/metrics", gin.WrapH(appMetrics.Handler()))
+	}
 
 	// Routes de l'API
 	// Doivent être au format /api/v1/
 	// POST /links
 	// GET /links/:shortCode/stats
 	api := router.Group("/api/v1")
+	if cfg.Server.ReadOnly {
+		api.Use(middleware.ReadOnlyMiddleware())
+	}
+	// Clé d'API exigée sur les écritures si activé ; les redirections et le health check, hors du groupe, restent publics
+	if cfg.Auth.Enabled {
+		api.Use(middleware.APIKeyAuthMiddleware(cfg.Auth.APIKeys, cfg.Security.AdminToken))
+	}
 	{
-		// Appliquer le rate limiter uniquement à la route de création de liens (feature bonus)
-		// Cela protège contre les abus de création massive de liens
-		if rateLimiter != nil {
-			api.POST("/links", middleware.RateLimitMiddleware(rateLimiter), CreateShortLinkHandler(linkService, cfg))
-		} else {
-			api.POST("/links", CreateShortLinkHandler(linkService, cfg))
+		// Index de découverte de l'API (sans authentification : il n'expose aucune donnée)
+		api.GET("", APIIndexHandler(cfg, healthPath))
+		api.GET("/", APIIndexHandler(cfg, healthPath))
+
+		// Blocage des tentatives d'alias infructueuses répétées (optionnel)
+		var aliasThrottle *middleware.FailureThrottle
+		if throttleCfg := cfg.Security.AliasThrottle; throttleCfg.Enabled {
+			aliasThrottle = middleware.NewFailureThrottle("alias_throttle", throttleCfg.MaxFailures,
+				time.Duration(throttleCfg.WindowMinutes)*time.Minute,
+				time.Duration(throttleCfg.CooldownMinutes)*time.Minute)
+		}
+
+		api.POST("/links", CreateShortLinkHandler(linkService, cfg, aliasThrottle, appMetrics))
+		api.GET("/links", ListLinksHandler(linkService, cfg))
+		if cfg.Auth.Enabled {
+			api.GET("/me/stats", OwnerStatsHandler(linkService, cfg))
+		}
+		api.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService, cfg))
+		api.GET("/links/:shortCode/qr", GetLinkQRCodeHandler(linkService, cfg))
+		api.PUT("/links/:shortCode", UpdateLinkHandler(linkService, cfg))
+		api.DELETE("/links/:shortCode", DeleteLinkHandler(linkService, cfg))
+		if cfg.Server.LinkAliases {
+			api.POST("/links/:shortCode/aliases", AddLinkAliasHandler(linkService, cfg))
+		}
+
+		// Routes d'administration, enregistrées uniquement si un jeton admin est configuré
+		if cfg.Security.AdminToken != "" {
+			admin := api.Group("/admin", middleware.AdminAuthMiddleware(cfg.Security.AdminToken))
+			admin.GET("/links/by-creator", ListLinksByCreatorHandler(linkService, cfg))
+			admin.GET("/stats/sources", LinkSourcesStatsHandler(linkService))
+			admin.GET("/stats/report", StatsReportHandler(linkService))
 		}
-		api.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+	}
+
+	// Blocage des mots de passe incorrects répétés sur les liens protégés, partagé par GET et POST
+	var passwordThrottle *middleware.FailureThrottle
+	if throttleCfg := cfg.Security.PasswordThrottle; throttleCfg.Enabled {
+		passwordThrottle = middleware.NewFailureThrottle("password_throttle", throttleCfg.MaxFailures,
+			time.Duration(throttleCfg.WindowMinutes)*time.Minute,
+			time.Duration(throttleCfg.CooldownMinutes)*time.Minute)
 	}
 
 	// Route de Redirection (au niveau racine pour les short codes)
-	router.GET("/:shortCode", RedirectHandler(linkService))
+	router.GET("/:shortCode", RedirectHandler(linkService, cfg, errorPages, clickEvents, appMetrics, passwordThrottle))
+	// Soumission du formulaire de mot de passe des liens protégés
+	router.POST("/:shortCode", RedirectHandler(linkService, cfg, errorPages, clickEvents, appMetrics, passwordThrottle))
+	return nil
+}
+
+// rateLimitScope retourne le filtre des requêtes soumises à la limite par défaut pour rate_limiter.scope :
+// "all" (toutes les requêtes), "api" (le groupe /api/v1) ou "create" (POST /api/v1/links).
+func rateLimitScope(scope string) func(c *gin.Context) bool {
+	switch scope {
+	case "all":
+		return func(*gin.Context) bool { return true }
+	case "api":
+		return func(c *gin.Context) bool {
+			route := c.FullPath()
+			return route == "/api/v1" || strings.HasPrefix(route, "/api/v1/")
+		}
+	default:
+		return func(c *gin.Context) bool {
+			return c.Request.Method == http.MethodPost && c.FullPath() == "/api/v1/links"
+		}
+	}
+}
+
+// apiVersion est la version de l'API exposée sous /api/v1.
+const apiVersion = "v1"
+
+// apiEndpoint décrit une route dans l'index de découverte de l'API.
+type apiEndpoint struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// APIIndexHandler retourne un document de découverte listant les endpoints disponibles (GET /api/v1).
+// Il complète la spécification OpenAPI sans la remplacer.
+func APIIndexHandler(cfg *config.Config, healthPath string) gin.HandlerFunc {
+	endpoints := []apiEndpoint{
+		{Method: http.MethodPost, Path: "/api/v1/links", Description: "Créer une URL courte"},
+		{Method: http.MethodGet, Path: "/api/v1/links", Description: "Lister les URLs courtes (page, page_size, owner=me)"},
+		{Method: http.MethodGet, Path: "/api/v1/links/:shortCode/stats", Description: "Statistiques d'une URL courte (?granularity=day&from=&to= pour la série des clics par jour)"},
+		{Method: http.MethodGet, Path: "/api/v1/links/:shortCode/qr", Description: "QR code PNG de l'URL courte (size, 256 par défaut)"},
+		{Method: http.MethodPut, Path: "/api/v1/links/:shortCode", Description: "Modifier la destination d'une URL courte"},
+		{Method: http.MethodDelete, Path: "/api/v1/links/:shortCode", Description: "Supprimer une URL courte et ses statistiques"},
+	}
+	if cfg.Auth.Enabled {
+		endpoints = append(endpoints, apiEndpoint{Method: http.MethodGet, Path: "/api/v1/me/stats",
+			Description: "Statistiques de tous les liens de la clé d'API (liens, clics, actifs/expirés, 5 plus cliqués)"})
+	}
+	if cfg.Server.LinkAliases {
+		endpoints = append(endpoints, apiEndpoint{Method: http.MethodPost, Path: "/api/v1/links/:shortCode/aliases",
+			Description: "Ajouter un code supplémentaire partageant la destination et les statistiques du lien"})
+	}
+	if cfg.Security.AdminToken != "" {
+		endpoints = append(endpoints, apiEndpoint{Method: http.MethodGet, Path: "/api/v1/admin/links/by-creator",
+			Description: "Liens créés depuis une IP (administrateurs)"},
+			apiEndpoint{Method: http.MethodGet, Path: "/api/v1/admin/stats/sources",
+				Description: "Nombre de liens par chemin de création (administrateurs)"},
+			apiEndpoint{Method: http.MethodGet, Path: "/api/v1/admin/stats/report",
+				Description: "Rapport de tous les liens en CSV ou JSON (format, since ; administrateurs)"})
+	}
+	endpoints = append(endpoints,
+		apiEndpoint{Method: http.MethodGet, Path: "/:shortCode", Description: "Rediriger vers l'URL longue"},
+		apiEndpoint{Method: http.MethodGet, Path: healthPath, Description: "Health check"},
+	)
+
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"version":   apiVersion,
+			"endpoints": endpoints,
+		})
+	}
 }
 
 // HealthCheckHandler gère la route /health pour vérifier l'état du service.
-func HealthCheckHandler(c *gin.Context) {
-	// Retourner simplement du JSON avec un StatusOK, {"status": "ok"}
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+// L'état du circuit breaker de création est exposé lorsqu'il est activé.
+func HealthCheckHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Retourner du JSON avec un StatusOK, {"status": "ok"}
+		response := gin.H{"status": "ok"}
+		if state := linkService.CircuitBreakerState(); state != "" {
+			response["circuit_breaker"] = state
+		}
+		c.JSON(http.StatusOK, response)
+	}
 }
 
 // CreateLinkRequest représente le corps de la requête JSON pour la création d'un lien.
 type CreateLinkRequest struct {
-	LongURL           string `json:"long_url" binding:"required,url"` // 'binding:required' pour validation, 'url' pour format URL
-	CustomAlias       string `json:"custom_alias,omitempty"`          // Alias personnalisé optionnel (feature bonus)
-	ExpirationMinutes int    `json:"expiration_minutes,omitempty"`    // Durée de vie du lien en minutes (optionnel, feature bonus)
+	LongURL           string `json:"long_url" binding:"required"`  // 'binding:required' pour validation, le format est validé par le service (schéma par défaut éventuel)
+	CustomAlias       string `json:"custom_alias,omitempty"`       // Alias personnalisé optionnel (feature bonus)
+	ExpirationMinutes int    `json:"expiration_minutes,omitempty"` // Durée de vie du lien en minutes (optionnel, feature bonus)
+	TrackClicks       *bool  `json:"track_clicks,omitempty"`       // false pour ne pas enregistrer les clics de ce lien (optionnel)
+	Force             bool   `json:"force,omitempty"`              // Autoriser un alias réservé (administrateurs uniquement)
+	CodeLength        int    `json:"code_length,omitempty"`        // Longueur du code généré, dans les bornes configurées (optionnel)
+	MaxClicks         int    `json:"max_clicks,omitempty"`         // Nombre de clics après lequel le lien expire (optionnel)
+	Password          string `json:"password,omitempty"`           // Mot de passe demandé avant la redirection (optionnel)
+	// Date d'expiration absolue au format RFC 3339 (optionnel, exclusif avec expiration_minutes)
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Rediriger directement, sans la page intermédiaire de server.preview_redirect (optionnel)
+	SkipPreview bool `json:"skip_preview,omitempty"`
 }
 
 // CreateShortLinkHandler gère la création d'une URL courte.
-func CreateShortLinkHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+// aliasThrottle est optionnel (nil si désactivé) et bloque les IPs qui enchaînent les alias pris ou invalides.
+func CreateShortLinkHandler(linkService *services.LinkService, cfg *config.Config, aliasThrottle *middleware.FailureThrottle,
+	appMetrics *metrics.Metrics) gin.HandlerFunc {
+	aliasThrottleWhitelist := middleware.NewIPList(cfg.Security.AliasThrottle.Whitelist)
+	quotaWhitelist := middleware.NewIPList(cfg.Security.CreateQuota.Whitelist)
 	return func(c *gin.Context) {
 		var req CreateLinkRequest
 		// Tente de lier le JSON de la requête à la structure CreateLinkRequest.
@@ -75,119 +249,927 @@ func CreateShortLinkHandler(linkService *services.LinkService, cfg *config.Confi
 			return
 		}
 
+		// Une seule façon d'indiquer l'expiration : durée relative ou date absolue
+		if req.ExpirationMinutes > 0 && req.ExpiresAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiration_minutes et expires_at ne peuvent pas être fournis ensemble"})
+			return
+		}
+
+		// La limite de clics s'appuie sur le compteur de redirections du lien, tenu même sans suivi des clics.
+		if req.MaxClicks < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_clicks doit être positif"})
+			return
+		}
+
+		// "force" lève la vérification des mots réservés et n'est accepté que des administrateurs.
+		if req.Force && !middleware.IsAdmin(c, cfg.Security.AdminToken) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "L'option force est réservée aux administrateurs"})
+			return
+		}
+
 		var link *models.Link
 		var err error
+		var reused bool // Lien existant retourné (server.dedupe_urls)
+
+		// Les IPs bloquées pour des tentatives d'alias répétées reçoivent un 429 pendant le cooldown.
+		throttleAlias := aliasThrottle != nil && req.CustomAlias != "" &&
+			!aliasThrottleWhitelist.Contains(c.ClientIP())
+		if throttleAlias {
+			if blockedUntil := aliasThrottle.BlockedUntil(c.ClientIP()); !blockedUntil.IsZero() {
+				c.Header("Retry-After", fmt.Sprintf("%d", int(time.Until(blockedUntil).Seconds())+1))
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"error":    "Trop de tentatives d'alias infructueuses. Veuillez réessayer plus tard.",
+					"reset_at": blockedUntil.Format(time.RFC3339),
+				})
+				return
+			}
+		}
+
+		// Capturer l'IP du créateur si activé (pour les investigations d'abus).
+		// c.ClientIP() respecte la configuration des proxies de confiance de Gin.
+		opts := services.CreateLinkOptions{
+			Source:        models.LinkSourceAPI,
+			TrackClicks:   req.TrackClicks,
+			AllowReserved: req.Force,
+			CodeLength:    req.CodeLength,
+			MaxClicks:     req.MaxClicks,
+			Password:      req.Password,
+			SkipPreview:   req.SkipPreview,
+			OwnerID:       middleware.OwnerID(c),
+		}
+		if cfg.Security.StoreCreatorIP {
+			opts.CreatorIP = c.ClientIP()
+		}
+		// Création simple : la seule qui peut réutiliser un lien existant (server.dedupe_urls)
+		plainCreate := req.CustomAlias == "" && req.ExpirationMinutes == 0 && req.ExpiresAt == nil && req.Password == ""
+
+		// Appliquer le quota de création par IP si activé (les IPs en whitelist en sont exemptées).
+		// quotaRemaining est le nombre de créations encore permises avant cette requête, -1 si aucun quota ne s'applique.
+		// Seule une création consomme le quota : la réutilisation d'un lien existant reste possible une fois le quota atteint.
+		quota := cfg.Security.CreateQuota
+		quotaRemaining := -1
+		if quota.Enabled && !quotaWhitelist.Contains(c.ClientIP()) {
+			created, err := linkService.CountRecentLinksByCreator(c.ClientIP(), time.Duration(quota.WindowHours)*time.Hour)
+			if err != nil {
+				slog.Error("Error checking create quota", "ip", c.ClientIP(), "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short link"})
+				return
+			}
+			quotaRemaining = max(quota.MaxLinks-created, 0)
+			if quotaRemaining == 0 {
+				if plainCreate {
+					link, err = linkService.FindReusableLink(req.LongURL, opts)
+					if err != nil {
+						slog.Warn("Error looking up reusable link", "ip", c.ClientIP(), "error", err)
+					}
+					reused = link != nil
+				}
+				if !reused {
+					slog.Warn("Quota de création atteint", "ip", c.ClientIP(), "max_links", quota.MaxLinks, "window_hours", quota.WindowHours)
+					setQuotaHeaders(c, quota.MaxLinks, 0)
+					c.JSON(http.StatusTooManyRequests, gin.H{
+						"error":        "Quota de création de liens atteint. Veuillez réessayer plus tard.",
+						"max_links":    quota.MaxLinks,
+						"window_hours": quota.WindowHours,
+					})
+					return
+				}
+			}
+		}
 
 		// Vérifier si un alias personnalisé a été fourni (feature bonus)
-		if req.CustomAlias != "" {
+		if reused {
+			// Quota atteint, mais un lien existant vers la même URL est réutilisé : rien n'est créé
+			slog.Info("Lien existant réutilisé malgré le quota atteint", "short_code", link.ShortCode, "ip", c.ClientIP())
+		} else if req.CustomAlias != "" {
 			// Créer le lien avec l'alias personnalisé
-			log.Printf("Création d'un lien avec alias personnalisé: %s", req.CustomAlias)
-			link, err = linkService.CreateLinkWithCustomAlias(req.LongURL, req.CustomAlias)
+			slog.Info("Création d'un lien avec alias personnalisé", "alias", req.CustomAlias, "ip", c.ClientIP())
+			link, err = linkService.CreateLinkWithCustomAlias(req.LongURL, req.CustomAlias, opts)
 		} else if req.ExpirationMinutes > 0 {
 			// Créer le lien avec expiration
-			log.Printf("Création d'un lien avec expiration: %d minutes", req.ExpirationMinutes)
-			link, err = linkService.CreateLinkWithExpiration(req.LongURL, req.ExpirationMinutes)
+			slog.Info("Création d'un lien avec expiration", "expiration_minutes", req.ExpirationMinutes, "ip", c.ClientIP())
+			link, err = linkService.CreateLinkWithExpiration(req.LongURL, req.ExpirationMinutes, opts)
+		} else if req.ExpiresAt != nil {
+			// Créer le lien avec une date d'expiration absolue
+			slog.Info("Création d'un lien avec date d'expiration", "expires_at", req.ExpiresAt.Format(time.RFC3339), "ip", c.ClientIP())
+			link, err = linkService.CreateLinkExpiringAt(req.LongURL, *req.ExpiresAt, opts)
+		} else if req.Password != "" {
+			// Créer un lien protégé par mot de passe
+			slog.Info("Création d'un lien protégé par mot de passe", "ip", c.ClientIP())
+			link, err = linkService.CreateProtectedLink(req.LongURL, req.Password, opts)
 		} else {
-			// Créer le lien sans options spéciales
-			link, err = linkService.CreateLink(req.LongURL)
+			// Créer le lien sans options spéciales (ou réutiliser un lien existant si server.dedupe_urls)
+			link, reused, err = linkService.CreateOrReuseLink(req.LongURL, opts)
 		}
 
 		if err != nil {
-			log.Printf("Error creating link: %v", err)
+			slog.Warn("Error creating link", "ip", c.ClientIP(), "error", err)
+			// Si le circuit breaker est ouvert, la base de données n'a pas été sollicitée : 503
+			var circuitErr *apperrors.ErrCircuitOpen
+			if errors.As(err, &circuitErr) {
+				c.Header("Retry-After", fmt.Sprintf("%d", int(circuitErr.RetryAfter.Seconds())+1))
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": circuitErr.Error()})
+				return
+			}
+			// Alias pris ou invalide : 400, compté pour le blocage des tentatives répétées
+			var aliasErr *apperrors.ErrInvalidAlias
+			if errors.As(err, &aliasErr) {
+				if throttleAlias {
+					aliasThrottle.RecordFailure(c.ClientIP())
+				}
+				c.JSON(http.StatusBadRequest, gin.H{"error": aliasErr.Error()})
+				return
+			}
+			// Aucun code libre trouvé après server.max_generation_retries tirages : 503, la requête peut être retentée
+			var generationErr *apperrors.ErrCodeGenerationFailed
+			if errors.As(err, &generationErr) {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": generationErr.Error()})
+				return
+			}
+			// Longueur de code court hors des bornes configurées : 400
+			var lengthErr *apperrors.ErrInvalidCodeLength
+			if errors.As(err, &lengthErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": lengthErr.Error()})
+				return
+			}
+			// Mot de passe trop court ou trop long : 400
+			var passwordErr *apperrors.ErrInvalidPassword
+			if errors.As(err, &passwordErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": passwordErr.Error()})
+				return
+			}
+			// URL invalide, refusée ou impossible à vérifier
+			if respondURLError(c, err) {
+				return
+			}
 			// Si l'erreur concerne un alias personnalisé ou une durée d'expiration invalide, retourner un BadRequest
-			if req.CustomAlias != "" || req.ExpirationMinutes > 0 {
+			if req.CustomAlias != "" || req.ExpirationMinutes > 0 || req.ExpiresAt != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			} else {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short link"})
 			}
 			return
 		}
+		if !reused {
+			appMetrics.LinkCreated()
+		}
 
 		// Préparer la réponse JSON
-		response := gin.H{
-			"short_code":     link.ShortCode,
-			"long_url":       link.LongURL,
-			"full_short_url": cfg.Server.BaseURL + "/" + link.ShortCode,
+		response := linkResponse(link, cfg.Server.BaseURL, time.Now())
+
+		// Lien existant réutilisé : rien n'a été créé, 200 et aucune unité de quota consommée
+		if reused {
+			response["reused"] = true
+			if quotaRemaining >= 0 {
+				setQuotaHeaders(c, quota.MaxLinks, quotaRemaining)
+			}
+			c.JSON(http.StatusOK, response)
+			return
 		}
 
-		// Ajouter un indicateur si c'est un alias personnalisé
-		if link.IsCustom {
-			response["is_custom"] = true
+		// Le lien créé consomme une unité du quota
+		if quotaRemaining >= 0 {
+			setQuotaHeaders(c, quota.MaxLinks, quotaRemaining-1)
 		}
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// setQuotaHeaders expose le quota de création et le nombre de créations restantes sur la fenêtre,
+// sur le modèle des en-têtes X-RateLimit-*, pour que les clients puissent ralentir avant un 429.
+func setQuotaHeaders(c *gin.Context, limit, remaining int) {
+	c.Header("X-Create-Quota-Limit", fmt.Sprintf("%d", limit))
+	c.Header("X-Create-Quota-Remaining", fmt.Sprintf("%d", remaining))
+}
+
+// requireLinkWriteAccess vérifie que la requête peut modifier ou supprimer le lien 'shortCode'.
+// Avec l'authentification (auth.enabled), les règles de propriété de requireLinkOwner s'appliquent.
+// Sans elle, ces opérations sont réservées aux administrateurs : le jeton admin est exigé (401 sinon),
+// et elles sont donc indisponibles tant que security.admin_token n'est pas configuré.
+func requireLinkWriteAccess(c *gin.Context, linkService *services.LinkService, cfg *config.Config, shortCode string) bool {
+	if cfg.Auth.Enabled {
+		return requireLinkOwner(c, linkService, cfg, shortCode)
+	}
+	if middleware.IsAdmin(c, cfg.Security.AdminToken) {
+		return true
+	}
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentification administrateur requise"})
+	return false
+}
+
+// requireLinkOwner vérifie, quand l'authentification est activée, que la requête peut agir sur le lien 'shortCode' :
+// le jeton admin passe toujours, sinon le lien doit être sans propriétaire ou appartenir à la clé d'API utilisée.
+// Elle répond 404, 401 (requête anonyme), 403 ou 500 et retourne false si ce n'est pas le cas.
+func requireLinkOwner(c *gin.Context, linkService *services.LinkService, cfg *config.Config, shortCode string) bool {
+	if !cfg.Auth.Enabled || middleware.IsAdmin(c, cfg.Security.AdminToken) {
+		return true
+	}
 
-		// Ajouter la date d'expiration si le lien expire
-		if link.ExpiresAt != nil {
-			response["expires_at"] = link.ExpiresAt.Format(time.RFC3339)
-			response["expires_in_minutes"] = int(time.Until(*link.ExpiresAt).Minutes())
+	ownerID := middleware.OwnerID(c)
+	err := linkService.CheckLinkOwner(shortCode, ownerID)
+	if err == nil {
+		return true
+	}
+	var ownerErr *apperrors.ErrNotLinkOwner
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+	case errors.As(err, &ownerErr) && ownerID == "":
+		c.Header("WWW-Authenticate", `Bearer realm="api"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Clé d'API requise pour ce lien"})
+	case errors.As(err, &ownerErr):
+		c.JSON(http.StatusForbidden, gin.H{"error": ownerErr.Error()})
+	default:
+		slog.Error("Error checking link owner", "short_code", shortCode, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+	return false
+}
+
+// respondURLError répond aux erreurs de validation d'une URL longue et indique si l'erreur a été traitée :
+// URL invalide (400), domaine bloqué ou URL refusée par le service de vérification (403)
+// ou service injoignable en mode fail-closed (503).
+func respondURLError(c *gin.Context, err error) bool {
+	// URL invalide (ex: domaine internationalisé non convertible) : 400
+	var invalidErr *apperrors.ErrInvalidURL
+	if errors.As(err, &invalidErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidErr.Error()})
+		return true
+	}
+	// Domaine présent dans security.blocked_domains : 403
+	var blockedErr *apperrors.ErrDomainBlocked
+	if errors.As(err, &blockedErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": blockedErr.Error()})
+		return true
+	}
+	// URL refusée par le service de vérification externe : 403 avec la raison
+	var deniedErr *apperrors.ErrURLDenied
+	if errors.As(err, &deniedErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "URL refusée", "reason": deniedErr.Reason})
+		return true
+	}
+	// Service de vérification injoignable en mode fail-closed : 503
+	var checkErr *apperrors.ErrURLCheckUnavailable
+	if errors.As(err, &checkErr) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "URL verification service unavailable"})
+		return true
+	}
+	return false
+}
+
+// UpdateLinkRequest représente le corps de la requête JSON de modification de la destination d'un lien.
+type UpdateLinkRequest struct {
+	LongURL string `json:"long_url" binding:"required"` // Même règle que CreateLinkRequest : le format est validé par le service
+}
+
+// UpdateLinkHandler gère la modification de la destination d'une URL courte (PUT /api/v1/links/:shortCode).
+// Les alias personnalisés sont modifiables comme les codes générés ; le code court reste inchangé.
+// Réservé au propriétaire du lien, ou aux administrateurs sans authentification (voir requireLinkWriteAccess).
+func UpdateLinkHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if !requireLinkWriteAccess(c, linkService, cfg, shortCode) {
+			return
 		}
 
-		c.JSON(http.StatusCreated, response)
+		link, err := linkService.UpdateLongURL(shortCode, req.LongURL)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			if respondURLError(c, err) {
+				return
+			}
+			slog.Warn("Error updating link", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		slog.Info("Destination du lien modifiée", "short_code", shortCode, "long_url", link.LongURL)
+		c.JSON(http.StatusOK, linkResponse(link, cfg.Server.BaseURL, time.Now()))
 	}
 }
 
 // RedirectHandler gère la redirection d'une URL courte vers l'URL longue et l'enregistrement asynchrone des clics.
 // Vérifie également si le lien a expiré (feature bonus).
-func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
+// Si server.forward_query_params est activé, la query string entrante est fusionnée dans l'URL de destination.
+// Les erreurs 404/410/500 sont rendues avec les pages HTML personnalisées pour les navigateurs, si configurées.
+// passwordThrottle est optionnel (nil si désactivé) et bloque une IP qui enchaîne les mots de passe incorrects sur un lien.
+func RedirectHandler(linkService *services.LinkService, cfg *config.Config, errorPages *ErrorPages,
+	clickEvents chan<- models.ClickEvent, appMetrics *metrics.Metrics, passwordThrottle *middleware.FailureThrottle) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Récupère le shortCode de l'URL avec c.Param
 		shortCode := c.Param("shortCode")
 
-		// Récupérer l'URL longue associée au shortCode depuis le linkService (GetLinkByShortCode)
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		// Compter la redirection par résultat et mesurer sa durée (métriques Prometheus, si activées)
+		if appMetrics != nil {
+			start := time.Now()
+			defer func() {
+				appMetrics.Redirect(c.Writer.Status(), time.Since(start))
+			}()
+		}
+
+		// Mesurer séparément le temps de lookup et le temps total de la redirection si activé,
+		// pour distinguer une lenteur de la base de données d'une lenteur du reste du traitement.
+		var lookupDuration time.Duration
+		if cfg.Server.LogLatency {
+			start := time.Now()
+			defer func() {
+				slog.Info("Redirection traitée", "component", "latency", "request_id", middleware.RequestIDFromContext(c.Request.Context()),
+					"short_code", shortCode, "status", c.Writer.Status(), "ip", c.ClientIP(), "lookup_ms", lookupDuration.Seconds()*1000, "latency_ms", time.Since(start).Seconds()*1000)
+			}()
+		}
+
+		// Détection de boucle : chaque passage incrémente le compteur de sauts transmis dans X-Shortener-Hops.
+		// Cela ne fonctionne que si le client relaie l'en-tête d'une redirection à l'autre
+		// (proxy ou client HTTP interne), c'est-à-dire quand notre service est lui-même dans la chaîne.
+		hops, _ := strconv.Atoi(c.GetHeader(hopsHeader))
+		hops++
+		if cfg.Server.MaxRedirectHops > 0 && hops > cfg.Server.MaxRedirectHops {
+			slog.Warn("Redirect loop detected", "short_code", shortCode, "hops", hops, "ip", c.ClientIP())
+			c.JSON(http.StatusLoopDetected, gin.H{"error": "Redirect loop detected", "hops": hops})
+			return
+		}
+
+		// Récupérer le lien associé au shortCode depuis le linkService.
+		// Pour un alias, c'est le lien canonique qui est servi et qui reçoit le clic.
+		lookupStart := time.Now()
+		link, err := linkService.ResolveLink(shortCode)
+		lookupDuration = time.Since(lookupStart)
 
 		if err != nil {
 			// Si le lien n'est pas trouvé, retourner HTTP 404 Not Found.
 			// Utiliser errors.Is et l'erreur Gorm
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				respondError(c, errorPages, http.StatusNotFound, ErrorPageData{ShortCode: shortCode},
+					gin.H{"error": "Short code not found"})
 				return
 			}
 			// Gérer d'autres erreurs potentielles de la base de données ou du service
-			log.Printf("Error retrieving link for %s: %v", shortCode, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			slog.Error("Error retrieving link", "short_code", shortCode, "error", err)
+			respondError(c, errorPages, http.StatusInternalServerError, ErrorPageData{ShortCode: shortCode},
+				gin.H{"error": "Internal server error"})
 			return
 		}
 
 		// Vérifier si le lien a expiré (feature bonus)
 		if link.IsExpired() {
-			log.Printf("Link %s has expired (expired at: %v)", shortCode, link.ExpiresAt)
-			c.JSON(http.StatusGone, gin.H{
-				"error":      "This link has expired",
-				"expired_at": link.ExpiresAt.Format(time.RFC3339),
+			slog.Info("Link has expired", "short_code", shortCode, "expired_at", *link.ExpiresAt, "status", http.StatusGone)
+			expiredAt := link.ExpiresAt.Format(time.RFC3339)
+			respondError(c, errorPages, http.StatusGone, ErrorPageData{ShortCode: shortCode, ExpiredAt: expiredAt},
+				gin.H{
+					"error":      "This link has expired",
+					"expired_at": expiredAt,
+				})
+			return
+		}
+
+		// Destination injoignable à la dernière vérification avec monitor.unreachable: warn :
+		// le lien reste servi, le client est averti par un en-tête Warning.
+		if !link.IsActive && link.InactiveReason == models.InactiveReasonUnreachable && cfg.Monitor.Unreachable == "warn" {
+			slog.Warn("Destination was unreachable at last check, redirecting anyway", "short_code", shortCode)
+			c.Header("Warning", `199 - "destination unreachable at last check"`)
+		} else if !link.IsActive {
+			// Un lien désactivé par le moniteur n'est plus servi. La raison est donnée de façon générique :
+			// 403 si la destination a été signalée comme dangereuse, 410 sinon.
+			slog.Info("Link is disabled", "short_code", shortCode, "reason", link.InactiveReason)
+			status, message := http.StatusGone, "This link has been disabled"
+			if link.InactiveReason == models.InactiveReasonMalware {
+				status, message = http.StatusForbidden, "This link has been disabled because its destination was flagged as unsafe"
+			}
+			respondError(c, errorPages, status, ErrorPageData{ShortCode: shortCode},
+				gin.H{"error": message})
+			return
+		}
+
+		// Un lien limité en nombre de clics expire une fois la limite atteinte. Ce premier contrôle, sur le compteur
+		// de redirections chargé avec le lien, évite d'afficher formulaire ou page intermédiaire pour un lien épuisé ;
+		// la limite est garantie par l'incrément conditionnel de RecordRedirect, juste avant la redirection.
+		if link.MaxClicks != nil && link.RedirectCount >= *link.MaxClicks {
+			respondClickLimitReached(c, errorPages, link)
+			return
+		}
+
+		// Un lien protégé ne redirige qu'avec le bon mot de passe, transmis par le formulaire (POST) uniquement :
+		// dans la query string, il finirait dans les logs, l'historique et l'en-tête Referer.
+		// Le clic n'est enregistré qu'une fois le mot de passe vérifié.
+		query := c.Request.URL.Query()
+		if link.IsProtected() {
+			// Ne jamais transmettre à la destination un mot de passe passé par erreur dans l'URL
+			query.Del("password")
+			password := c.PostForm("password")
+			if password == "" {
+				respondPasswordRequired(c, shortCode, "")
+				return
+			}
+			// Les tentatives sont limitées par IP et par code : une IP bloquée reçoit 429 pendant le cooldown,
+			// même avec le bon mot de passe.
+			throttleKey := c.ClientIP() + "|" + shortCode
+			if passwordThrottle != nil {
+				if blockedUntil := passwordThrottle.BlockedUntil(throttleKey); !blockedUntil.IsZero() {
+					c.Header("Retry-After", fmt.Sprintf("%d", int(time.Until(blockedUntil).Seconds())+1))
+					c.JSON(http.StatusTooManyRequests, gin.H{
+						"error":    "Too many wrong passwords. Please try again later.",
+						"reset_at": blockedUntil.Format(time.RFC3339),
+					})
+					return
+				}
+			}
+			valid, err := linkService.CheckLinkPassword(link, password)
+			if err != nil {
+				slog.Error("Error checking link password", "short_code", shortCode, "error", err)
+				respondError(c, errorPages, http.StatusInternalServerError, ErrorPageData{ShortCode: shortCode},
+					gin.H{"error": "Internal server error"})
+				return
+			}
+			if !valid {
+				slog.Warn("Wrong password for protected link", "short_code", shortCode, "ip", c.ClientIP())
+				if passwordThrottle != nil {
+					passwordThrottle.RecordFailure(throttleKey)
+				}
+				respondPasswordRequired(c, shortCode, "Mot de passe incorrect")
+				return
+			}
+		}
+
+		// Les clients API (Accept: application/json ou en-tête X-No-Redirect) peuvent recevoir
+		// la destination dans le corps au lieu d'une redirection, si l'option est activée.
+		jsonResolve := cfg.Server.JSONResolve.Enabled && wantsJSONResolve(c)
+
+		// Page intermédiaire (server.preview_redirect) : contournée par ?preview=false, par les liens créés avec skip_preview,
+		// par les liens protégés (le formulaire de mot de passe en tient lieu) et par les résolutions JSON.
+		// Le paramètre preview n'est jamais transmis à la destination.
+		showPreview := cfg.Server.PreviewRedirect && !link.SkipPreview && !link.IsProtected() && !jsonResolve &&
+			query.Get("preview") != "false"
+		query.Del("preview")
+
+		// Fusionner les paramètres de la requête entrante dans la destination si activé.
+		// Sinon, les paramètres entrants sont ignorés.
+		destination := link.LongURL
+		if cfg.Server.ForwardQueryParams && len(query) > 0 {
+			incomingWins := cfg.Server.QueryParamConflict == "incoming"
+			merged, err := mergeQueryParams(link.LongURL, query, incomingWins)
+			if err != nil {
+				// L'URL stockée a été validée à la création, on se contente de la servir telle quelle.
+				slog.Warn("Impossible de fusionner la query string", "short_code", shortCode, "error", err)
+			} else {
+				destination = merged
+			}
+		}
+
+		// Le clic sera enregistré quand le visiteur confirmera depuis la page intermédiaire, pas à son affichage.
+		if showPreview {
+			continueQuery := url.Values{}
+			for key, values := range query {
+				continueQuery[key] = values
+			}
+			continueQuery.Set("preview", "false")
+			respondPreview(c, shortCode, destination, "/"+shortCode+"?"+continueQuery.Encode())
+			return
+		}
+
+		// Compter la redirection sur le lien, uniquement si le compteur sert : en-tête X-Total-Clicks activé,
+		// ou limite max_clicks à faire respecter de façon atomique. Sinon, la redirection n'écrit rien en base.
+		// Le compteur est tenu même sans analytics ni suivi des clics, et compte chaque redirection servie :
+		// un clic écarté par la déduplication des analytics (analytics.dedup_window_seconds) consomme tout de même la limite.
+		// En lecture seule, rien n'est écrit : max_clicks n'est appliqué que par le contrôle du compteur chargé
+		// ci-dessus, et X-Total-Clicks expose ce compteur tel quel.
+		clickCount := link.RedirectCount
+		var countErr error
+		if !cfg.Server.ReadOnly && (cfg.Server.ExposeClickCountHeader || link.MaxClicks != nil) {
+			clickCount, countErr = linkService.RecordRedirect(link)
+			var limitErr *apperrors.ErrClickLimitReached
+			switch {
+			case errors.As(countErr, &limitErr):
+				respondClickLimitReached(c, errorPages, link)
+				return
+			case countErr != nil && link.MaxClicks != nil:
+				// Sans compteur, la limite ne peut pas être garantie : ne pas servir le lien.
+				slog.Error("Error counting redirect", "short_code", shortCode, "error", countErr)
+				respondError(c, errorPages, http.StatusInternalServerError, ErrorPageData{ShortCode: shortCode},
+					gin.H{"error": "Internal server error"})
+				return
+			case countErr != nil:
+				slog.Warn("Impossible d'incrémenter le compteur de redirections", "short_code", shortCode, "error", countErr)
+			}
+		}
+
+		// Enregistrer le clic uniquement si les clics sont collectés (channel absent si les analytics sont désactivées
+		// ou en lecture seule) et que le lien n'a pas désactivé le suivi.
+		if clickEvents != nil && link.TracksClicks() && (!jsonResolve || cfg.Server.JSONResolve.RecordClick) {
+			if !enqueueClick(c, clickEvents, link, models.ServedPathPrimary) {
+				appMetrics.ClickDropped()
+			}
+		}
+
+		// Indiquer au navigateur de préconnecter l'origine de destination si activé.
+		if cfg.Server.EmitPreconnect {
+			if origin := destinationOrigin(destination); origin != "" {
+				c.Header("Link", "<"+origin+">; rel=preconnect")
+			}
+		}
+
+		// Exposer le nombre de redirections servies par le lien, celle-ci comprise (hors lecture seule), si activé.
+		// La valeur vient du compteur incrémenté ci-dessus : aucune requête de comptage supplémentaire.
+		if cfg.Server.ExposeClickCountHeader && countErr == nil {
+			c.Header("X-Total-Clicks", strconv.Itoa(clickCount))
+		}
+
+		// Transmettre le compteur de sauts pour la détection de boucle
+		c.Header(hopsHeader, strconv.Itoa(hops))
+
+		if jsonResolve {
+			c.JSON(http.StatusOK, gin.H{
+				"short_code": link.ShortCode,
+				"long_url":   destination,
 			})
 			return
 		}
 
-		// Créer un ClickEvent avec les informations pertinentes.
-		clickEvent := models.ClickEvent{
-			LinkID:    link.ID,
-			Timestamp: time.Now(),
-			UserAgent: c.Request.UserAgent(),
-			IPAddress: c.ClientIP(),
+		// N'émettre que des URLs absolues dans Location : une URL relative stockée par erreur
+		// (anciennes données) serait résolue par le navigateur par rapport à notre propre domaine.
+		if u, err := url.Parse(destination); err != nil || !u.IsAbs() || u.Host == "" {
+			slog.Error("Destination non absolue", "short_code", shortCode, "destination", destination)
+			respondError(c, errorPages, http.StatusInternalServerError, ErrorPageData{ShortCode: shortCode},
+				gin.H{"error": "Internal server error"})
+			return
 		}
 
-		// Envoyer le ClickEvent dans le ClickEventsChannel avec le Multiplexage.
-		// Utilise un `select` avec un `default` pour éviter de bloquer si le channel est plein.
-		select {
-		case ClickEventsChannel <- clickEvent:
-			// Événement envoyé avec succès
-		default:
-			log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+		// Effectuer la redirection HTTP vers l'URL longue (302 par défaut, configurable via server.redirect_status).
+		// Le formulaire de mot de passe (POST) est toujours suivi d'un 303 : avec un 307 ou un 308, le navigateur
+		// renverrait le POST et le mot de passe du lien à la destination.
+		status := cfg.Server.RedirectStatus
+		if c.Request.Method == http.MethodPost {
+			status = http.StatusSeeOther
 		}
+		c.Redirect(status, destination)
+	}
+}
+
+// respondClickLimitReached répond 410 pour un lien ayant servi son nombre maximal de redirections.
+func respondClickLimitReached(c *gin.Context, errorPages *ErrorPages, link *models.Link) {
+	slog.Info("Link has reached its click limit", "short_code", link.ShortCode, "max_clicks", *link.MaxClicks, "status", http.StatusGone)
+	respondError(c, errorPages, http.StatusGone, ErrorPageData{ShortCode: link.ShortCode},
+		gin.H{
+			"error":      "This link has reached its maximum number of clicks",
+			"max_clicks": *link.MaxClicks,
+		})
+}
 
-		// Effectuer la redirection HTTP 302 (StatusFound) vers l'URL longue.
-		c.Redirect(http.StatusFound, link.LongURL)
+// enqueueClick envoie un ClickEvent pour le lien dans le channel des clics sans jamais bloquer la requête.
+// Retourne false si le channel était plein et l'événement perdu.
+func enqueueClick(c *gin.Context, clickEvents chan<- models.ClickEvent, link *models.Link, servedPath string) bool {
+	// Un clic sans en-tête Referer est attribué à un accès direct
+	referrer := c.Request.Referer()
+	if referrer == "" {
+		referrer = models.ReferrerDirect
+	} else if len(referrer) > models.ReferrerMaxLength {
+		referrer = referrer[:models.ReferrerMaxLength]
+	}
+
+	// Créer un ClickEvent avec les informations pertinentes.
+	clickEvent := models.ClickEvent{
+		LinkID:     link.ID,
+		Timestamp:  time.Now(),
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
+		ServedPath: servedPath,
+		Referrer:   referrer,
+		RequestID:  middleware.RequestIDFromContext(c.Request.Context()),
+	}
+
+	// Envoyer le ClickEvent dans le channel avec le Multiplexage.
+	// Utilise un `select` avec un `default` pour éviter de bloquer si le channel est plein.
+	select {
+	case clickEvents <- clickEvent:
+		// Événement envoyé avec succès
+		return true
+	default:
+		slog.Warn("Click events channel is full, dropping click event", "request_id", clickEvent.RequestID,
+			"short_code", link.ShortCode, "ip", clickEvent.IPAddress)
+		return false
+	}
+}
+
+// wantsJSONResolve indique si le client demande la destination en JSON plutôt qu'une redirection :
+// en-tête X-No-Redirect présent, ou Accept préférant application/json à text/html.
+// Un navigateur (Accept: text/html) ou un client sans préférence (*/*) reçoit la redirection.
+func wantsJSONResolve(c *gin.Context) bool {
+	if c.GetHeader("X-No-Redirect") != "" {
+		return true
+	}
+	if c.GetHeader("Accept") == "" {
+		return false
+	}
+	return c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+}
+
+// destinationOrigin retourne l'origine (schéma://hôte[:port]) d'une URL de destination HTTP(S),
+// ou une chaîne vide pour les autres schémas ou une URL invalide.
+func destinationOrigin(destination string) string {
+	u, err := url.Parse(destination)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// mergeQueryParams fusionne les paramètres 'incoming' dans la query string de 'longURL'.
+// Lorsqu'une clé existe des deux côtés, incomingWins détermine quelle valeur est conservée :
+// true pour les valeurs de la requête entrante, false pour celles de l'URL stockée.
+func mergeQueryParams(longURL string, incoming url.Values, incomingWins bool) (string, error) {
+	dest, err := url.Parse(longURL)
+	if err != nil {
+		return "", err
+	}
+
+	stored := dest.Query()
+	for key, values := range incoming {
+		if _, exists := stored[key]; exists && !incomingWins {
+			continue // L'URL stockée gagne, on ignore la valeur entrante
+		}
+		stored[key] = values
+	}
+
+	dest.RawQuery = stored.Encode()
+	return dest.String(), nil
+}
+
+// Bornes de la pagination de GET /api/v1/links.
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// ListLinksHandler liste les URLs courtes page par page (GET /api/v1/links?page=1&page_size=50).
+// Avec l'authentification, seul le jeton admin liste tous les liens : une clé d'API ne voit que les siens,
+// avec ou sans owner=me, et une requête anonyme est refusée (401).
+func ListLinksHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Le paramètre page doit être un entier supérieur ou égal à 1"})
+			return
+		}
+		pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Le paramètre page_size doit être compris entre 1 et %d", maxPageSize)})
+			return
+		}
+
+		// ?owner=me restreint la liste aux liens de la clé d'API utilisée, implicite pour une clé d'API avec l'authentification
+		owner := c.Query("owner")
+		if owner != "" && owner != "me" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Le paramètre owner ne peut valoir que 'me'"})
+			return
+		}
+		if cfg.Auth.Enabled && !middleware.IsAdmin(c, cfg.Security.AdminToken) {
+			owner = "me"
+		}
+
+		var links []models.Link
+		var total int64
+		if owner == "me" {
+			ownerID := middleware.OwnerID(c)
+			if ownerID == "" {
+				c.Header("WWW-Authenticate", `Bearer realm="api"`)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Clé d'API requise pour lister les liens"})
+				return
+			}
+			links, total, err = linkService.ListLinksByOwner(ownerID, page, pageSize)
+		} else {
+			links, total, err = linkService.ListLinks(page, pageSize)
+		}
+		if err != nil {
+			slog.Error("Error listing links", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(links))
+		for _, link := range links {
+			item := gin.H{
+				"short_code": link.ShortCode,
+				"created_at": link.CreatedAt.Format(time.RFC3339),
+				"is_custom":  link.IsCustom,
+			}
+			addLongURL(item, &link)
+			items = append(items, item)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+			"links":     items,
+		})
+	}
+}
+
+// OwnerStatsHandler retourne les statistiques agrégées des liens de la clé d'API utilisée (GET /api/v1/me/stats) :
+// nombres de liens (total, actifs, expirés), total des clics et les liens les plus cliqués.
+// Enregistrée avec auth.enabled ; une clé sans lien obtient des statistiques à zéro.
+func OwnerStatsHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ownerID := middleware.OwnerID(c)
+		if ownerID == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Clé d'API requise pour les statistiques du compte"})
+			return
+		}
+
+		stats, err := linkService.GetOwnerStats(ownerID)
+		if err != nil {
+			slog.Error("Error computing owner stats", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		now := time.Now()
+		topLinks := make([]gin.H, 0, len(stats.TopLinks))
+		for i := range stats.TopLinks {
+			item := linkResponse(&stats.TopLinks[i].Link, cfg.Server.BaseURL, now)
+			item["total_clicks"] = stats.TopLinks[i].ClickCount
+			topLinks = append(topLinks, item)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"total_links":   stats.TotalLinks,
+			"active_links":  stats.ActiveLinks,
+			"expired_links": stats.ExpiredLinks,
+			"total_clicks":  stats.TotalClicks,
+			"top_links":     topLinks,
+		})
+	}
+}
+
+// DeleteLinkHandler gère la suppression d'une URL courte (DELETE /api/v1/links/:shortCode).
+// Répond 204 en cas de succès et 404 si le code n'existe pas.
+// Réservé au propriétaire du lien, ou aux administrateurs sans authentification (voir requireLinkWriteAccess).
+func DeleteLinkHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+		if !requireLinkWriteAccess(c, linkService, cfg, shortCode) {
+			return
+		}
+
+		if err := linkService.DeleteLink(shortCode); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			slog.Error("Error deleting link", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		slog.Info("Lien supprimé", "short_code", shortCode)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// AddLinkAliasRequest est le corps de POST /api/v1/links/:shortCode/aliases.
+type AddLinkAliasRequest struct {
+	Alias string `json:"alias" binding:"required"`
+}
+
+// AddLinkAliasHandler ajoute un code supplémentaire à un lien (POST /api/v1/links/:shortCode/aliases).
+// Répond 201 avec l'alias créé, 404 si le lien n'existe pas et 400 si l'alias est invalide ou déjà pris.
+func AddLinkAliasHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req AddLinkAliasRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if !requireLinkOwner(c, linkService, cfg, shortCode) {
+			return
+		}
+
+		alias, err := linkService.AddAlias(shortCode, req.Alias)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			var aliasErr *apperrors.ErrInvalidAlias
+			if errors.As(err, &aliasErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": aliasErr.Error()})
+				return
+			}
+			slog.Warn("Error adding alias", "alias", req.Alias, "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		response := linkResponse(alias, cfg.Server.BaseURL, time.Now())
+		response["alias_of"] = shortCode
+		c.JSON(http.StatusCreated, response)
 	}
 }
 
+// topReferrersLimit est le nombre de référents listés dans les statistiques d'un lien.
+const topReferrersLimit = 10
+
+// Période de la série temporelle des statistiques : 30 derniers jours par défaut, un an au plus.
+const (
+	defaultStatsPeriod = 30 * 24 * time.Hour
+	maxStatsPeriod     = 366 * 24 * time.Hour
+)
+
+// parseStatsPeriod lit les paramètres 'from' et 'to' (RFC 3339) de la série temporelle des statistiques.
+// 'to' vaut maintenant par défaut et 'from' 30 jours avant 'to'.
+func parseStatsPeriod(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("le paramètre to doit être un horodatage RFC 3339")
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultStatsPeriod)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("le paramètre from doit être un horodatage RFC 3339")
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("le paramètre from doit précéder to")
+	}
+	if to.Sub(from) > maxStatsPeriod {
+		return time.Time{}, time.Time{}, fmt.Errorf("la période demandée ne peut pas dépasser un an")
+	}
+	return from, to, nil
+}
+
 // GetLinkStatsHandler gère la récupération des statistiques pour un lien spécifique.
-func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
+// Si les analytics sont désactivées, la réponse l'indique explicitement au lieu d'afficher 0 clic.
+func GetLinkStatsHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Récupère le shortCode de l'URL avec c.Param
 		shortCode := c.Param("shortCode")
 
-		// Appeler le LinkService pour obtenir le lien et le nombre total de clics.
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		// Série temporelle optionnelle des clics (?granularity=day&from=...&to=...)
+		granularity := c.Query("granularity")
+		if granularity != "" && granularity != "day" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Le paramètre granularity ne peut valoir que 'day'"})
+			return
+		}
+		from, to, err := parseStatsPeriod(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Un callback JSONP invalide est refusé avant toute requête (et avant un éventuel 304)
+		if _, ok := jsonpCallback(c, cfg); !ok {
+			return
+		}
+		// Statistiques réservées au propriétaire du lien si auth.stats_owner_only
+		if cfg.Auth.StatsOwnerOnly && !requireLinkOwner(c, linkService, cfg, shortCode) {
+			return
+		}
+
+		if !cfg.Analytics.Enabled {
+			link, err := linkService.ResolveStoredLink(shortCode)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+					return
+				}
+				slog.Error("Error retrieving link", "short_code", shortCode, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
+			response := gin.H{
+				"short_code": link.ShortCode,
+				"analytics":  "disabled",
+			}
+			addLongURL(response, link)
+			addClickLimit(response, link)
+			addInactiveStatus(response, link)
+			addMonitorStatus(response, link)
+			if !statsNotModified(c, cfg, response) {
+				respondStats(c, cfg, response)
+			}
+			return
+		}
+
+		// Récupérer le lien sans le cache : clics restants et dernière vérification du moniteur reflètent la base
+		link, err := linkService.ResolveStoredLink(shortCode)
 		if err != nil {
 			// Gérer le cas où le lien n'est pas trouvé.
 			// toujours avec l'erreur Gorm ErrRecordNotFound
@@ -196,16 +1178,99 @@ func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 				return
 			}
 			// Gérer d'autres erreurs
-			log.Printf("Error retrieving stats for %s: %v", shortCode, err)
+			slog.Error("Error retrieving stats", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Champs tirés du lien seul, sans requête supplémentaire
+		response := gin.H{"short_code": link.ShortCode}
+		addLongURL(response, link)
+		addClickLimit(response, link)
+		addInactiveStatus(response, link)
+		addMonitorStatus(response, link)
+
+		// Le suivi des clics est désactivé pour ce lien : l'indiquer plutôt qu'afficher 0 clic
+		if !link.TracksClicks() {
+			response["click_tracking"] = "disabled"
+			if !statsNotModified(c, cfg, response) {
+				respondStats(c, cfg, response)
+			}
+			return
+		}
+
+		// L'état des clics (une seule requête) suffit à valider la réponse : répondre 304 avant les répartitions
+		version, err := linkService.GetClickVersion(link.ID)
+		if err != nil {
+			slog.Error("Error retrieving stats", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		validator := gin.H{"link": response, "clicks": version}
+		if granularity == "day" {
+			// La période par défaut se termine maintenant : la série change avec elle, même sans nouveau clic
+			validator["from"], validator["to"] = from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano)
+		}
+		if statsNotModified(c, cfg, validator) {
+			return
+		}
+
+		// Répartition des clics par chemin de redirection emprunté
+		servedPaths, err := linkService.GetServedPathBreakdown(link.ID)
+		if err != nil {
+			slog.Error("Error retrieving served path breakdown", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Référents les plus fréquents
+		topReferrers, err := linkService.GetTopReferrers(link.ID, topReferrersLimit)
+		if err != nil {
+			slog.Error("Error retrieving referrers", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Répartition géographique des clics
+		clicksByCountry, err := linkService.GetCountryBreakdown(link.ID)
+		if err != nil {
+			slog.Error("Error retrieving country breakdown", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Répartition des clics par navigateur
+		clicksByBrowser, err := linkService.GetBrowserBreakdown(link.ID)
+		if err != nil {
+			slog.Error("Error retrieving browser breakdown", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Visiteurs distincts (par adresse IP)
+		uniqueVisitors, err := linkService.CountUniqueVisitors(link.ID)
+		if err != nil {
+			slog.Error("Error counting unique visitors", "short_code", shortCode, "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 			return
 		}
 
 		// Retourne les statistiques dans la réponse JSON.
-		c.JSON(http.StatusOK, gin.H{
-			"short_code":   link.ShortCode,
-			"long_url":     link.LongURL,
-			"total_clicks": totalClicks,
-		})
+		response["total_clicks"] = version.Total()
+		response["unique_visitors"] = uniqueVisitors
+		response["served_paths"] = servedPaths
+		response["top_referrers"] = topReferrers
+		response["clicks_by_country"] = clicksByCountry
+		response["clicks_by_browser"] = clicksByBrowser
+		if granularity == "day" {
+			clicksByDay, err := linkService.GetClicksByDay(link.ID, from, to)
+			if err != nil {
+				slog.Error("Error retrieving daily clicks", "short_code", shortCode, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
+			response["clicks_by_day"] = clicksByDay
+		}
+		respondStats(c, cfg, response)
 	}
 }
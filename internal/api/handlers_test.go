@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestMergeQueryParamsConflicts(t *testing.T) {
+	incoming := url.Values{"utm_source": {"newsletter"}, "ref": {"x"}}
+
+	tests := []struct {
+		name         string
+		incomingWins bool
+		want         string
+	}{
+		{"stored wins", false, "https://example.com/page?ref=x&utm_source=site"},
+		{"incoming wins", true, "https://example.com/page?ref=x&utm_source=newsletter"},
+	}
+
+	for _, tt := range tests {
+		got, err := mergeQueryParams("https://example.com/page?utm_source=site", incoming, tt.incomingWins)
+		if err != nil {
+			t.Fatalf("%s: erreur inattendue: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: obtenu %q, attendu %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeQueryParamsRepeatedKey(t *testing.T) {
+	incoming := url.Values{"tag": {"a", "b"}}
+
+	got, err := mergeQueryParams("https://example.com/?tag=stored", incoming, true)
+	if err != nil {
+		t.Fatalf("erreur inattendue: %v", err)
+	}
+	if want := "https://example.com/?tag=a&tag=b"; got != want {
+		t.Errorf("obtenu %q, attendu %q", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+func TestMaxClicksEnforcedWithoutClickTracking(t *testing.T) {
+	// Analytics désactivées et suivi des clics coupé : la limite repose sur le seul compteur de redirections.
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.Analytics.Enabled = false })
+
+	create := api.do(http.MethodPost, "/api/v1/links",
+		`{"long_url":"https://example.com/limited","max_clicks":2,"track_clicks":false}`)
+	if create.Code != http.StatusCreated {
+		t.Fatalf("POST /api/v1/links: statut %d, attendu 201 (corps %s)", create.Code, create.Body.String())
+	}
+	var link struct {
+		ShortCode string `json:"short_code"`
+	}
+	decodeJSON(t, create, &link)
+
+	for i := 1; i <= 2; i++ {
+		if rec := api.do(http.MethodGet, "/"+link.ShortCode, ""); rec.Code != http.StatusFound {
+			t.Fatalf("redirection %d: statut %d, attendu 302", i, rec.Code)
+		}
+	}
+	rec := api.do(http.MethodGet, "/"+link.ShortCode, "")
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "maximum number of clicks") {
+		t.Errorf("redirection 3: statut %d, corps %q, attendu 410 (limite atteinte)", rec.Code, rec.Body.String())
+	}
+
+	stats := api.do(http.MethodGet, "/api/v1/links/"+link.ShortCode+"/stats", "")
+	var body struct {
+		ClicksRemaining *int `json:"clicks_remaining"`
+	}
+	decodeJSON(t, stats, &body)
+	if body.ClicksRemaining == nil || *body.ClicksRemaining != 0 {
+		t.Errorf("clicks_remaining = %v, attendu 0", body.ClicksRemaining)
+	}
+}
+
+func TestRedirectCountOnlyKeptWhenNeeded(t *testing.T) {
+	// Sans en-tête X-Total-Clicks ni max_clicks, une redirection n'écrit rien en base.
+	t.Run("default", func(t *testing.T) {
+		api := newTestAPI(t, nil)
+		link := api.createLink(t, "plain", "https://example.com/plain")
+		if rec := api.do(http.MethodGet, "/plain", ""); rec.Code != http.StatusFound {
+			t.Fatalf("statut %d, attendu 302", rec.Code)
+		}
+		var stored models.Link
+		if err := api.db.First(&stored, link.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.RedirectCount != 0 {
+			t.Errorf("redirect_count = %d, attendu 0", stored.RedirectCount)
+		}
+	})
+
+	t.Run("header", func(t *testing.T) {
+		api := newTestAPI(t, func(cfg *config.Config) { cfg.Server.ExposeClickCountHeader = true })
+		api.createLink(t, "counted", "https://example.com/counted")
+		for i := 1; i <= 2; i++ {
+			rec := api.do(http.MethodGet, "/counted", "")
+			if got := rec.Header().Get("X-Total-Clicks"); got != strconv.Itoa(i) {
+				t.Errorf("redirection %d: X-Total-Clicks = %q, attendu %d", i, got, i)
+			}
+		}
+	})
+}
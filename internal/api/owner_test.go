@@ -0,0 +1,162 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// withAPIKeys active l'authentification avec les clés "key-a" et "key-b" et le jeton admin "s3cret".
+func withAPIKeys(cfg *config.Config) {
+	cfg.Auth.Enabled = true
+	cfg.Auth.APIKeys = []string{"key-a", "key-b"}
+	cfg.Security.AdminToken = "s3cret"
+}
+
+func TestCreateDedupeIsScopedToOwner(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) {
+		withAPIKeys(cfg)
+		cfg.Server.DedupeURLs = true
+	})
+	body := `{"long_url":"https://example.com/shared"}`
+	create := func(key string, wantStatus int) string {
+		t.Helper()
+		res := api.do(http.MethodPost, "/api/v1/links", body, "Authorization", "Bearer "+key)
+		if res.Code != wantStatus {
+			t.Fatalf("création (%s): statut %d, attendu %d, corps %s", key, res.Code, wantStatus, res.Body.String())
+		}
+		var created struct {
+			ShortCode string `json:"short_code"`
+		}
+		decodeJSON(t, res, &created)
+		return created.ShortCode
+	}
+
+	codeA := create("key-a", http.StatusCreated)
+	// Le lien d'un autre propriétaire n'est pas réutilisé : la clé B obtient son propre code
+	codeB := create("key-b", http.StatusCreated)
+	if codeB == codeA {
+		t.Errorf("la clé B a reçu le lien de la clé A (%s)", codeA)
+	}
+	// Le lien plus récent de la clé B ne masque pas celui de la clé A
+	if again := create("key-a", http.StatusOK); again != codeA {
+		t.Errorf("réutilisation (key-a) = %s, attendu %s", again, codeA)
+	}
+}
+
+func TestListLinksScopedToCallerWithAuth(t *testing.T) {
+	api := newTestAPI(t, withAPIKeys)
+	for _, c := range []struct{ key, alias string }{{"key-a", "owned-a"}, {"key-b", "owned-b"}} {
+		body := `{"long_url":"https://example.com/` + c.alias + `","custom_alias":"` + c.alias + `"}`
+		if res := api.do(http.MethodPost, "/api/v1/links", body, "Authorization", "Bearer "+c.key); res.Code != http.StatusCreated {
+			t.Fatalf("création %s: statut %d, corps %s", c.alias, res.Code, res.Body.String())
+		}
+	}
+	list := func(path string, headers ...string) []string {
+		t.Helper()
+		res := api.do(http.MethodGet, path, "", headers...)
+		if res.Code != http.StatusOK {
+			t.Fatalf("GET %s: statut %d, corps %s", path, res.Code, res.Body.String())
+		}
+		var page struct {
+			Links []struct {
+				ShortCode string `json:"short_code"`
+			} `json:"links"`
+		}
+		decodeJSON(t, res, &page)
+		codes := make([]string, 0, len(page.Links))
+		for _, link := range page.Links {
+			codes = append(codes, link.ShortCode)
+		}
+		return codes
+	}
+
+	if res := api.do(http.MethodGet, "/api/v1/links", ""); res.Code != http.StatusUnauthorized {
+		t.Errorf("sans clé: statut %d, attendu 401", res.Code)
+	}
+	// Une clé ne voit que ses liens, avec ou sans owner=me
+	for _, path := range []string{"/api/v1/links", "/api/v1/links?owner=me"} {
+		if codes := list(path, "Authorization", "Bearer key-a"); len(codes) != 1 || codes[0] != "owned-a" {
+			t.Errorf("GET %s (key-a) = %v, attendu [owned-a]", path, codes)
+		}
+	}
+	if codes := list("/api/v1/links", "Authorization", "Bearer s3cret"); len(codes) != 2 {
+		t.Errorf("jeton admin = %v, attendu les 2 liens", codes)
+	}
+}
+
+func TestOwnerStats(t *testing.T) {
+	api := newTestAPI(t, withAPIKeys)
+	// Propriétaires dérivés des clés : relus sur un lien créé par l'API avec chacune
+	ownerOf := func(key, alias string) *models.Link {
+		t.Helper()
+		body := `{"long_url":"https://example.com/` + alias + `","custom_alias":"` + alias + `"}`
+		if res := api.do(http.MethodPost, "/api/v1/links", body, "Authorization", "Bearer "+key); res.Code != http.StatusCreated {
+			t.Fatalf("création %s: statut %d, corps %s", alias, res.Code, res.Body.String())
+		}
+		var link models.Link
+		api.db.Where("short_code = ?", alias).First(&link)
+		return &link
+	}
+	live, other := ownerOf("key-a", "a-live"), ownerOf("key-b", "b-live")
+
+	past := time.Now().Add(-time.Hour)
+	one := 1
+	old := &models.Link{ShortCode: "a-old", LongURL: "https://example.com/old", IsActive: true, OwnerID: live.OwnerID, ExpiresAt: &past}
+	spent := &models.Link{ShortCode: "a-spent", LongURL: "https://example.com/spent", IsActive: true, OwnerID: live.OwnerID,
+		MaxClicks: &one, RedirectCount: 1}
+	for _, link := range []*models.Link{old, spent} {
+		if err := api.db.Create(link).Error; err != nil {
+			t.Fatalf("création du lien %s: %v", link.ShortCode, err)
+		}
+	}
+	// Un alias n'est pas compté : ses clics le sont sur son lien canonique
+	api.db.Create(&models.Link{ShortCode: "a-alias", LongURL: live.LongURL, IsActive: true, OwnerID: live.OwnerID, CanonicalLinkID: &live.ID})
+	for link, clicks := range map[*models.Link]int{live: 3, spent: 1, other: 5} {
+		for i := 0; i < clicks; i++ {
+			api.db.Create(&models.Click{LinkID: link.ID, Timestamp: time.Now()})
+		}
+	}
+	api.db.Create(&models.ClickDaily{LinkID: old.ID, Day: past.AddDate(0, 0, -1).UTC().Format("2006-01-02"), Clicks: 2})
+
+	var stats struct {
+		TotalLinks   int `json:"total_links"`
+		ActiveLinks  int `json:"active_links"`
+		ExpiredLinks int `json:"expired_links"`
+		TotalClicks  int `json:"total_clicks"`
+		TopLinks     []struct {
+			ShortCode   string `json:"short_code"`
+			TotalClicks int    `json:"total_clicks"`
+		} `json:"top_links"`
+	}
+	res := api.do(http.MethodGet, "/api/v1/me/stats", "", "Authorization", "Bearer key-a")
+	if res.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/me/stats: statut %d, corps %s", res.Code, res.Body.String())
+	}
+	decodeJSON(t, res, &stats)
+	if stats.TotalLinks != 3 || stats.ActiveLinks != 1 || stats.ExpiredLinks != 2 || stats.TotalClicks != 6 {
+		t.Errorf("statistiques = %+v, attendu 3 liens (1 actif, 2 expirés) et 6 clics", stats)
+	}
+	var top []string
+	for _, link := range stats.TopLinks {
+		top = append(top, fmt.Sprintf("%s:%d", link.ShortCode, link.TotalClicks))
+	}
+	if got := strings.Join(top, ","); got != "a-live:3,a-old:2,a-spent:1" {
+		t.Errorf("top_links = %s, attendu a-live:3,a-old:2,a-spent:1", got)
+	}
+
+	// Une clé sans lien obtient des statistiques à zéro, avec une liste vide
+	empty := api.do(http.MethodGet, "/api/v1/me/stats", "", "Authorization", "Bearer s3cret")
+	if empty.Code != http.StatusOK || !strings.Contains(empty.Body.String(), `"top_links":[]`) ||
+		!strings.Contains(empty.Body.String(), `"total_links":0`) {
+		t.Errorf("sans lien: statut %d, corps %s, attendu des statistiques à zéro", empty.Code, empty.Body.String())
+	}
+	if anonymous := api.do(http.MethodGet, "/api/v1/me/stats", ""); anonymous.Code != http.StatusUnauthorized {
+		t.Errorf("sans clé: statut %d, attendu 401", anonymous.Code)
+	}
+}
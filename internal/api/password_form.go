@@ -0,0 +1,53 @@
+package api
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// passwordFormTemplate est le formulaire affiché aux navigateurs avant la redirection d'un lien protégé.
+// Il est soumis en POST sur l'URL courte elle-même.
+var passwordFormTemplate = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head><meta charset="utf-8"><title>Lien protégé</title></head>
+<body>
+<h1>Ce lien est protégé par un mot de passe</h1>
+{{if .Error}}<p style="color: #b00020">{{.Error}}</p>{{end}}
+<form method="post" action="/{{.ShortCode}}">
+<label for="password">Mot de passe :</label>
+<input type="password" id="password" name="password" autofocus required>
+<button type="submit">Continuer</button>
+</form>
+</body>
+</html>
+`))
+
+// passwordFormData contient le contexte du formulaire de mot de passe.
+type passwordFormData struct {
+	ShortCode string
+	Error     string // Message affiché après un mot de passe incorrect
+}
+
+// respondPasswordRequired répond 401 à une redirection protégée sans mot de passe valide :
+// le formulaire HTML pour un navigateur, du JSON pour les autres clients.
+// 'errorMessage' est vide si aucun mot de passe n'a été fourni.
+func respondPasswordRequired(c *gin.Context, shortCode, errorMessage string) {
+	c.Header("Cache-Control", "no-store")
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		c.Status(http.StatusUnauthorized)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if err := passwordFormTemplate.Execute(c.Writer, passwordFormData{ShortCode: shortCode, Error: errorMessage}); err != nil {
+			slog.Error("Error rendering password form", "short_code", shortCode, "error", err)
+		}
+		return
+	}
+
+	if errorMessage == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "This link is password protected"})
+		return
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+}
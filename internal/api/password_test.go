@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// createProtectedLink crée par l'API un lien protégé par 'password' et retourne son code court.
+func createProtectedLink(t *testing.T, api *testAPI, password string) string {
+	t.Helper()
+	rec := api.do(http.MethodPost, "/api/v1/links",
+		`{"long_url":"https://example.com/secret","password":"`+password+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /api/v1/links: statut %d, attendu 201 (corps %s)", rec.Code, rec.Body.String())
+	}
+	var body map[string]any
+	decodeJSON(t, rec, &body)
+	if _, exposed := body["long_url"]; exposed {
+		t.Errorf("réponse de création: long_url exposée pour un lien protégé: %v", body)
+	}
+	return body["short_code"].(string)
+}
+
+// postPassword soumet le formulaire de mot de passe d'un lien protégé.
+func postPassword(api *testAPI, shortCode, password string) int {
+	form := url.Values{"password": {password}}.Encode()
+	return api.do(http.MethodPost, "/"+shortCode, form, "Content-Type", "application/x-www-form-urlencoded").Code
+}
+
+func TestProtectedLinkPassword(t *testing.T) {
+	api := newTestAPI(t, nil)
+	shortCode := createProtectedLink(t, api, "correct-horse")
+
+	if code := api.do(http.MethodGet, "/"+shortCode, "").Code; code != http.StatusUnauthorized {
+		t.Errorf("sans mot de passe: statut %d, attendu 401", code)
+	}
+	if code := postPassword(api, shortCode, "wrong-password"); code != http.StatusUnauthorized {
+		t.Errorf("mauvais mot de passe: statut %d, attendu 401", code)
+	}
+	// Le mot de passe n'est plus accepté dans la query string
+	if code := api.do(http.MethodGet, "/"+shortCode+"?password=correct-horse", "").Code; code != http.StatusUnauthorized {
+		t.Errorf("?password=: statut %d, attendu 401", code)
+	}
+
+	form := url.Values{"password": {"correct-horse"}}.Encode()
+	rec := api.do(http.MethodPost, "/"+shortCode, form, "Content-Type", "application/x-www-form-urlencoded")
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "https://example.com/secret" {
+		t.Errorf("bon mot de passe: statut %d, Location %q, attendu 303 vers la destination", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestProtectedLinkPasswordPostAnsweredWith303(t *testing.T) {
+	// Avec 307 ou 308, le navigateur renverrait le formulaire, mot de passe compris, à la destination
+	for _, status := range []int{http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			api := newTestAPI(t, func(cfg *config.Config) { cfg.Server.RedirectStatus = status })
+			shortCode := createProtectedLink(t, api, "correct-horse")
+
+			form := url.Values{"password": {"correct-horse"}}.Encode()
+			rec := api.do(http.MethodPost, "/"+shortCode, form, "Content-Type", "application/x-www-form-urlencoded")
+			if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "https://example.com/secret" {
+				t.Errorf("statut %d, Location %q, attendu 303 vers la destination", rec.Code, rec.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestProtectedLinkPasswordMinimumLength(t *testing.T) {
+	api := newTestAPI(t, nil)
+	rec := api.do(http.MethodPost, "/api/v1/links", `{"long_url":"https://example.com/secret","password":"1234567"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("mot de passe de 7 caractères: statut %d, attendu 400", rec.Code)
+	}
+}
+
+func TestProtectedLinkPasswordThrottle(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) {
+		cfg.Security.PasswordThrottle.MaxFailures = 3
+	})
+	shortCode := createProtectedLink(t, api, "correct-horse")
+	other := createProtectedLink(t, api, "correct-horse")
+
+	for i := 1; i <= 3; i++ {
+		if code := postPassword(api, shortCode, "wrong-password"); code != http.StatusUnauthorized {
+			t.Fatalf("échec %d: statut %d, attendu 401", i, code)
+		}
+	}
+	// IP bloquée sur ce code, même avec le bon mot de passe
+	if code := postPassword(api, shortCode, "correct-horse"); code != http.StatusTooManyRequests {
+		t.Errorf("après le seuil: statut %d, attendu 429", code)
+	}
+	// Les autres liens restent accessibles
+	if code := postPassword(api, other, "correct-horse"); code != http.StatusSeeOther {
+		t.Errorf("autre lien: statut %d, attendu 303", code)
+	}
+}
+
+func TestProtectedLinkDestinationRedacted(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) {
+		cfg.Security.AdminToken = "s3cret"
+	})
+	shortCode := createProtectedLink(t, api, "correct-horse")
+	api.createLink(t, "public", "https://example.com/public")
+
+	var stats map[string]any
+	decodeJSON(t, api.do(http.MethodGet, "/api/v1/links/"+shortCode+"/stats", ""), &stats)
+	if _, exposed := stats["long_url"]; exposed {
+		t.Errorf("stats: long_url exposée pour un lien protégé: %v", stats)
+	}
+
+	var list struct {
+		Links []map[string]any `json:"links"`
+	}
+	decodeJSON(t, api.do(http.MethodGet, "/api/v1/links", ""), &list)
+	for _, item := range list.Links {
+		_, exposed := item["long_url"]
+		if protected := item["short_code"] == shortCode; exposed == protected {
+			t.Errorf("liste: lien %v, long_url exposée = %v", item["short_code"], exposed)
+		}
+	}
+
+	update := api.do(http.MethodPut, "/api/v1/links/"+shortCode, `{"long_url":"https://example.com/moved"}`,
+		"Authorization", "Bearer s3cret")
+	if update.Code != http.StatusOK {
+		t.Fatalf("PUT: statut %d, attendu 200 (corps %s)", update.Code, update.Body.String())
+	}
+	var updated map[string]any
+	decodeJSON(t, update, &updated)
+	if _, exposed := updated["long_url"]; exposed {
+		t.Errorf("PUT: long_url exposée pour un lien protégé: %v", updated)
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// previewPageTemplate est la page intermédiaire affichée avant la redirection (server.preview_redirect).
+// Le lien "Continuer" pointe vers l'URL courte avec ?preview=false : c'est lui qui redirige et enregistre le clic.
+var previewPageTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Vous allez quitter ce site</title></head>
+<body>
+<h1>Vous allez être redirigé vers :</h1>
+<p><code>{{.Destination}}</code></p>
+<p>Vérifiez que vous faites confiance à cette adresse avant de continuer.</p>
+<p><a href="{{.ContinueURL}}" rel="noreferrer">Continuer</a></p>
+</body>
+</html>
+`))
+
+// previewPageData contient le contexte de la page intermédiaire.
+type previewPageData struct {
+	Destination string
+	ContinueURL string // URL courte avec ?preview=false (et la query string d'origine)
+}
+
+// respondPreview répond 200 avec la page intermédiaire pour un navigateur, ou la destination en JSON pour les autres clients.
+// Aucun clic n'est enregistré ici : il le sera quand le visiteur suivra continueURL.
+func respondPreview(c *gin.Context, shortCode, destination, continueURL string) {
+	c.Header("Cache-Control", "no-store")
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		if err := previewPageTemplate.Execute(c.Writer, previewPageData{Destination: destination, ContinueURL: continueURL}); err != nil {
+			slog.Error("Error rendering preview page", "short_code", shortCode, "error", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"short_code":   shortCode,
+		"long_url":     destination,
+		"continue_url": continueURL,
+	})
+}
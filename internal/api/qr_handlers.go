@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	qrcode "github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+// Tailles (en pixels) acceptées pour les QR codes.
+const (
+	defaultQRSize = 256
+	minQRSize     = 64
+	maxQRSize     = 1024
+)
+
+// GetLinkQRCodeHandler retourne une image PNG du QR code encodant l'URL courte complète
+// (GET /api/v1/links/:shortCode/qr?size=256), pour les supports imprimés.
+func GetLinkQRCodeHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		size := defaultQRSize
+		if value := c.Query("size"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < minQRSize || parsed > maxQRSize {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Le paramètre size doit être un entier entre 64 et 1024"})
+				return
+			}
+			size = parsed
+		}
+
+		shortCode := c.Param("shortCode")
+		link, err := linkService.GetLinkByShortCode(shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			slog.Error("Error retrieving link", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		png, err := qrcode.Encode(cfg.Server.BaseURL+"/"+link.ShortCode, qrcode.Medium, size)
+		if err != nil {
+			slog.Error("Error generating QR code", "short_code", shortCode, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", png)
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/middleware"
+)
+
+// expectStatuses exécute n fois la même requête et vérifie le statut de chacune, la dernière pouvant différer.
+func expectStatuses(t *testing.T, api *testAPI, method, path, body string, n, status, lastStatus int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		want := status
+		if i == n {
+			want = lastStatus
+		}
+		if res := api.do(method, path, body); res.Code != want {
+			t.Fatalf("%s %s, requête %d: statut %d, attendu %d", method, path, i, res.Code, want)
+		}
+	}
+}
+
+func TestRateLimitRulesLeaveUnmatchedRoutesToScope(t *testing.T) {
+	rules := []middleware.RouteRateLimit{
+		{Method: http.MethodPost, Path: "/api/v1/links", Limiter: middleware.NewIPRateLimiter(1, 1)},
+	}
+	api := newTestAPIWithLimits(t, nil, middleware.NewIPRateLimiter(1, 1), rules)
+	api.createLink(t, "abc123", "https://example.com")
+
+	// Scope "api" par défaut : ni les redirections ni le health check ne sont limités
+	expectStatuses(t, api, http.MethodGet, "/abc123", "", 5, http.StatusFound, http.StatusFound)
+	expectStatuses(t, api, http.MethodGet, "/health", "", 5, http.StatusOK, http.StatusOK)
+	expectStatuses(t, api, http.MethodPost, "/api/v1/links", `{"long_url":"https://example.com/a"}`, 2,
+		http.StatusCreated, http.StatusTooManyRequests)
+}
+
+func TestRateLimitScopeAll(t *testing.T) {
+	api := newTestAPIWithLimits(t, func(cfg *config.Config) { cfg.RateLimiter.Scope = "all" },
+		middleware.NewIPRateLimiter(2, 1), nil)
+	api.createLink(t, "abc123", "https://example.com")
+
+	expectStatuses(t, api, http.MethodGet, "/abc123", "", 3, http.StatusFound, http.StatusTooManyRequests)
+}
+
+func TestRateLimitResponsesCarryCORSHeaders(t *testing.T) {
+	api := newTestAPIWithLimits(t, func(cfg *config.Config) { cfg.Server.CORSOrigins = []string{"https://app.example"} },
+		middleware.NewIPRateLimiter(1, 1), nil)
+	body := `{"long_url":"https://example.com/a"}`
+	origin := []string{"Origin", "https://app.example"}
+
+	api.do(http.MethodPost, "/api/v1/links", body, origin...)
+	res := api.do(http.MethodPost, "/api/v1/links", body, origin...)
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("statut %d, attendu 429", res.Code)
+	}
+	if got := res.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q sur la réponse 429, attendu l'origine", got)
+	}
+}
+
+func TestAllowlistedIPNeverRateLimited(t *testing.T) {
+	// httptest envoie les requêtes depuis 192.0.2.1 ; X-Forwarded-For simule un autre client
+	api := newTestAPIWithLimits(t, func(cfg *config.Config) { cfg.RateLimiter.Allowlist = []string{"192.0.2.0/24"} },
+		middleware.NewIPRateLimiter(1, 1), nil)
+	body := `{"long_url":"https://example.com/a"}`
+
+	for i := 1; i <= 20; i++ {
+		res := api.do(http.MethodPost, "/api/v1/links", body)
+		if res.Code == http.StatusTooManyRequests {
+			t.Fatalf("requête %d: 429 pour une IP de l'allowlist", i)
+		}
+		if res.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("requête %d: en-têtes X-RateLimit-* pour une IP de l'allowlist", i)
+		}
+	}
+
+	other := []string{"X-Forwarded-For", "203.0.113.7"}
+	api.do(http.MethodPost, "/api/v1/links", body, other...)
+	if res := api.do(http.MethodPost, "/api/v1/links", body, other...); res.Code != http.StatusTooManyRequests {
+		t.Errorf("IP hors allowlist: statut %d, attendu 429", res.Code)
+	}
+}
+
+func TestDefaultRateLimitCoversAPIGroup(t *testing.T) {
+	// Configuration par défaut (scope "api") et limiter construit comme run-server, ramené à 3 requêtes
+	mutate := func(cfg *config.Config) { cfg.RateLimiter.MaxRequests = 3 }
+	cfg := testConfig(t, mutate)
+	limiter := middleware.NewRateLimiter(cfg.RateLimiter.Algorithm, cfg.RateLimiter.MaxRequests, cfg.RateLimiter.WindowMinutes)
+	api := newTestAPIWithLimits(t, mutate, limiter, nil)
+	api.createLink(t, "abc123", "https://example.com")
+
+	// Les lectures de l'API sont décomptées au même titre que les créations
+	expectStatuses(t, api, http.MethodGet, "/api/v1/links/abc123/stats", "", 3, http.StatusOK, http.StatusOK)
+	res := api.do(http.MethodGet, "/api/v1/links/abc123/stats", "")
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("4e requête: statut %d, attendu 429", res.Code)
+	}
+	if res.Header().Get("Retry-After") == "" {
+		t.Error("en-tête Retry-After absent de la réponse 429")
+	}
+	// Les redirections restent hors du groupe et donc non limitées
+	expectStatuses(t, api, http.MethodGet, "/abc123", "", 5, http.StatusFound, http.StatusFound)
+}
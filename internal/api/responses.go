@@ -0,0 +1,183 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// linkResponse construit la représentation JSON publique d'un lien.
+// Toute la sérialisation d'un lien vers une réponse passe par cette fonction.
+func linkResponse(link *models.Link, baseURL string, now time.Time) gin.H {
+	response := gin.H{
+		"short_code":     link.ShortCode,
+		"full_short_url": baseURL + "/" + link.ShortCode,
+	}
+	addLongURL(response, link)
+
+	// Ajouter un indicateur si c'est un alias personnalisé
+	if link.IsCustom {
+		response["is_custom"] = true
+	}
+
+	// Ajouter la date d'expiration si le lien expire.
+	// On copie la valeur pour ne jamais déréférencer le pointeur plus d'une fois.
+	if expiresAt := link.ExpiresAt; expiresAt != nil {
+		response["expires_at"] = expiresAt.Format(time.RFC3339)
+		response["expires_in_minutes"] = expiresInMinutes(*expiresAt, now)
+	}
+
+	// Signaler un lien protégé par mot de passe (le hash n'est jamais exposé)
+	if link.IsProtected() {
+		response["password_protected"] = true
+	}
+
+	// Ajouter la limite de clics si le lien expire après un nombre de clics
+	if link.MaxClicks != nil {
+		response["max_clicks"] = *link.MaxClicks
+	}
+
+	return response
+}
+
+// addMonitorStatus ajoute à une réponse admin ou de statistiques le résultat de la dernière vérification
+// du moniteur : le code HTTP observé (0 = injoignable) et sa date. Rien n'est ajouté avant la première vérification.
+func addMonitorStatus(response gin.H, link *models.Link) {
+	if link.LastCheckedAt == nil {
+		return
+	}
+	response["last_check_status"] = link.LastCheckStatus
+	response["last_checked_at"] = link.LastCheckedAt.Format(time.RFC3339)
+}
+
+// addLongURL ajoute la destination du lien à une réponse, sauf pour un lien protégé par mot de passe :
+// le mot de passe protège aussi la destination, qui n'est révélée qu'à la redirection.
+func addLongURL(response gin.H, link *models.Link) {
+	if link.IsProtected() {
+		return
+	}
+	response["long_url"] = link.LongURL
+}
+
+// addClickLimit ajoute à une réponse de statistiques la limite de clics d'un lien et les redirections restantes,
+// calculées à partir du compteur de redirections (tenu même sans analytics ni suivi des clics).
+func addClickLimit(response gin.H, link *models.Link) {
+	if link.MaxClicks == nil {
+		return
+	}
+	response["max_clicks"] = *link.MaxClicks
+	response["clicks_remaining"] = max(*link.MaxClicks-link.RedirectCount, 0)
+}
+
+// addInactiveStatus signale dans une réponse admin ou de statistiques qu'un lien a été désactivé, et pourquoi.
+func addInactiveStatus(response gin.H, link *models.Link) {
+	if link.IsActive {
+		return
+	}
+	response["is_active"] = false
+	if link.InactiveReason != "" {
+		response["inactive_reason"] = link.InactiveReason
+	}
+}
+
+// jsonpCallbackPattern n'accepte comme callback JSONP qu'un identifiant JavaScript, éventuellement qualifié
+// (ex: "cb", "widget.onStats"), pour qu'aucun code arbitraire ne puisse être injecté dans la réponse.
+var jsonpCallbackPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]{0,63}(\.[A-Za-z_$][A-Za-z0-9_$]{0,63}){0,3}$`)
+
+// statsNotModified calcule l'ETag d'une réponse de statistiques si server.stats_etag est activé, avant le calcul
+// des répartitions : il est dérivé d'un validateur peu coûteux (champs du lien et état de ses clics, voir
+// repository.ClickVersion) et de la query string. Si l'en-tête If-None-Match du client correspond, la réponse
+// 304 Not Modified est envoyée et la fonction retourne true ; sinon l'ETag accompagne la réponse à venir.
+func statsNotModified(c *gin.Context, cfg *config.Config, validator gin.H) bool {
+	if !cfg.Server.StatsETag {
+		return false
+	}
+	// Les clés d'une map sont triées : la sérialisation est stable
+	payload, err := json.Marshal(validator)
+	if err != nil {
+		slog.Error("Error encoding stats validator", "error", err)
+		return false
+	}
+	hash := sha256.New()
+	hash.Write(payload)
+	hash.Write([]byte(c.Request.URL.RawQuery)) // callback, granularity, from, to
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// jsonpCallback retourne le callback JSONP demandé (?callback=fn), vide si server.allow_jsonp est désactivé.
+// Un callback invalide est refusé avec une réponse 400 et ok vaut false.
+func jsonpCallback(c *gin.Context, cfg *config.Config) (callback string, ok bool) {
+	if !cfg.Server.AllowJSONP {
+		return "", true
+	}
+	callback = c.Query("callback")
+	if callback != "" && !jsonpCallbackPattern.MatchString(callback) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Paramètre callback invalide: un identifiant JavaScript est attendu"})
+		return "", false
+	}
+	return callback, true
+}
+
+// respondStats envoie une réponse de statistiques (200), avec l'ETag éventuellement posé par statsNotModified.
+// Si server.allow_jsonp est activé et que ?callback=fn est fourni, le JSON est enveloppé dans l'appel fn(...).
+func respondStats(c *gin.Context, cfg *config.Config, body gin.H) {
+	callback, ok := jsonpCallback(c, cfg)
+	if !ok {
+		return
+	}
+
+	// encoding/json échappe <, > et &, le corps peut donc être inclus tel quel dans un script
+	payload, err := json.Marshal(body)
+	if err != nil {
+		slog.Error("Error encoding response", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	contentType := "application/json; charset=utf-8"
+	if callback != "" {
+		// Le commentaire initial empêche l'interprétation de la réponse comme un autre type de contenu
+		payload = []byte("/**/" + callback + "(" + string(payload) + ");")
+		contentType = "application/javascript; charset=utf-8"
+		c.Header("X-Content-Type-Options", "nosniff")
+	}
+	c.Data(http.StatusOK, contentType, payload)
+}
+
+// etagMatches indique si la valeur de If-None-Match (liste séparée par des virgules, "*" ou ETags faibles W/)
+// contient l'ETag donné.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// expiresInMinutes retourne le nombre de minutes restantes avant 'expiresAt'.
+// Une date déjà dépassée retourne 0 plutôt qu'une valeur négative.
+func expiresInMinutes(expiresAt, now time.Time) int {
+	remaining := expiresAt.Sub(now)
+	if remaining <= 0 {
+		return 0
+	}
+	return int(remaining.Minutes())
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLinkResponseExpiresInMinutes(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	future := now.Add(90*time.Minute + 30*time.Second)
+	past := now.Add(-10 * time.Minute)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		want      int
+		present   bool
+	}{
+		{"sans expiration", nil, 0, false},
+		{"expiration future", &future, 90, true},
+		{"déjà expiré", &past, 0, true},
+		{"expire maintenant", &now, 0, true},
+	}
+
+	for _, tt := range tests {
+		link := &models.Link{ShortCode: "abc123", LongURL: "https://example.com", ExpiresAt: tt.expiresAt}
+		response := linkResponse(link, "http://localhost:8080", now)
+
+		got, ok := response["expires_in_minutes"]
+		if ok != tt.present {
+			t.Fatalf("%s: expires_in_minutes présent = %v, attendu %v", tt.name, ok, tt.present)
+		}
+		if ok && got.(int) != tt.want {
+			t.Errorf("%s: expires_in_minutes = %v, attendu %d", tt.name, got, tt.want)
+		}
+		if _, hasDate := response["expires_at"]; hasDate != tt.present {
+			t.Errorf("%s: expires_at présent = %v, attendu %v", tt.name, hasDate, tt.present)
+		}
+	}
+}
+
+func TestLinkResponseFullShortURL(t *testing.T) {
+	link := &models.Link{ShortCode: "abc123", LongURL: "https://example.com"}
+	response := linkResponse(link, "https://sho.rt", time.Now())
+
+	if got := response["full_short_url"]; got != "https://sho.rt/abc123" {
+		t.Errorf("full_short_url = %v, attendu https://sho.rt/abc123", got)
+	}
+}
+
+// statsRequest envoie une réponse de statistiques validée par statsNotModified avec l'en-tête If-None-Match donné.
+func statsRequest(cfg *config.Config, validator gin.H, ifNoneMatch string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/links/abc123/stats", nil)
+	if ifNoneMatch != "" {
+		c.Request.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	if !statsNotModified(c, cfg, validator) {
+		respondStats(c, cfg, validator)
+	}
+	c.Writer.WriteHeaderNow() // Comme gin en fin de requête, pour un 304 sans corps
+	return recorder
+}
+
+func TestRespondStatsETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Server.StatsETag = true
+
+	first := statsRequest(cfg, gin.H{"total_clicks": 3}, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("première réponse: statut %d, ETag %q", first.Code, etag)
+	}
+
+	if unchanged := statsRequest(cfg, gin.H{"total_clicks": 3}, etag); unchanged.Code != http.StatusNotModified {
+		t.Errorf("statistiques inchangées: statut %d, attendu 304", unchanged.Code)
+	}
+
+	changed := statsRequest(cfg, gin.H{"total_clicks": 4}, etag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Errorf("statistiques modifiées: statut %d, ETag %q, attendu 200 avec un nouvel ETag", changed.Code, changed.Header().Get("ETag"))
+	}
+
+	cfg.Server.StatsETag = false
+	if disabled := statsRequest(cfg, gin.H{"total_clicks": 3}, etag); disabled.Code != http.StatusOK || disabled.Header().Get("ETag") != "" {
+		t.Errorf("ETag désactivé: statut %d, ETag %q", disabled.Code, disabled.Header().Get("ETag"))
+	}
+}
+
+func TestLinkStatsETagFollowsClicks(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.Server.StatsETag = true })
+	link := api.createLink(t, "etag01", "https://example.com/etag")
+	path := "/api/v1/links/etag01/stats"
+
+	first := api.do(http.MethodGet, path, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("première réponse: statut %d, ETag %q", first.Code, etag)
+	}
+	if unchanged := api.do(http.MethodGet, path, "", "If-None-Match", etag); unchanged.Code != http.StatusNotModified {
+		t.Errorf("statistiques inchangées: statut %d, attendu 304", unchanged.Code)
+	}
+	// La query string fait partie de l'ETag : une autre vue des statistiques n'est pas validée par le même ETag
+	if other := api.do(http.MethodGet, path+"?granularity=day", "", "If-None-Match", etag); other.Code != http.StatusOK {
+		t.Errorf("série temporelle: statut %d, attendu 200", other.Code)
+	}
+
+	// Un nouveau clic change l'ETag
+	api.db.Create(&models.Click{LinkID: link.ID, Timestamp: time.Now().Add(-48 * time.Hour)})
+	clicked := api.do(http.MethodGet, path, "", "If-None-Match", etag)
+	clickedETag := clicked.Header().Get("ETag")
+	if clicked.Code != http.StatusOK || clickedETag == etag {
+		t.Fatalf("après un clic: statut %d, ETag %q, attendu 200 avec un nouvel ETag", clicked.Code, clickedETag)
+	}
+
+	// Le compactage ne change pas le total mais modifie les répartitions : l'ETag change aussi
+	if _, err := repository.NewClickRepository(api.db).RollupClicksBefore(time.Now().Add(-24 * time.Hour)); err != nil {
+		t.Fatalf("RollupClicksBefore: %v", err)
+	}
+	rolledUp := api.do(http.MethodGet, path, "", "If-None-Match", clickedETag)
+	if rolledUp.Code != http.StatusOK || rolledUp.Header().Get("ETag") == clickedETag {
+		t.Errorf("après compactage: statut %d, attendu 200 avec un nouvel ETag", rolledUp.Code)
+	}
+	var body map[string]any
+	decodeJSON(t, rolledUp, &body)
+	if body["total_clicks"] != float64(1) {
+		t.Errorf("total_clicks = %v, attendu 1", body["total_clicks"])
+	}
+}
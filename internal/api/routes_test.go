@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/middleware"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testAPI regroupe un routeur configuré par SetupRoutes et la base SQLite en mémoire qui le sert.
+type testAPI struct {
+	router  *gin.Engine
+	db      *gorm.DB
+	service *services.LinkService
+	cfg     *config.Config
+}
+
+// testConfig retourne la configuration par défaut (aucun config.yaml dans le dossier du package), modifiée par mutate.
+// Les destinations privées sont autorisées pour ne pas dépendre du DNS dans les tests.
+func testConfig(t *testing.T, mutate func(cfg *config.Config)) *config.Config {
+	t.Helper()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() a échoué: %v", err)
+	}
+	cfg.Server.AllowPrivateURLs = true
+	if mutate != nil {
+		mutate(cfg)
+	}
+	return cfg
+}
+
+// newTestAPI monte les routes de l'API sur une base SQLite en mémoire propre au test, sans rate limiting.
+func newTestAPI(t *testing.T, mutate func(cfg *config.Config)) *testAPI {
+	t.Helper()
+	return newTestAPIWithLimits(t, mutate, nil, nil)
+}
+
+// newTestAPIWithLimits monte les routes de l'API avec le rate limiter et les règles par route donnés.
+func newTestAPIWithLimits(t *testing.T, mutate func(cfg *config.Config), rateLimiter middleware.RateLimiter,
+	rules []middleware.RouteRateLimit) *testAPI {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("ouverture de la base de test: %v", err)
+	}
+	if err := conn.AutoMigrate(&models.Link{}, &models.Click{}, &models.ClickDaily{}); err != nil {
+		t.Fatalf("migration de la base de test: %v", err)
+	}
+	sqlDB, _ := conn.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	cfg := testConfig(t, mutate)
+	service := services.NewLinkService(repository.NewLinkRepository(conn), cfg)
+	router := gin.New()
+	if err := SetupRoutes(router, service, cfg, rateLimiter, rules, nil, nil); err != nil {
+		t.Fatalf("SetupRoutes: %v", err)
+	}
+	return &testAPI{router: router, db: conn, service: service, cfg: cfg}
+}
+
+// do exécute une requête sur le routeur. headers alterne noms et valeurs d'en-têtes.
+func (a *testAPI) do(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	a.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// decodeJSON décode le corps JSON d'une réponse dans v.
+func decodeJSON(t *testing.T, recorder *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(recorder.Body.Bytes(), v); err != nil {
+		t.Fatalf("corps JSON invalide %q: %v", recorder.Body.String(), err)
+	}
+}
+
+// createLink insère directement un lien en base (sans passer par l'API) et le retourne.
+func (a *testAPI) createLink(t *testing.T, shortCode, longURL string) *models.Link {
+	t.Helper()
+	link := &models.Link{ShortCode: shortCode, LongURL: longURL, IsActive: true}
+	if err := a.db.Create(link).Error; err != nil {
+		t.Fatalf("création du lien %s: %v", shortCode, err)
+	}
+	return link
+}
+
+func TestReadOnlyRejectsWritesButServesRedirects(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.Server.ReadOnly = true })
+	api.createLink(t, "abc123", "https://example.com/page")
+
+	create := api.do(http.MethodPost, "/api/v1/links", `{"long_url":"https://example.com/new"}`)
+	if create.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /api/v1/links: statut %d, attendu 503", create.Code)
+	}
+	if !strings.Contains(create.Body.String(), "read-only") {
+		t.Errorf("POST /api/v1/links: corps %q, attendu un message de lecture seule", create.Body.String())
+	}
+	if del := api.do(http.MethodDelete, "/api/v1/links/abc123", ""); del.Code != http.StatusServiceUnavailable {
+		t.Errorf("DELETE /api/v1/links/abc123: statut %d, attendu 503", del.Code)
+	}
+
+	redirect := api.do(http.MethodGet, "/abc123", "")
+	if redirect.Code != http.StatusFound || redirect.Header().Get("Location") != "https://example.com/page" {
+		t.Errorf("GET /abc123: statut %d, Location %q, attendu 302 vers la destination", redirect.Code, redirect.Header().Get("Location"))
+	}
+	if stats := api.do(http.MethodGet, "/api/v1/links/abc123/stats", ""); stats.Code != http.StatusOK {
+		t.Errorf("GET stats: statut %d, attendu 200", stats.Code)
+	}
+	if health := api.do(http.MethodGet, "/health", ""); health.Code != http.StatusOK {
+		t.Errorf("GET /health: statut %d, attendu 200", health.Code)
+	}
+}
+
+func TestReadOnlyRedirectEnforcesMaxClicksWithoutWriting(t *testing.T) {
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.Server.ReadOnly = true })
+	maxClicks := 2
+	link := &models.Link{ShortCode: "limited", LongURL: "https://example.com/limited", IsActive: true, MaxClicks: &maxClicks}
+	exhausted := &models.Link{ShortCode: "spent", LongURL: "https://example.com/spent", IsActive: true,
+		MaxClicks: &maxClicks, RedirectCount: 2}
+	for _, l := range []*models.Link{link, exhausted} {
+		if err := api.db.Create(l).Error; err != nil {
+			t.Fatalf("création du lien %s: %v", l.ShortCode, err)
+		}
+	}
+
+	if rec := api.do(http.MethodGet, "/limited", ""); rec.Code != http.StatusFound {
+		t.Errorf("GET /limited: statut %d, attendu 302", rec.Code)
+	}
+	var stored models.Link
+	if err := api.db.First(&stored, link.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.RedirectCount != 0 {
+		t.Errorf("redirect_count = %d, attendu 0 (aucune écriture en lecture seule)", stored.RedirectCount)
+	}
+
+	if rec := api.do(http.MethodGet, "/spent", ""); rec.Code != http.StatusGone {
+		t.Errorf("GET /spent: statut %d, attendu 410 (limite atteinte)", rec.Code)
+	}
+}
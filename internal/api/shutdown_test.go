@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/workers"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGracefulShutdownRecordsQueuedClicks(t *testing.T) {
+	api := newTestAPI(t, nil)
+	link := api.createLink(t, "abc123", "https://example.com/page")
+
+	// Workers par lots avec un intervalle de vidage long : seuls les clics vidés à l'arrêt peuvent être enregistrés
+	clickEvents := make(chan models.ClickEvent, 100)
+	clickWorkers := workers.StartClickWorkers(2, clickEvents, repository.NewClickRepository(api.db),
+		repository.NewLinkRepository(api.db), nil, nil, 50, time.Hour)
+	router := gin.New()
+	if err := SetupRoutes(router, api.service, api.cfg, nil, nil, clickEvents, nil); err != nil {
+		t.Fatalf("SetupRoutes: %v", err)
+	}
+	server := httptest.NewServer(router)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	const redirects = 5
+	for i := 0; i < redirects; i++ {
+		resp, err := client.Get(server.URL + "/abc123")
+		if err != nil {
+			t.Fatalf("redirection %d: %v", i+1, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatalf("redirection %d: statut %d, attendu 302", i+1, resp.StatusCode)
+		}
+	}
+
+	// Même séquence que run-server : arrêt du serveur HTTP, puis vidage du channel
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Config.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	server.Close()
+	if !workers.DrainClickWorkers(clickEvents, clickWorkers, 5*time.Second) {
+		t.Fatal("DrainClickWorkers: délai expiré")
+	}
+
+	var count int64
+	api.db.Model(&models.Click{}).Where("link_id = ?", link.ID).Count(&count)
+	if count != redirects {
+		t.Errorf("clics enregistrés = %d, attendu %d", count, redirects)
+	}
+}
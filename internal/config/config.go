@@ -1,9 +1,14 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
-	"log" // Pour logger les informations ou erreurs de chargement de config
+	"log/slog" // Pour logger les informations de chargement de config
+	"net"
+	"net/url"
+	"strings"
 
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/spf13/viper" // La bibliothèque pour la gestion de configuration
 )
 
@@ -15,41 +20,263 @@ type Config struct {
 	Database    DatabaseConfig    `mapstructure:"database"`
 	Analytics   AnalyticsConfig   `mapstructure:"analytics"`
 	Monitor     MonitorConfig     `mapstructure:"monitor"`
+	Cleanup     CleanupConfig     `mapstructure:"cleanup"`      // Découpage de la purge des liens expirés
 	RateLimiter RateLimiterConfig `mapstructure:"rate_limiter"` // Configuration du rate limiting (feature bonus)
+	// Configuration du circuit breaker protégeant la base de données lors des créations
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	Security       SecurityConfig       `mapstructure:"security"` // Options liées à la lutte contre les abus
+	Cache          CacheConfig          `mapstructure:"cache"`    // Cache des liens servis par les redirections
+	Logging        LoggingConfig        `mapstructure:"logging"`  // Niveau et format des logs
+	Auth           AuthConfig           `mapstructure:"auth"`     // Authentification des écritures de l'API par clé
 }
 
 // ServerConfig contient la configuration du serveur web Gin.
 type ServerConfig struct {
-	Port    int    `mapstructure:"port"`
-	BaseURL string `mapstructure:"base_url"`
+	Port               int    `mapstructure:"port"`
+	BaseURL            string `mapstructure:"base_url"`
+	ForwardQueryParams bool   `mapstructure:"forward_query_params"` // Fusionner la query string entrante dans l'URL de destination
+	QueryParamConflict string `mapstructure:"query_param_conflict"` // Résolution des conflits de paramètres: "stored" ou "incoming"
+	HealthPath         string `mapstructure:"health_path"`          // Chemin de la route de health check (ex: /health, /healthz)
+	EmitPreconnect     bool   `mapstructure:"emit_preconnect"`      // Ajouter un en-tête Link rel=preconnect vers l'origine de destination
+	ErrorTemplatesDir  string `mapstructure:"error_templates_dir"`  // Dossier des pages HTML d'erreur (404.html, 410.html, 500.html)
+	NotFoundTemplate   string `mapstructure:"not_found_template"`   // Fichier de la page HTML 404, prioritaire sur error_templates_dir
+	ExpiredTemplate    string `mapstructure:"expired_template"`     // Fichier de la page HTML 410, prioritaire sur error_templates_dir
+	MaxRedirectHops    int    `mapstructure:"max_redirect_hops"`    // Nombre maximum de sauts via X-Shortener-Hops avant 508 (0 = désactivé)
+	// Préfixes de routes interdits comme alias, seuls ou suivis d'un tiret (en plus de "api" et du health check)
+	ReservedRoutePrefixes  []string `mapstructure:"reserved_route_prefixes"`
+	ReserveVersionPrefixes bool     `mapstructure:"reserve_version_prefixes"`  // Refuser les alias de version pure (v1, v2, ...)
+	LogLatency             bool     `mapstructure:"log_latency"`               // Journaliser le détail de la latence des redirections (lookup et total)
+	ExposeClickCountHeader bool     `mapstructure:"expose_click_count_header"` // Ajouter l'en-tête X-Total-Clicks aux redirections
+	DefaultScheme          string   `mapstructure:"default_scheme"`            // Schéma ajouté aux URLs sans schéma ("http", "https" ou vide pour les refuser)
+	StatsETag              bool     `mapstructure:"stats_etag"`                // ETag et 304 Not Modified sur les statistiques
+	AllowJSONP             bool     `mapstructure:"allow_jsonp"`               // Accepter ?callback=fn (JSONP) sur les statistiques
+	ReadOnly               bool     `mapstructure:"read_only"`                 // Refuser toutes les écritures (redirections, statistiques et health restent disponibles)
+	ShortCodeLength        int      `mapstructure:"short_code_length"`         // Longueur par défaut des codes courts générés
+	MinShortCodeLength     int      `mapstructure:"min_short_code_length"`     // Longueur minimale acceptée pour code_length
+	MaxShortCodeLength     int      `mapstructure:"max_short_code_length"`     // Longueur maximale acceptée pour code_length (au plus models.ShortCodeMaxLength)
+	CodeFirstCharAlpha     bool     `mapstructure:"code_first_char_alpha"`     // Faire commencer les codes générés par une lettre
+	LinkAliases            bool     `mapstructure:"link_aliases"`              // Activer POST /api/v1/links/:shortCode/aliases (codes supplémentaires)
+	AllowPrivateURLs       bool     `mapstructure:"allow_private_urls"`        // Accepter les destinations internes (loopback, réseaux privés, link-local)
+	DedupeURLs             bool     `mapstructure:"dedupe_urls"`               // Réutiliser un lien existant vers la même URL plutôt qu'en créer un nouveau
+	CollapseWWW            bool     `mapstructure:"collapse_www"`              // Avec dedupe_urls : considérer "www.example.com" et "example.com" comme le même hôte
+	MaxGenerationRetries   int      `mapstructure:"max_generation_retries"`    // Codes tirés au plus par création en cas de collisions
+	CodeStrategy           string   `mapstructure:"code_strategy"`             // "random" (tirage aléatoire) ou "sequential" (ID encodé en base 62)
+	UnambiguousCodes       bool     `mapstructure:"unambiguous_codes"`         // Exclure des codes générés les caractères ambigus (l, I, 1, O, 0)
+	ShutdownTimeoutSeconds int      `mapstructure:"shutdown_timeout_seconds"`  // Délai laissé aux requêtes en cours et aux workers de clics à l'arrêt
+	RedirectStatus         int      `mapstructure:"redirect_status"`           // Code HTTP des redirections : 301, 302, 307 ou 308
+	PreviewRedirect        bool     `mapstructure:"preview_redirect"`          // Afficher une page intermédiaire avec la destination avant de rediriger
+	CORSOrigins            []string `mapstructure:"cors_origins"`              // Origines autorisées à appeler l'API depuis un navigateur ("*" pour toutes, vide = CORS désactivé)
+	CORSAllowCredentials   bool     `mapstructure:"cors_allow_credentials"`    // Autoriser les requêtes CORS avec cookies ou en-tête Authorization
+	// Réponse 200 JSON au lieu d'une redirection pour les clients API
+	JSONResolve JSONResolveConfig `mapstructure:"json_resolve"`
+}
+
+// JSONResolveConfig contrôle la réponse JSON {"long_url": ...} renvoyée à la place de la redirection
+// aux clients qui envoient Accept: application/json ou l'en-tête X-No-Redirect.
+type JSONResolveConfig struct {
+	Enabled     bool `mapstructure:"enabled"`      // Activer la résolution JSON
+	RecordClick bool `mapstructure:"record_click"` // Compter ces résolutions comme des clics
 }
 
 // DatabaseConfig contient la configuration de la base de données.
 type DatabaseConfig struct {
-	Name string `mapstructure:"name"`
+	Driver                 string `mapstructure:"driver"`                    // "sqlite" (fichier 'name') ou "postgres" (chaîne de connexion 'dsn')
+	Name                   string `mapstructure:"name"`                      // Fichier SQLite (driver: sqlite)
+	DSN                    string `mapstructure:"dsn"`                       // Chaîne de connexion PostgreSQL (ex: host=localhost user=app dbname=urlshortener)
+	ConnectAttempts        int    `mapstructure:"connect_attempts"`          // Nombre de tentatives de connexion avant d'abandonner
+	ConnectRetryIntervalMs int    `mapstructure:"connect_retry_interval_ms"` // Délai initial entre deux tentatives (doublé à chaque échec)
 }
 
 // AnalyticsConfig contient la configuration des analytics asynchrones.
 type AnalyticsConfig struct {
-	BufferSize  int `mapstructure:"buffer_size"`
-	WorkerCount int `mapstructure:"worker_count"`
+	Enabled     bool         `mapstructure:"enabled"` // Désactiver pour ne plus enregistrer aucun clic (ni channel, ni workers)
+	BufferSize  int          `mapstructure:"buffer_size"`
+	WorkerCount int          `mapstructure:"worker_count"`
+	Rollup      RollupConfig `mapstructure:"rollup"` // Compactage des anciens clics en agrégats journaliers
+	// Déduplication des clics d'un même visiteur (IP + User-Agent) sur un lien
+	DedupWindowSeconds int    `mapstructure:"dedup_window_seconds"` // Fenêtre de déduplication (0 = désactivée)
+	DedupBackend       string `mapstructure:"dedup_backend"`        // "memory" (par instance) ou "redis" (partagé entre répliques)
+	RedisAddr          string `mapstructure:"redis_addr"`           // Adresse host:port de Redis pour dedup_backend: redis
+	// Insertion des clics par lots (1 = un INSERT par clic)
+	BatchSize       int `mapstructure:"batch_size"`
+	FlushIntervalMs int `mapstructure:"flush_interval_ms"` // Délai maximal avant l'insertion d'un lot incomplet
+	// Base MaxMind (.mmdb) pour résoudre le pays des clics (vide = désactivé)
+	GeoIPDB string `mapstructure:"geoip_db"`
+}
+
+// RollupConfig contient la configuration du compactage de la table des clics.
+type RollupConfig struct {
+	Enabled       bool `mapstructure:"enabled"`         // Lancer le job de compactage périodique dans le serveur
+	OlderThanDays int  `mapstructure:"older_than_days"` // Âge à partir duquel les clics bruts sont agrégés
+	IntervalHours int  `mapstructure:"interval_hours"`  // Intervalle entre deux exécutions du job
 }
 
 // MonitorConfig contient la configuration du moniteur d'URLs.
 type MonitorConfig struct {
-	IntervalMinutes int `mapstructure:"interval_minutes"`
+	IntervalMinutes    int    `mapstructure:"interval_minutes"`
+	MinTLSVersion      string `mapstructure:"min_tls_version"`      // Version TLS minimale des sondes HTTPS ("1.0", "1.1", "1.2", "1.3")
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"` // Désactive la vérification des certificats (staging, certificats auto-signés)
+	// Service de vérification de sécurité des destinations (vide pour désactiver)
+	SafetyCheckEndpoint  string `mapstructure:"safety_check_endpoint"`
+	SafetyCheckTimeoutMs int    `mapstructure:"safety_check_timeout_ms"` // Timeout d'un appel au service de vérification
+	DryRun               bool   `mapstructure:"dry_run"`                 // Observer uniquement : journaliser les désactivations sans les appliquer
+	// Purge périodique des liens expirés, au même intervalle : "off", "soft" (désactivation) ou "hard" (suppression)
+	PurgeExpired string `mapstructure:"purge_expired"`
+	// Liens dont la destination est injoignable : "off" (journaliser seulement), "warn" (désactiver mais
+	// continuer à rediriger avec un en-tête Warning) ou "block" (désactiver, la redirection répond 410)
+	Unreachable string `mapstructure:"unreachable"`
+	// Nombre de vérifications consécutives en échec avant de désactiver un lien injoignable
+	UnreachableThreshold int `mapstructure:"unreachable_threshold"`
+	// Exposer les métriques Prometheus sur GET /metrics
+	MetricsEnabled bool `mapstructure:"metrics_enabled"`
+}
+
+// CleanupConfig contient la configuration de la purge des liens expirés (monitor.purge_expired).
+type CleanupConfig struct {
+	BatchSize    int `mapstructure:"batch_size"`     // Nombre maximal de liens chargés et purgés par lot
+	BatchPauseMs int `mapstructure:"batch_pause_ms"` // Pause entre deux lots pour laisser respirer la base
+}
+
+// tlsVersions associe les valeurs acceptées pour monitor.min_tls_version aux constantes de crypto/tls.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSVersion retourne la constante crypto/tls correspondant à MinTLSVersion.
+// Retourne une erreur si la version configurée n'est pas reconnue.
+func (m MonitorConfig) TLSVersion() (uint16, error) {
+	version, ok := tlsVersions[m.MinTLSVersion]
+	if !ok {
+		return 0, fmt.Errorf("monitor.min_tls_version invalide: '%s' (valeurs acceptées: 1.0, 1.1, 1.2, 1.3)", m.MinTLSVersion)
+	}
+	return version, nil
 }
 
 // RateLimiterConfig contient la configuration du rate limiting (feature bonus).
 type RateLimiterConfig struct {
-	Enabled       bool `mapstructure:"enabled"`        // Activer ou désactiver le rate limiting
-	MaxRequests   int  `mapstructure:"max_requests"`   // Nombre maximum de requêtes par IP
-	WindowMinutes int  `mapstructure:"window_minutes"` // Fenêtre de temps en minutes
+	Enabled       bool   `mapstructure:"enabled"`        // Activer ou désactiver le rate limiting
+	MaxRequests   int    `mapstructure:"max_requests"`   // Nombre maximum de requêtes par IP
+	WindowMinutes int    `mapstructure:"window_minutes"` // Fenêtre de temps en minutes
+	Algorithm     string `mapstructure:"algorithm"`      // "fixed_window" ou "token_bucket"
+	Backend       string `mapstructure:"backend"`        // "memory" (par instance) ou "redis" (partagé entre instances)
+	RedisAddr     string `mapstructure:"redis_addr"`     // Adresse host:port de Redis pour backend: redis
+	Scope         string `mapstructure:"scope"`          // Routes sans règle soumises à la limite par défaut : "create", "api" ou "all"
+	// Limites propres à certaines routes, prioritaires sur la limite par défaut
+	Rules     []RateLimitRuleConfig `mapstructure:"rules"`
+	Allowlist []string              `mapstructure:"allowlist"` // IPs ou plages CIDR jamais limitées (monitoring, services internes)
+}
+
+// RateLimitRuleConfig définit la limite d'une route (rate_limiter.rules).
+type RateLimitRuleConfig struct {
+	Path          string `mapstructure:"path"`           // Route telle que déclarée dans Gin (ex: /api/v1/links, /:shortCode)
+	Method        string `mapstructure:"method"`         // Méthode HTTP concernée (ex: POST), vide pour toutes
+	MaxRequests   int    `mapstructure:"max_requests"`   // Nombre maximum de requêtes par IP sur cette route
+	WindowMinutes int    `mapstructure:"window_minutes"` // Fenêtre de temps en minutes
+}
+
+// CircuitBreakerConfig contient la configuration du circuit breaker sur le chemin de création.
+type CircuitBreakerConfig struct {
+	Enabled          bool `mapstructure:"enabled"`           // Activer ou désactiver le circuit breaker
+	FailureThreshold int  `mapstructure:"failure_threshold"` // Nombre d'erreurs DB consécutives avant ouverture
+	CooldownSeconds  int  `mapstructure:"cooldown_seconds"`  // Durée d'ouverture avant de tester la récupération
+}
+
+// SecurityConfig contient les options liées à la lutte contre les abus.
+type SecurityConfig struct {
+	StoreCreatorIP bool              `mapstructure:"store_creator_ip"` // Enregistrer l'IP du créateur de chaque lien
+	AdminToken     string            `mapstructure:"admin_token"`      // Jeton Bearer des appels administrateur (vide = accès admin désactivé)
+	CreateQuota    CreateQuotaConfig `mapstructure:"create_quota"`     // Quota de créations par IP sur une longue fenêtre
+	// Service externe consulté avant chaque création (vide pour désactiver)
+	URLCheckEndpoint  string `mapstructure:"url_check_endpoint"`
+	URLCheckTimeoutMs int    `mapstructure:"url_check_timeout_ms"` // Timeout de l'appel au service de vérification
+	URLCheckFailOpen  bool   `mapstructure:"url_check_fail_open"`  // Autoriser la création si le service est injoignable
+	// Blocage temporaire des IPs qui enchaînent les alias personnalisés pris ou invalides
+	AliasThrottle AliasThrottleConfig `mapstructure:"alias_throttle"`
+	// Blocage temporaire des mots de passe incorrects répétés sur un lien protégé, par IP et par code
+	PasswordThrottle PasswordThrottleConfig `mapstructure:"password_throttle"`
+	// Domaines refusés comme destination, sous-domaines compris
+	BlockedDomains []string `mapstructure:"blocked_domains"`
+}
+
+// AliasThrottleConfig contient la configuration du blocage des tentatives d'alias infructueuses.
+type AliasThrottleConfig struct {
+	Enabled         bool     `mapstructure:"enabled"`          // Activer ou désactiver le blocage
+	MaxFailures     int      `mapstructure:"max_failures"`     // Nombre d'échecs tolérés par IP dans la fenêtre
+	WindowMinutes   int      `mapstructure:"window_minutes"`   // Fenêtre de comptage des échecs en minutes
+	CooldownMinutes int      `mapstructure:"cooldown_minutes"` // Durée du blocage en minutes
+	Whitelist       []string `mapstructure:"whitelist"`        // IPs ou plages CIDR jamais bloquées
+}
+
+// PasswordThrottleConfig contient la configuration du blocage des mots de passe incorrects sur les liens protégés.
+type PasswordThrottleConfig struct {
+	Enabled         bool `mapstructure:"enabled"`          // Activer ou désactiver le blocage
+	MaxFailures     int  `mapstructure:"max_failures"`     // Nombre d'échecs tolérés par IP et par code dans la fenêtre
+	WindowMinutes   int  `mapstructure:"window_minutes"`   // Fenêtre de comptage des échecs en minutes
+	CooldownMinutes int  `mapstructure:"cooldown_minutes"` // Durée du blocage en minutes
+}
+
+// CreateQuotaConfig limite le nombre de liens qu'une même IP peut créer sur une fenêtre longue
+// (ex: 100 liens par jour), indépendamment du rate limiting par minute.
+type CreateQuotaConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`      // Activer ou désactiver le quota
+	MaxLinks    int      `mapstructure:"max_links"`    // Nombre maximum de liens par IP sur la fenêtre
+	WindowHours int      `mapstructure:"window_hours"` // Durée de la fenêtre en heures
+	Whitelist   []string `mapstructure:"whitelist"`    // IPs ou plages CIDR exemptées du quota
+}
+
+// CacheConfig contient la configuration du cache de lecture des liens par code court.
+type CacheConfig struct {
+	RedisAddr  string `mapstructure:"redis_addr"`  // Adresse host:port de Redis (vide = pas de cache Redis)
+	MaxEntries int    `mapstructure:"max_entries"` // Taille du cache LRU en mémoire, sans Redis (0 = pas de cache en mémoire)
+	TTLSeconds int    `mapstructure:"ttl_seconds"` // Durée de vie d'une entrée, bornée par l'expiration du lien
+	// Servir une entrée expirée quand la base est en erreur, pendant au plus StaleTTLSeconds après son expiration
+	ServeStaleOnError bool `mapstructure:"serve_stale_on_error"`
+	StaleTTLSeconds   int  `mapstructure:"stale_ttl_seconds"` // Durée de conservation des entrées expirées (serve_stale_on_error)
+}
+
+// LoggingConfig contient la configuration des logs structurés (log/slog).
+type LoggingConfig struct {
+	Level  string `mapstructure:"level"`  // "debug", "info", "warn" ou "error"
+	Format string `mapstructure:"format"` // "text" (clé=valeur) ou "json" (agrégateurs de logs)
+}
+
+// AuthConfig contient la configuration de l'authentification par clé d'API des routes d'écriture.
+type AuthConfig struct {
+	Enabled        bool     `mapstructure:"enabled"`          // Exiger une clé d'API pour les créations, modifications et suppressions
+	APIKeys        []string `mapstructure:"api_keys"`         // Clés acceptées dans l'en-tête "Authorization: Bearer <clé>"
+	StatsOwnerOnly bool     `mapstructure:"stats_owner_only"` // Réserver les statistiques d'un lien à son propriétaire
+}
+
+// validateIPList vérifie que chaque entrée de la liste 'key' est une IP ou une plage CIDR.
+func validateIPList(key string, entries []string) error {
+	for _, entry := range entries {
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return fmt.Errorf("%s invalide: '%s' (une IP ou une plage CIDR est attendue, ex: 10.0.0.0/8)", key, entry)
+		}
+	}
+	return nil
+}
+
+// normalizeBaseURL vérifie que server.base_url est une URL absolue http(s) et la retourne sans slash final.
+func normalizeBaseURL(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("server.base_url invalide: '%s' (une URL absolue http ou https est attendue, ex: https://sho.rt)", baseURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("server.base_url invalide: '%s' (ni query string ni fragment)", baseURL)
+	}
+	return strings.TrimRight(baseURL, "/"), nil
 }
 
 // LoadConfig charge la configuration de l'application en utilisant Viper.
 // Elle recherche un fichier 'config.yaml' dans le dossier 'configs/'.
 // Elle définit également des valeurs par défaut si le fichier de config est absent ou incomplet.
+// Chaque clé peut être surchargée par une variable d'environnement préfixée par URLSHORT_,
+// les points devenant des underscores (ex: server.port -> URLSHORT_SERVER_PORT), prioritaire sur le fichier.
 func LoadConfig() (*Config, error) {
 	// Spécifie le chemin où Viper doit chercher les fichiers de config.
 	// on cherche dans le dossier 'configs' relatif au répertoire d'exécution.
@@ -62,31 +289,141 @@ func LoadConfig() (*Config, error) {
 	// Spécifie le type de fichier de config.
 	viper.SetConfigType("yaml")
 
+	// Surcharges par variables d'environnement (déploiements conteneurisés).
+	// Seules les clés connues de Viper (valeur par défaut ou présente dans le fichier) sont lues ;
+	// les listes s'écrivent séparées par des virgules (ex: URLSHORT_SECURITY_BLOCKED_DOMAINS=a.com,b.com).
+	viper.SetEnvPrefix("URLSHORT")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
 	// Définir les valeurs par défaut pour toutes les options de configuration.
 	// Ces valeurs seront utilisées si les clés correspondantes ne sont pas trouvées dans le fichier de config
 	// ou si le fichier n'existe pas.
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.forward_query_params", false)
+	viper.SetDefault("server.query_param_conflict", "stored")
+	viper.SetDefault("server.health_path", "/health")
+	viper.SetDefault("server.emit_preconnect", false)
+	viper.SetDefault("server.error_templates_dir", "")
+	viper.SetDefault("server.not_found_template", "")
+	viper.SetDefault("server.expired_template", "")
+	viper.SetDefault("server.max_redirect_hops", 10)
+	viper.SetDefault("server.reserved_route_prefixes", []string{})
+	viper.SetDefault("server.reserve_version_prefixes", false)
+	viper.SetDefault("server.log_latency", false)
+	viper.SetDefault("server.expose_click_count_header", false)
+	viper.SetDefault("server.default_scheme", "")
+	viper.SetDefault("server.stats_etag", true)
+	viper.SetDefault("server.read_only", false)
+	viper.SetDefault("server.allow_jsonp", false)
+	viper.SetDefault("server.short_code_length", 6)
+	viper.SetDefault("server.min_short_code_length", 4)
+	viper.SetDefault("server.max_short_code_length", 10)
+	viper.SetDefault("server.code_first_char_alpha", false)
+	viper.SetDefault("server.link_aliases", false)
+	viper.SetDefault("server.allow_private_urls", false)
+	viper.SetDefault("server.dedupe_urls", false)
+	viper.SetDefault("server.collapse_www", false)
+	viper.SetDefault("server.max_generation_retries", 5)
+	viper.SetDefault("server.code_strategy", "random")
+	viper.SetDefault("server.unambiguous_codes", false)
+	viper.SetDefault("server.shutdown_timeout_seconds", 5)
+	viper.SetDefault("server.redirect_status", 302)
+	viper.SetDefault("server.preview_redirect", false)
+	viper.SetDefault("server.cors_origins", []string{})
+	viper.SetDefault("server.cors_allow_credentials", false)
+	viper.SetDefault("server.json_resolve.enabled", false)
+	viper.SetDefault("server.json_resolve.record_click", true)
+	viper.SetDefault("database.driver", "sqlite")
 	viper.SetDefault("database.name", "url_shortener.db")
+	viper.SetDefault("database.dsn", "")
+	viper.SetDefault("database.connect_attempts", 5)
+	viper.SetDefault("database.connect_retry_interval_ms", 500)
+	viper.SetDefault("analytics.enabled", true)
 	viper.SetDefault("analytics.buffer_size", 1000)
 	viper.SetDefault("analytics.worker_count", 5)
+	viper.SetDefault("analytics.batch_size", 1)
+	viper.SetDefault("analytics.flush_interval_ms", 1000)
+	viper.SetDefault("analytics.dedup_window_seconds", 0)
+	viper.SetDefault("analytics.dedup_backend", "memory")
+	viper.SetDefault("analytics.redis_addr", "localhost:6379")
+	viper.SetDefault("analytics.geoip_db", "")
+	viper.SetDefault("analytics.rollup.enabled", false)
+	viper.SetDefault("analytics.rollup.older_than_days", 90)
+	viper.SetDefault("analytics.rollup.interval_hours", 24)
 	viper.SetDefault("monitor.interval_minutes", 5)
+	viper.SetDefault("monitor.min_tls_version", "1.2")
+	viper.SetDefault("monitor.insecure_skip_verify", false)
+	viper.SetDefault("monitor.safety_check_endpoint", "")
+	viper.SetDefault("monitor.safety_check_timeout_ms", 2000)
+	viper.SetDefault("monitor.dry_run", false)
+	viper.SetDefault("monitor.purge_expired", "off")
+	viper.SetDefault("monitor.metrics_enabled", false)
+	viper.SetDefault("monitor.unreachable", "off")
+	viper.SetDefault("monitor.unreachable_threshold", 3)
+	viper.SetDefault("cleanup.batch_size", 500)
+	viper.SetDefault("cleanup.batch_pause_ms", 100)
 	// Valeurs par défaut pour le rate limiting (feature bonus)
 	viper.SetDefault("rate_limiter.enabled", true)
 	viper.SetDefault("rate_limiter.max_requests", 10)
 	viper.SetDefault("rate_limiter.window_minutes", 1)
+	viper.SetDefault("rate_limiter.algorithm", "fixed_window")
+	viper.SetDefault("rate_limiter.backend", "memory")
+	viper.SetDefault("rate_limiter.redis_addr", "localhost:6379")
+	viper.SetDefault("rate_limiter.scope", "api")
+	viper.SetDefault("rate_limiter.rules", []RateLimitRuleConfig{})
+	viper.SetDefault("rate_limiter.allowlist", []string{})
+	// Valeurs par défaut pour la sécurité
+	viper.SetDefault("security.store_creator_ip", false)
+	viper.SetDefault("security.create_quota.enabled", false)
+	viper.SetDefault("security.create_quota.max_links", 100)
+	viper.SetDefault("security.create_quota.window_hours", 24)
+	viper.SetDefault("security.create_quota.whitelist", []string{})
+	viper.SetDefault("security.url_check_endpoint", "")
+	viper.SetDefault("security.url_check_timeout_ms", 2000)
+	viper.SetDefault("security.url_check_fail_open", false)
+	viper.SetDefault("security.admin_token", "")
+	viper.SetDefault("security.alias_throttle.enabled", false)
+	viper.SetDefault("security.alias_throttle.max_failures", 5)
+	viper.SetDefault("security.alias_throttle.window_minutes", 10)
+	viper.SetDefault("security.alias_throttle.cooldown_minutes", 15)
+	viper.SetDefault("security.alias_throttle.whitelist", []string{})
+	viper.SetDefault("security.password_throttle.enabled", true)
+	viper.SetDefault("security.password_throttle.max_failures", 5)
+	viper.SetDefault("security.password_throttle.window_minutes", 10)
+	viper.SetDefault("security.password_throttle.cooldown_minutes", 15)
+	viper.SetDefault("security.blocked_domains", []string{})
+	// Valeurs par défaut pour le cache des liens
+	viper.SetDefault("cache.redis_addr", "")
+	viper.SetDefault("cache.max_entries", 0)
+	viper.SetDefault("cache.ttl_seconds", 300)
+	viper.SetDefault("cache.serve_stale_on_error", false)
+	viper.SetDefault("cache.stale_ttl_seconds", 3600)
+	// Valeurs par défaut pour les logs
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "text")
+	viper.SetDefault("auth.enabled", false)
+	viper.SetDefault("auth.api_keys", []string{})
+	viper.SetDefault("auth.stats_owner_only", false)
+	// Valeurs par défaut pour le circuit breaker
+	viper.SetDefault("circuit_breaker.enabled", true)
+	viper.SetDefault("circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("circuit_breaker.cooldown_seconds", 30)
 
 	// Lire le fichier de configuration.
+	// Les logs du chargement passent par le handler slog par défaut : logging.Setup, qui dépend de cette
+	// configuration, n'est appelé qu'après. Ils sont donc toujours au format texte, niveau INFO.
 	if err := viper.ReadInConfig(); err != nil {
 		// Si le fichier n'est pas trouvé, on continue avec les valeurs par défaut
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Println("Fichier de configuration non trouvé. Utilisation des valeurs par défaut.")
+			slog.Info("Fichier de configuration non trouvé. Utilisation des valeurs par défaut.", "component", "config")
 		} else {
 			// Autre erreur de lecture
 			return nil, fmt.Errorf("erreur lors de la lecture du fichier de configuration: %w", err)
 		}
 	} else {
-		log.Printf("Fichier de configuration chargé: %s", viper.ConfigFileUsed())
+		slog.Info("Fichier de configuration chargé", "component", "config", "path", viper.ConfigFileUsed())
 	}
 
 	// Démapper (unmarshal) la configuration lue (ou les valeurs par défaut) dans la structure Config.
@@ -95,9 +432,229 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("erreur lors du démappage de la configuration: %w", err)
 	}
 
+	// Valider la configuration pour échouer dès le démarrage en cas d'erreur
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration invalide: %w", err)
+	}
+
+	// Retirer le slash final de l'URL de base (déjà validée), pour que BaseURL + "/" + code ne double pas le slash
+	cfg.Server.BaseURL, _ = normalizeBaseURL(cfg.Server.BaseURL)
+
 	// Log  pour vérifier la config chargée
-	log.Printf("Configuration loaded: Server Port=%d, DB Name=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
-		cfg.Server.Port, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
+	slog.Info("Configuration loaded", "component", "config", "port", cfg.Server.Port, "db_name", cfg.Database.Name,
+		"analytics_buffer", cfg.Analytics.BufferSize, "monitor_interval_minutes", cfg.Monitor.IntervalMinutes)
 
 	return &cfg, nil // Retourne la configuration chargée
 }
+
+// Validate vérifie la cohérence de la configuration chargée et retourne une erreur descriptive
+// pour la première valeur invalide rencontrée.
+func (c *Config) Validate() error {
+	// Valider le port d'écoute
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		return fmt.Errorf("server.port invalide: %d (attendu entre 1 et 65535)", c.Server.Port)
+	}
+
+	// Valider la version TLS minimale du moniteur
+	if _, err := c.Monitor.TLSVersion(); err != nil {
+		return err
+	}
+
+	// Valider le moteur de base de données
+	if driver := c.Database.Driver; driver != "sqlite" && driver != "postgres" {
+		return fmt.Errorf("database.driver invalide: '%s' (valeurs acceptées: sqlite, postgres)", driver)
+	}
+	if c.Database.Driver == "postgres" && c.Database.DSN == "" {
+		return fmt.Errorf("database.dsn est requis avec database.driver: postgres")
+	}
+
+	// Valider le backend de déduplication des clics
+	if backend := c.Analytics.DedupBackend; backend != "memory" && backend != "redis" {
+		return fmt.Errorf("analytics.dedup_backend invalide: '%s' (valeurs acceptées: memory, redis)", backend)
+	}
+
+	// Valider l'URL de base (non vide, absolue, http ou https)
+	if _, err := normalizeBaseURL(c.Server.BaseURL); err != nil {
+		return err
+	}
+
+	// Un channel sans buffer ou sans worker perdrait tous les clics
+	if c.Analytics.Enabled && c.Analytics.BufferSize < 1 {
+		return fmt.Errorf("analytics.buffer_size doit être au moins 1 quand les analytics sont activées")
+	}
+	if c.Analytics.Enabled && c.Analytics.WorkerCount < 1 {
+		return fmt.Errorf("analytics.worker_count doit être au moins 1 quand les analytics sont activées")
+	}
+
+	// L'insertion par lots a besoin d'un délai de vidage pour ne pas garder des clics indéfiniment
+	if c.Analytics.BatchSize > 1 && c.Analytics.FlushIntervalMs <= 0 {
+		return fmt.Errorf("analytics.flush_interval_ms doit être positif quand analytics.batch_size est supérieur à 1")
+	}
+
+	// Valider la résolution des conflits de paramètres transmis à la destination
+	if mode := c.Server.QueryParamConflict; mode != "stored" && mode != "incoming" {
+		return fmt.Errorf("server.query_param_conflict invalide: '%s' (valeurs acceptées: stored, incoming)", mode)
+	}
+
+	// Valider le schéma par défaut des URLs
+	if scheme := c.Server.DefaultScheme; scheme != "" && scheme != "http" && scheme != "https" {
+		return fmt.Errorf("server.default_scheme invalide: '%s' (valeurs acceptées: http, https ou vide)", scheme)
+	}
+
+	// Valider la stratégie de génération des codes ; un code dérivé de l'ID peut commencer par un chiffre
+	if strategy := c.Server.CodeStrategy; strategy != "random" && strategy != "sequential" {
+		return fmt.Errorf("server.code_strategy invalide: '%s' (valeurs acceptées: random, sequential)", strategy)
+	}
+	if c.Server.CodeStrategy == "sequential" && c.Server.CodeFirstCharAlpha {
+		return fmt.Errorf("server.code_first_char_alpha n'est pas compatible avec server.code_strategy: sequential")
+	}
+
+	// Valider les actions du moniteur sur les liens expirés et les destinations injoignables
+	if mode := c.Monitor.PurgeExpired; mode != "off" && mode != "soft" && mode != "hard" {
+		return fmt.Errorf("monitor.purge_expired invalide: '%s' (valeurs acceptées: off, soft, hard)", mode)
+	}
+	if mode := c.Monitor.Unreachable; mode != "off" && mode != "warn" && mode != "block" {
+		return fmt.Errorf("monitor.unreachable invalide: '%s' (valeurs acceptées: off, warn, block)", mode)
+	}
+	if c.Monitor.UnreachableThreshold < 1 {
+		return fmt.Errorf("monitor.unreachable_threshold doit être au moins 1")
+	}
+	if c.Cleanup.BatchSize < 1 {
+		return fmt.Errorf("cleanup.batch_size doit être au moins 1")
+	}
+	if c.Cleanup.BatchPauseMs < 0 {
+		return fmt.Errorf("cleanup.batch_pause_ms doit être positif")
+	}
+
+	// Valider le code HTTP des redirections
+	switch c.Server.RedirectStatus {
+	case 301, 302, 307, 308:
+	default:
+		return fmt.Errorf("server.redirect_status invalide: %d (valeurs acceptées: 301, 302, 307, 308)", c.Server.RedirectStatus)
+	}
+
+	// Valider les origines CORS
+	for _, origin := range c.Server.CORSOrigins {
+		if origin == "*" {
+			if c.Server.CORSAllowCredentials {
+				return fmt.Errorf("server.cors_allow_credentials n'est pas compatible avec l'origine \"*\" dans server.cors_origins")
+			}
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+			return fmt.Errorf("server.cors_origins invalide: '%s' (une origine est attendue, ex: https://app.example.com, ou \"*\")", origin)
+		}
+	}
+
+	// Valider le cache : un seul backend, et une durée de vie pour que les modifications finissent par être vues
+	if c.Cache.RedisAddr != "" && c.Cache.MaxEntries > 0 {
+		return fmt.Errorf("cache.redis_addr et cache.max_entries ne peuvent pas être utilisés ensemble")
+	}
+	if c.Cache.MaxEntries < 0 {
+		return fmt.Errorf("cache.max_entries doit être positif")
+	}
+	if (c.Cache.RedisAddr != "" || c.Cache.MaxEntries > 0) && c.Cache.TTLSeconds < 1 {
+		return fmt.Errorf("cache.ttl_seconds doit être au moins 1 quand un cache est activé")
+	}
+	if c.Cache.ServeStaleOnError && c.Cache.StaleTTLSeconds < 1 {
+		return fmt.Errorf("cache.stale_ttl_seconds doit être au moins 1 quand cache.serve_stale_on_error est activé")
+	}
+
+	// Valider le délai d'arrêt et le nombre de tirages de codes
+	if c.Server.ShutdownTimeoutSeconds < 1 {
+		return fmt.Errorf("server.shutdown_timeout_seconds doit être au moins 1")
+	}
+	if c.Server.MaxGenerationRetries < 1 {
+		return fmt.Errorf("server.max_generation_retries doit être au moins 1")
+	}
+
+	// Valider les bornes de longueur des codes courts générés (au plus models.ShortCodeMaxLength, la taille de la colonne)
+	if s := c.Server; s.MinShortCodeLength < 1 || s.MaxShortCodeLength > models.ShortCodeMaxLength ||
+		s.ShortCodeLength < s.MinShortCodeLength || s.ShortCodeLength > s.MaxShortCodeLength {
+		return fmt.Errorf("longueurs de code court invalides: il faut 1 <= min_short_code_length (%d) <= short_code_length (%d) <= max_short_code_length (%d) <= %d",
+			s.MinShortCodeLength, s.ShortCodeLength, s.MaxShortCodeLength, models.ShortCodeMaxLength)
+	}
+
+	// Un blocage sans seuil ou sans durée bloquerait dès le premier échec, ou jamais
+	if t := c.Security.PasswordThrottle; t.Enabled && (t.MaxFailures < 1 || t.WindowMinutes < 1 || t.CooldownMinutes < 1) {
+		return fmt.Errorf("security.password_throttle: max_failures, window_minutes et cooldown_minutes doivent être au moins 1")
+	}
+
+	// Le quota de création compte les liens par CreatorIP, qui doit donc être enregistrée
+	if c.Security.CreateQuota.Enabled && !c.Security.StoreCreatorIP {
+		return fmt.Errorf("security.create_quota nécessite security.store_creator_ip: true")
+	}
+
+	// Les listes d'IPs exemptées sont analysées une fois au démarrage : une entrée invalide y serait ignorée
+	if err := validateIPList("security.create_quota.whitelist", c.Security.CreateQuota.Whitelist); err != nil {
+		return err
+	}
+	if err := validateIPList("security.alias_throttle.whitelist", c.Security.AliasThrottle.Whitelist); err != nil {
+		return err
+	}
+
+	// Valider le niveau et le format des logs
+	switch strings.ToLower(c.Logging.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("logging.level invalide: '%s' (valeurs acceptées: debug, info, warn, error)", c.Logging.Level)
+	}
+	if format := c.Logging.Format; format != "text" && format != "json" {
+		return fmt.Errorf("logging.format invalide: '%s' (valeurs acceptées: text, json)", format)
+	}
+
+	// Valider les clés d'API si l'authentification est activée
+	if c.Auth.Enabled {
+		if len(c.Auth.APIKeys) == 0 {
+			return fmt.Errorf("auth.api_keys doit contenir au moins une clé quand auth.enabled est activé")
+		}
+		for _, key := range c.Auth.APIKeys {
+			if strings.TrimSpace(key) == "" {
+				return fmt.Errorf("auth.api_keys ne doit pas contenir de clé vide")
+			}
+		}
+	}
+
+	// Valider le rate limiting s'il est activé
+	if c.RateLimiter.Enabled && (c.RateLimiter.MaxRequests < 1 || c.RateLimiter.WindowMinutes < 1) {
+		return fmt.Errorf("rate_limiter.max_requests et rate_limiter.window_minutes doivent être au moins 1 quand le rate limiting est activé")
+	}
+	if algorithm := c.RateLimiter.Algorithm; algorithm != "fixed_window" && algorithm != "token_bucket" {
+		return fmt.Errorf("rate_limiter.algorithm invalide: '%s' (valeurs acceptées: fixed_window, token_bucket)", algorithm)
+	}
+	switch c.RateLimiter.Scope {
+	case "create", "api", "all":
+	default:
+		return fmt.Errorf("rate_limiter.scope invalide: '%s' (valeurs acceptées: create, api, all)", c.RateLimiter.Scope)
+	}
+	switch c.RateLimiter.Backend {
+	case "memory":
+	case "redis":
+		if c.RateLimiter.Algorithm != "fixed_window" {
+			return fmt.Errorf("rate_limiter.backend: redis ne prend en charge que rate_limiter.algorithm: fixed_window")
+		}
+		if c.RateLimiter.RedisAddr == "" {
+			return fmt.Errorf("rate_limiter.redis_addr est requis avec rate_limiter.backend: redis")
+		}
+	default:
+		return fmt.Errorf("rate_limiter.backend invalide: '%s' (valeurs acceptées: memory, redis)", c.RateLimiter.Backend)
+	}
+	if err := validateIPList("rate_limiter.allowlist", c.RateLimiter.Allowlist); err != nil {
+		return err
+	}
+	for i, rule := range c.RateLimiter.Rules {
+		if !strings.HasPrefix(rule.Path, "/") {
+			return fmt.Errorf("rate_limiter.rules[%d].path invalide: '%s' (une route commençant par / est attendue)", i, rule.Path)
+		}
+		if rule.MaxRequests < 1 || rule.WindowMinutes < 1 {
+			return fmt.Errorf("rate_limiter.rules[%d]: max_requests et window_minutes doivent être au moins 1", i)
+		}
+		switch strings.ToUpper(rule.Method) {
+		case "", "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		default:
+			return fmt.Errorf("rate_limiter.rules[%d].method invalide: '%s'", i, rule.Method)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/spf13/viper"
+)
+
+// validConfig charge la configuration par défaut (aucun config.yaml n'est présent dans le dossier du package).
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() a échoué: %v", err)
+	}
+	return cfg
+}
+
+func TestValidateQueryParamConflict(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"stored", false},
+		{"incoming", false},
+		{"", true},
+		{"merge", true},
+	}
+
+	for _, tt := range tests {
+		cfg := validConfig(t)
+		cfg.Server.QueryParamConflict = tt.value
+		err := cfg.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("query_param_conflict=%q: erreur = %v, attendu erreur = %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateMaxShortCodeLength(t *testing.T) {
+	tests := []struct {
+		max     int
+		wantErr bool
+	}{
+		{10, false},
+		{18, false},
+		{models.ShortCodeMaxLength, false},
+		{models.ShortCodeMaxLength + 1, true},
+	}
+
+	for _, tt := range tests {
+		cfg := validConfig(t)
+		cfg.Server.MaxShortCodeLength = tt.max
+		err := cfg.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("max_short_code_length=%d: erreur = %v, attendu erreur = %v", tt.max, err, tt.wantErr)
+		}
+	}
+}
+
+// loadConfigFresh recharge la configuration avec un état Viper vierge (LoadConfig utilise l'instance globale).
+func loadConfigFresh(t *testing.T) *Config {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	return validConfig(t)
+}
+
+func TestEnvOverridesDefaultAndFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "configs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	yaml := "server:\n  port: 9001\n"
+	if err := os.WriteFile(filepath.Join(dir, "configs", "config.yaml"), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	// Sans variable d'environnement : le fichier l'emporte sur la valeur par défaut
+	cfg := loadConfigFresh(t)
+	if cfg.Server.Port != 9001 {
+		t.Fatalf("server.port = %d, attendu 9001 (fichier)", cfg.Server.Port)
+	}
+
+	// La variable surcharge la valeur du fichier (server.port) et la valeur par défaut (analytics.buffer_size)
+	t.Setenv("URLSHORT_SERVER_PORT", "9000")
+	t.Setenv("URLSHORT_ANALYTICS_BUFFER_SIZE", "42")
+	cfg = loadConfigFresh(t)
+	if cfg.Server.Port != 9000 {
+		t.Errorf("server.port = %d, attendu 9000 (variable d'environnement)", cfg.Server.Port)
+	}
+	if cfg.Analytics.BufferSize != 42 {
+		t.Errorf("analytics.buffer_size = %d, attendu 42 (variable d'environnement)", cfg.Analytics.BufferSize)
+	}
+}
+
+func TestValidateIPLists(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(cfg *Config)
+	}{
+		{"rate_limiter.allowlist", func(cfg *Config) { cfg.RateLimiter.Allowlist = []string{"10.0.0.0/33"} }},
+		{"security.create_quota.whitelist", func(cfg *Config) { cfg.Security.CreateQuota.Whitelist = []string{"pas-une-ip"} }},
+		{"security.alias_throttle.whitelist", func(cfg *Config) { cfg.Security.AliasThrottle.Whitelist = []string{"10.0.0"} }},
+	}
+	for _, tt := range tests {
+		cfg := validConfig(t)
+		tt.mutate(cfg)
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.name) {
+			t.Errorf("%s: erreur = %v, attendu une erreur sur %s", tt.name, err, tt.name)
+		}
+	}
+
+	cfg := validConfig(t)
+	cfg.RateLimiter.Allowlist = []string{"10.0.0.0/8", "192.168.1.10", "::1"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("liste valide rejetée: %v", err)
+	}
+}
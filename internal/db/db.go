@@ -0,0 +1,82 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"gorm.io/driver/postgres" // Driver PostgreSQL pour GORM
+	"gorm.io/driver/sqlite"   // Driver SQLite pour GORM
+	"gorm.io/gorm"
+)
+
+// Connect ouvre la connexion à la base de données configurée et vérifie qu'elle répond.
+// En cas d'échec, la connexion est retentée jusqu'à database.connect_attempts fois
+// avec un délai qui double à chaque tentative (backoff exponentiel), ce qui évite
+// d'échouer immédiatement lorsque la base n'est pas encore prête au démarrage d'un conteneur.
+func Connect(cfg *config.Config) (*gorm.DB, error) {
+	attempts := cfg.Database.ConnectAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := time.Duration(cfg.Database.ConnectRetryIntervalMs) * time.Millisecond
+
+	var lastErr error
+	for i := 1; i <= attempts; i++ {
+		db, err := open(cfg)
+		if err == nil {
+			return db, nil
+		}
+		lastErr = err
+
+		if i < attempts {
+			slog.Warn("Connexion à la base de données impossible, nouvelle tentative", "attempt", i, "max_attempts", attempts,
+				"retry_in", delay.String(), "error", err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	return nil, fmt.Errorf("impossible de se connecter à la base de données après %d tentative(s): %w", attempts, lastErr)
+}
+
+// dialector retourne le driver GORM de database.driver : le fichier database.name pour SQLite,
+// la chaîne de connexion database.dsn pour PostgreSQL.
+func dialector(cfg *config.Config) gorm.Dialector {
+	if cfg.Database.Driver == "postgres" {
+		return postgres.Open(cfg.Database.DSN)
+	}
+	return sqlite.Open(cfg.Database.Name)
+}
+
+// open effectue une tentative de connexion unique et vérifie la connexion avec un Ping.
+func open(cfg *config.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector(cfg), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Close ferme la connexion ouverte par Connect. Prévue pour un defer : une erreur est seulement journalisée.
+func Close(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		slog.Warn("Échec de l'obtention de la base de données SQL sous-jacente", "error", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		slog.Warn("Erreur lors de la fermeture de la connexion à la base de données", "error", err)
+	}
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // ErrLinkNotFound est retournée quand un lien n'existe pas dans la base de données.
 type ErrLinkNotFound struct {
@@ -21,10 +24,113 @@ func (e *ErrCodeGenerationFailed) Error() string {
 }
 
 // ErrInvalidURL est retournée quand une URL fournie est invalide.
+// Reason, optionnelle, précise pourquoi une URL bien formée est refusée.
 type ErrInvalidURL struct {
-	URL string
+	URL    string
+	Reason string
 }
 
 func (e *ErrInvalidURL) Error() string {
-	return fmt.Sprintf("URL invalide: %s", e.URL)
+	if e.Reason == "" {
+		return fmt.Sprintf("URL invalide: %s", e.URL)
+	}
+	return fmt.Sprintf("URL invalide: %s (%s)", e.URL, e.Reason)
+}
+
+// ErrInvalidCodeLength est retournée quand la longueur de code court demandée sort des bornes configurées.
+type ErrInvalidCodeLength struct {
+	Length int
+	Min    int
+	Max    int
+}
+
+func (e *ErrInvalidCodeLength) Error() string {
+	return fmt.Sprintf("longueur de code court invalide: %d (doit être comprise entre %d et %d)", e.Length, e.Min, e.Max)
+}
+
+// ErrInvalidPassword est retournée quand le mot de passe d'un lien protégé ne respecte pas les contraintes de longueur.
+type ErrInvalidPassword struct {
+	Min int
+	Max int
+}
+
+func (e *ErrInvalidPassword) Error() string {
+	return fmt.Sprintf("mot de passe invalide: il doit contenir entre %d et %d octets", e.Min, e.Max)
+}
+
+// ErrCircuitOpen est retournée quand le circuit breaker de création est ouvert
+// et que la requête est rejetée sans solliciter la base de données.
+type ErrCircuitOpen struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("service de création temporairement indisponible, réessayez dans %v", e.RetryAfter.Round(time.Second))
+}
+
+// ErrURLDenied est retournée quand le service de vérification externe refuse une URL.
+type ErrURLDenied struct {
+	URL    string
+	Reason string
+}
+
+func (e *ErrURLDenied) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("URL refusée par le service de vérification: %s", e.URL)
+	}
+	return fmt.Sprintf("URL refusée par le service de vérification: %s (%s)", e.URL, e.Reason)
+}
+
+// ErrDomainBlocked est retournée quand le domaine d'une URL figure dans security.blocked_domains
+// (directement ou en tant que sous-domaine d'un domaine bloqué).
+type ErrDomainBlocked struct {
+	URL    string
+	Domain string // Entrée de la liste de blocage correspondante
+}
+
+func (e *ErrDomainBlocked) Error() string {
+	return fmt.Sprintf("le domaine '%s' n'est pas autorisé: %s", e.Domain, e.URL)
+}
+
+// ErrURLCheckUnavailable est retournée quand le service de vérification est injoignable
+// et que la configuration impose de refuser les créations dans ce cas (fail-closed).
+type ErrURLCheckUnavailable struct {
+	Err error
+}
+
+func (e *ErrURLCheckUnavailable) Error() string {
+	return fmt.Sprintf("service de vérification des URLs indisponible: %v", e.Err)
+}
+
+func (e *ErrURLCheckUnavailable) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidAlias est retournée quand un alias personnalisé est invalide, réservé ou déjà utilisé.
+type ErrInvalidAlias struct {
+	Alias  string
+	Reason string
+}
+
+func (e *ErrInvalidAlias) Error() string {
+	return e.Reason
+}
+
+// ErrNotLinkOwner est retournée quand un utilisateur authentifié agit sur un lien appartenant à un autre utilisateur.
+type ErrNotLinkOwner struct {
+	ShortCode string
+}
+
+func (e *ErrNotLinkOwner) Error() string {
+	return fmt.Sprintf("le lien '%s' appartient à un autre utilisateur", e.ShortCode)
+}
+
+// ErrClickLimitReached est retournée quand un lien a déjà servi son nombre maximal de redirections (max_clicks).
+type ErrClickLimitReached struct {
+	ShortCode string
+	MaxClicks int
+}
+
+func (e *ErrClickLimitReached) Error() string {
+	return fmt.Sprintf("le lien '%s' a atteint sa limite de %d clic(s)", e.ShortCode, e.MaxClicks)
 }
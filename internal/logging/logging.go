@@ -0,0 +1,27 @@
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// Setup installe le logger slog par défaut selon logging.level et logging.format.
+// slog.SetDefault redirige aussi le package standard 'log' vers ce handler (niveau INFO),
+// si bien que les messages non encore structurés sortent au même format.
+func Setup(cfg config.LoggingConfig) {
+	var level slog.Level
+	// Le niveau est validé au chargement de la configuration
+	_ = level.UnmarshalText([]byte(strings.ToUpper(cfg.Level)))
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
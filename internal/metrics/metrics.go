@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Résultats d'une redirection, utilisés comme valeur du label "result".
+const (
+	RedirectFound    = "found"     // Redirection servie (ou page d'aperçu, réponse JSON)
+	RedirectNotFound = "not_found" // Code court inconnu (404)
+	RedirectExpired  = "expired"   // Lien expiré, désactivé ou à sa limite de clics (410)
+	RedirectOther    = "other"     // Toute autre réponse (mot de passe requis, 403, 500, 508, ...)
+)
+
+// Metrics regroupe les métriques Prometheus du service, enregistrées dans un registre dédié.
+// Toutes les méthodes acceptent un receveur nil (métriques désactivées) et ne font alors rien.
+type Metrics struct {
+	registry        *prometheus.Registry
+	linksCreated    prometheus.Counter
+	redirects       *prometheus.CounterVec
+	redirectLatency prometheus.Histogram
+	clickDrops      prometheus.Counter
+}
+
+// New crée et enregistre les métriques du service.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		linksCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "urlshortener_links_created_total",
+			Help: "Nombre de liens créés (hors liens existants réutilisés).",
+		}),
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "urlshortener_redirects_total",
+			Help: "Nombre de requêtes de redirection, par résultat (found, not_found, expired, other).",
+		}, []string{"result"}),
+		redirectLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "urlshortener_redirect_duration_seconds",
+			Help:    "Durée de traitement des requêtes de redirection.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		clickDrops: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "urlshortener_click_events_dropped_total",
+			Help: "Nombre d'événements de clic perdus car le channel des clics était plein.",
+		}),
+	}
+	m.registry.MustRegister(m.linksCreated, m.redirects, m.redirectLatency, m.clickDrops,
+		prometheus.NewGoCollector(), prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	return m
+}
+
+// Handler retourne le handler HTTP exposant les métriques au format Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// LinkCreated compte un lien créé.
+func (m *Metrics) LinkCreated() {
+	if m == nil {
+		return
+	}
+	m.linksCreated.Inc()
+}
+
+// Redirect compte une requête de redirection d'après son code de statut HTTP et enregistre sa durée.
+func (m *Metrics) Redirect(status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.redirects.WithLabelValues(redirectResult(status)).Inc()
+	m.redirectLatency.Observe(duration.Seconds())
+}
+
+// ClickDropped compte un événement de clic perdu.
+func (m *Metrics) ClickDropped() {
+	if m == nil {
+		return
+	}
+	m.clickDrops.Inc()
+}
+
+// ObserveCircuitBreaker expose l'état du circuit breaker de création sous la forme d'une jauge par état
+// (urlshortener_circuit_breaker_state{state="closed|open|half-open"}), valant 1 pour l'état courant et 0 sinon.
+// La fonction state est appelée à chaque collecte ; les valeurs d'état sont celles de services.Breaker*.
+func (m *Metrics) ObserveCircuitBreaker(state func() string, states ...string) {
+	if m == nil {
+		return
+	}
+	for _, s := range states {
+		s := s
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "urlshortener_circuit_breaker_state",
+			Help:        "État du circuit breaker de création (1 pour l'état courant, 0 sinon).",
+			ConstLabels: prometheus.Labels{"state": s},
+		}, func() float64 {
+			if state() == s {
+				return 1
+			}
+			return 0
+		}))
+	}
+}
+
+// ObserveDroppedOrphanClicks expose le nombre de clics ignorés car leur lien a été supprimé avant
+// leur enregistrement (urlshortener_click_events_orphaned_total). La fonction count est appelée à chaque collecte.
+func (m *Metrics) ObserveDroppedOrphanClicks(count func() int64) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "urlshortener_click_events_orphaned_total",
+		Help: "Nombre de clics ignorés car leur lien a été supprimé entre la redirection et l'enregistrement.",
+	}, func() float64 {
+		return float64(count())
+	}))
+}
+
+// ObserveStaleCacheServes expose le nombre de liens servis depuis une entrée de cache expirée faute de pouvoir
+// lire la base (urlshortener_link_cache_stale_served_total). La fonction count est appelée à chaque collecte.
+func (m *Metrics) ObserveStaleCacheServes(count func() int64) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "urlshortener_link_cache_stale_served_total",
+		Help: "Nombre de liens servis depuis une entrée de cache expirée car la base était en erreur.",
+	}, func() float64 {
+		return float64(count())
+	}))
+}
+
+// redirectResult classe une réponse de redirection d'après son code de statut.
+func redirectResult(status int) string {
+	switch {
+	case status < 400:
+		return RedirectFound
+	case status == http.StatusNotFound:
+		return RedirectNotFound
+	case status == http.StatusGone:
+		return RedirectExpired
+	default:
+		return RedirectOther
+	}
+}
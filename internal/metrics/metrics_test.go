@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestObserveCircuitBreaker(t *testing.T) {
+	m := New()
+	current := "closed"
+	m.ObserveCircuitBreaker(func() string { return current }, "closed", "open", "half-open")
+
+	read := func() map[string]float64 {
+		families, err := m.registry.Gather()
+		if err != nil {
+			t.Fatalf("Gather() a échoué: %v", err)
+		}
+		values := map[string]float64{}
+		for _, family := range families {
+			if family.GetName() != "urlshortener_circuit_breaker_state" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "state" {
+						values[label.GetValue()] = metric.GetGauge().GetValue()
+					}
+				}
+			}
+		}
+		return values
+	}
+
+	if got := read(); got["closed"] != 1 || got["open"] != 0 || got["half-open"] != 0 {
+		t.Fatalf("circuit fermé: jauges inattendues %v", got)
+	}
+
+	current = "open"
+	if got := read(); got["closed"] != 0 || got["open"] != 1 || got["half-open"] != 0 {
+		t.Fatalf("circuit ouvert: jauges inattendues %v", got)
+	}
+}
+
+func TestNilMetricsIsNoop(t *testing.T) {
+	var m *Metrics
+	m.ObserveCircuitBreaker(func() string { return "open" }, "open")
+	m.LinkCreated()
+	m.ClickDropped()
+}
+
+func TestObserveDroppedOrphanClicks(t *testing.T) {
+	m := New()
+	var dropped int64 = 3
+	m.ObserveDroppedOrphanClicks(func() int64 { return dropped })
+
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() a échoué: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "urlshortener_click_events_orphaned_total" {
+			if got := family.GetMetric()[0].GetCounter().GetValue(); got != 3 {
+				t.Fatalf("obtenu %v, attendu 3", got)
+			}
+			return
+		}
+	}
+	t.Fatal("métrique urlshortener_click_events_orphaned_total absente")
+}
+
+func TestObserveStaleCacheServes(t *testing.T) {
+	m := New()
+	m.ObserveStaleCacheServes(func() int64 { return 2 })
+
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() a échoué: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "urlshortener_link_cache_stale_served_total" {
+			if got := family.GetMetric()[0].GetCounter().GetValue(); got != 2 {
+				t.Fatalf("obtenu %v, attendu 2", got)
+			}
+			return
+		}
+	}
+	t.Fatal("métrique urlshortener_link_cache_stale_served_total absente")
+}
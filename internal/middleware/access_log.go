@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLogMiddleware journalise chaque requête (méthode, chemin, statut, IP, latence),
+// du début de la requête jusqu'à l'écriture de la réponse, avec l'identifiant de RequestIDMiddleware.
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		slog.Info("Requête traitée", "component", "access", "request_id", RequestIDFromContext(c.Request.Context()),
+			"method", c.Request.Method, "path", c.Request.URL.Path, "status", c.Writer.Status(), "ip", c.ClientIP(),
+			"latency_ms", time.Since(start).Seconds()*1000)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IsAdmin indique si la requête porte le jeton administrateur dans l'en-tête "Authorization: Bearer <jeton>".
+// Un jeton vide désactive l'accès administrateur : aucune requête n'est alors considérée comme admin.
+func IsAdmin(c *gin.Context, adminToken string) bool {
+	if adminToken == "" {
+		return false
+	}
+	provided, ok := bearerToken(c)
+	return ok && tokenEquals(provided, adminToken)
+}
+
+// bearerToken extrait le jeton de l'en-tête "Authorization: Bearer <jeton>".
+func bearerToken(c *gin.Context) (string, bool) {
+	return strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// tokenEquals compare deux jetons en temps constant, pour ne pas les divulguer par mesure du temps de réponse.
+func tokenEquals(provided, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) == 1
+}
+
+// AdminAuthMiddleware rejette (401) les requêtes qui ne portent pas le jeton administrateur.
+func AdminAuthMiddleware(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c, adminToken) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentification administrateur requise"})
+			return
+		}
+		c.Next()
+	}
+}
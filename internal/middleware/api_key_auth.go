@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminOwnerID est le propriétaire enregistré pour les liens créés avec le jeton administrateur.
+const AdminOwnerID = "admin"
+
+// ownerIDKey est la clé du propriétaire authentifié dans le contexte Gin.
+const ownerIDKey = "auth.owner_id"
+
+// APIKeyAuthMiddleware rejette (401) les requêtes d'écriture qui ne portent pas l'une des clés d'API
+// (ou le jeton administrateur) dans l'en-tête "Authorization: Bearer <clé>".
+// Comme ReadOnlyMiddleware, les méthodes de lecture passent : toute nouvelle route d'écriture est donc protégée.
+// Une clé valide identifie son propriétaire (OwnerID) pour toutes les méthodes, lectures comprises.
+func APIKeyAuthMiddleware(apiKeys []string, adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAdmin(c, adminToken) {
+			c.Set(ownerIDKey, AdminOwnerID)
+		} else if key, ok := validAPIKey(c, apiKeys); ok {
+			c.Set(ownerIDKey, apiKeyOwnerID(key))
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if OwnerID(c) == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Clé d'API manquante ou invalide"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// OwnerID retourne le propriétaire identifié par APIKeyAuthMiddleware, ou une chaîne vide
+// si la requête n'est pas authentifiée (ou si l'authentification est désactivée).
+func OwnerID(c *gin.Context) string {
+	return c.GetString(ownerIDKey)
+}
+
+// validAPIKey retourne la clé d'API configurée portée par la requête, le cas échéant.
+// Toutes les clés sont comparées, pour que le temps de réponse ne dépende pas de la clé reconnue.
+func validAPIKey(c *gin.Context, apiKeys []string) (string, bool) {
+	provided, ok := bearerToken(c)
+	if !ok {
+		return "", false
+	}
+	matched, found := "", false
+	for _, key := range apiKeys {
+		if tokenEquals(provided, key) {
+			matched, found = key, true
+		}
+	}
+	return matched, found
+}
+
+// apiKeyOwnerID dérive l'identifiant de propriétaire d'une clé d'API : un condensé SHA-256 tronqué,
+// stable tant que la clé ne change pas, qui n'expose pas la clé en base ni dans les logs.
+func apiKeyOwnerID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
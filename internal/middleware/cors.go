@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsAllowedMethods et corsAllowedHeaders sont annoncés en réponse aux requêtes preflight.
+const (
+	corsAllowedMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	corsAllowedHeaders = "Authorization, Content-Type, " + RequestIDHeader
+	corsMaxAgeSeconds  = "600"
+)
+
+// corsExposedHeaders sont les en-têtes de réponse lisibles par le JavaScript du client.
+const corsExposedHeaders = RequestIDHeader + ", X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, " +
+	"X-Create-Quota-Limit, X-Create-Quota-Remaining, Retry-After"
+
+// CORSMiddleware autorise les appels depuis les origines listées (server.cors_origins), "*" acceptant toutes les origines.
+// Les requêtes preflight (OPTIONS avec Access-Control-Request-Method) sont traitées ici et répondent 204 ;
+// une origine non autorisée ne reçoit aucun en-tête CORS et le navigateur bloque alors la requête.
+// Avec allowCredentials, l'origine exacte est renvoyée (jamais "*") avec Access-Control-Allow-Credentials.
+func CORSMiddleware(origins []string, allowCredentials bool) gin.HandlerFunc {
+	allowAll := false
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAll = true
+			continue
+		}
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		// La réponse dépend de l'origine dès qu'elle n'est pas "*" : les caches doivent en tenir compte
+		reflectOrigin := !allowAll || allowCredentials
+		if reflectOrigin {
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+
+		if origin != "" && (allowAll || allowed[strings.ToLower(origin)]) {
+			if reflectOrigin {
+				c.Header("Access-Control-Allow-Origin", origin)
+			} else {
+				c.Header("Access-Control-Allow-Origin", "*")
+			}
+			if allowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+			if preflight {
+				c.Header("Access-Control-Allow-Methods", corsAllowedMethods)
+				c.Header("Access-Control-Allow-Headers", corsAllowedHeaders)
+				c.Header("Access-Control-Max-Age", corsMaxAgeSeconds)
+			} else {
+				c.Header("Access-Control-Expose-Headers", corsExposedHeaders)
+			}
+		}
+
+		if preflight {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// FailureThrottle limite les tentatives infructueuses par clé (IP, ou IP et code court).
+// Après 'maxFailures' échecs dans la fenêtre, la clé est bloquée pendant 'cooldown'.
+// Les tentatives réussies ne sont pas comptées.
+// Utilisé pour les alias personnalisés pris ou invalides (énumération et squat d'alias)
+// et pour les mots de passe incorrects des liens protégés (force brute).
+type FailureThrottle struct {
+	keys        map[string]*failureAttempts // Échecs récents par clé
+	mu          sync.Mutex                  // Mutex pour protéger l'accès concurrent à la map
+	component   string                      // Attribut "component" des logs ("alias_throttle", "password_throttle")
+	maxFailures int                         // Nombre d'échecs tolérés dans la fenêtre
+	window      time.Duration               // Fenêtre de comptage des échecs
+	cooldown    time.Duration               // Durée du blocage une fois le seuil atteint
+}
+
+// failureAttempts contient le suivi des échecs pour une clé.
+type failureAttempts struct {
+	failures     int       // Nombre d'échecs dans la fenêtre actuelle
+	windowStart  time.Time // Début de la fenêtre actuelle
+	blockedUntil time.Time // Fin du blocage (zéro si non bloquée)
+}
+
+// NewFailureThrottle crée un FailureThrottle.
+// component: nom du composant dans les logs
+// maxFailures: nombre d'échecs tolérés par clé dans la fenêtre
+// window: fenêtre de comptage des échecs
+// cooldown: durée du blocage une fois le seuil atteint
+func NewFailureThrottle(component string, maxFailures int, window, cooldown time.Duration) *FailureThrottle {
+	throttle := &FailureThrottle{
+		keys:        make(map[string]*failureAttempts),
+		component:   component,
+		maxFailures: maxFailures,
+		window:      window,
+		cooldown:    cooldown,
+	}
+
+	// Nettoyer périodiquement les clés inactives pour que la map ne grandisse pas indéfiniment
+	go throttle.cleanupOldEntries()
+
+	return throttle
+}
+
+// cleanupOldEntries supprime les clés dont la fenêtre et le blocage sont terminés.
+func (t *FailureThrottle) cleanupOldEntries() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.mu.Lock()
+		now := time.Now()
+		for key, attempts := range t.keys {
+			if now.Sub(attempts.windowStart) > t.window && now.After(attempts.blockedUntil) {
+				delete(t.keys, key)
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+// BlockedUntil retourne la fin du blocage de la clé, ou l'instant zéro si elle n'est pas bloquée.
+func (t *FailureThrottle) BlockedUntil(key string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	attempts, exists := t.keys[key]
+	if !exists || time.Now().After(attempts.blockedUntil) {
+		return time.Time{}
+	}
+	return attempts.blockedUntil
+}
+
+// RecordFailure enregistre une tentative infructueuse pour la clé
+// et la bloque si le seuil est atteint dans la fenêtre.
+func (t *FailureThrottle) RecordFailure(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	attempts, exists := t.keys[key]
+	if !exists || now.Sub(attempts.windowStart) > t.window {
+		attempts = &failureAttempts{windowStart: now}
+		t.keys[key] = attempts
+	}
+
+	attempts.failures++
+	if attempts.failures >= t.maxFailures {
+		attempts.blockedUntil = now.Add(t.cooldown)
+		attempts.failures = 0
+		attempts.windowStart = now
+		slog.Warn("Clé bloquée après des tentatives infructueuses", "component", t.component, "key", key,
+			"cooldown", t.cooldown.String(), "failures", t.maxFailures)
+	}
+}
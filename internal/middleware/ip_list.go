@@ -0,0 +1,43 @@
+package middleware
+
+import "net"
+
+// IPList est une liste d'IPs exactes et de plages CIDR (allowlists et whitelists de la configuration),
+// analysée une seule fois à la construction plutôt qu'à chaque requête.
+type IPList struct {
+	ips      []net.IP
+	networks []*net.IPNet
+}
+
+// NewIPList analyse les entrées de la liste. Une entrée qui n'est ni une IP ni une plage CIDR est ignorée :
+// les listes de la configuration sont validées au chargement (Config.Validate).
+func NewIPList(entries []string) *IPList {
+	list := &IPList{}
+	for _, entry := range entries {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			list.networks = append(list.networks, network)
+		} else if ip := net.ParseIP(entry); ip != nil {
+			list.ips = append(list.ips, ip)
+		}
+	}
+	return list
+}
+
+// Contains indique si l'IP correspond à l'une des entrées de la liste.
+func (l *IPList) Contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range l.ips {
+		if entry.Equal(parsed) {
+			return true
+		}
+	}
+	for _, network := range l.networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import "testing"
+
+func TestIPListContains(t *testing.T) {
+	list := NewIPList([]string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32", "::1"})
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"192.168.1.10", true},
+		{"192.168.1.11", false},
+		{"2001:db8::42", true},
+		{"::1", true},
+		{"0:0:0:0:0:0:0:1", true}, // Forme non abrégée de ::1
+		{"203.0.113.7", false},
+		{"pas-une-ip", false},
+	}
+	for _, tt := range tests {
+		if got := list.Contains(tt.ip); got != tt.want {
+			t.Errorf("Contains(%q) = %v, attendu %v", tt.ip, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,8 @@ package middleware
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
 	"sync"
 	"time"
@@ -10,7 +11,41 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// IPRateLimiter gère le rate limiting par adresse IP.
+// RateLimiter est un algorithme de rate limiting par adresse IP utilisé par RateLimitMiddleware.
+// Le middleware n'a pas à connaître l'algorithme retenu (rate_limiter.algorithm).
+type RateLimiter interface {
+	// isAllowed vérifie si l'IP peut faire une requête et la décompte le cas échéant.
+	isAllowed(ip string) bool
+	// getRemainingRequests retourne le nombre de requêtes que l'IP peut encore faire immédiatement.
+	getRemainingRequests(ip string) int
+	// getResetTime retourne le moment où une IP bloquée pourra de nouveau faire une requête,
+	// ou, si elle ne l'est pas, celui où elle retrouvera toute sa limite.
+	getResetTime(ip string) time.Time
+	// limit retourne le nombre maximum de requêtes par fenêtre.
+	limit() int
+	// windowDuration retourne la durée de la fenêtre.
+	windowDuration() time.Duration
+}
+
+// rateLimitTaker est implémenté par les limiters qui décomptent une requête et retournent l'état de l'IP
+// (autorisation, requêtes restantes, réinitialisation) en un seul appel, comme RedisRateLimiter
+// pour qui chaque méthode de RateLimiter coûte un aller-retour réseau.
+type rateLimitTaker interface {
+	take(ip string) (allowed bool, remaining int, resetTime time.Time)
+}
+
+// takeRequest décompte la requête de l'IP et retourne l'état utilisé pour la réponse et les en-têtes X-RateLimit-*.
+func takeRequest(limiter RateLimiter, ip string) (bool, int, time.Time) {
+	if taker, ok := limiter.(rateLimitTaker); ok {
+		return taker.take(ip)
+	}
+	if !limiter.isAllowed(ip) {
+		return false, 0, limiter.getResetTime(ip)
+	}
+	return true, limiter.getRemainingRequests(ip), limiter.getResetTime(ip)
+}
+
+// IPRateLimiter gère le rate limiting par adresse IP avec une fenêtre fixe.
 // Cette structure fait partie des features bonus et permet de limiter le nombre de requêtes
 // qu'une même IP peut effectuer dans un intervalle de temps donné.
 type IPRateLimiter struct {
@@ -60,7 +95,7 @@ func (rl *IPRateLimiter) cleanupOldEntries() {
 			}
 		}
 		rl.mu.Unlock()
-		log.Printf("[RATE LIMITER] Nettoyage effectué. Nombre d'IPs suivies: %d", len(rl.ips))
+		slog.Debug("Nettoyage effectué", "component", "rate_limiter", "tracked_ips", len(rl.ips))
 	}
 }
 
@@ -98,7 +133,8 @@ func (rl *IPRateLimiter) isAllowed(ip string) bool {
 
 	// Vérifier si le nombre maximum de requêtes est atteint
 	if info.count >= rl.maxRequest {
-		log.Printf("[RATE LIMITER] IP %s a dépassé la limite (%d requêtes en %v)", ip, rl.maxRequest, rl.window)
+		slog.Warn("Limite de requêtes dépassée", "component", "rate_limiter", "ip", ip,
+			"max_requests", rl.maxRequest, "window", rl.window.String())
 		return false
 	}
 
@@ -107,6 +143,16 @@ func (rl *IPRateLimiter) isAllowed(ip string) bool {
 	return true
 }
 
+// limit retourne le nombre maximum de requêtes autorisées par fenêtre.
+func (rl *IPRateLimiter) limit() int {
+	return rl.maxRequest
+}
+
+// windowDuration retourne la durée de la fenêtre de comptage.
+func (rl *IPRateLimiter) windowDuration() time.Duration {
+	return rl.window
+}
+
 // getRemainingRequests retourne le nombre de requêtes restantes pour une IP.
 func (rl *IPRateLimiter) getRemainingRequests(ip string) int {
 	rl.mu.RLock()
@@ -149,43 +195,54 @@ func (rl *IPRateLimiter) getResetTime(ip string) time.Time {
 
 // RateLimitMiddleware crée un middleware Gin pour le rate limiting par IP.
 // Ce middleware doit être appliqué aux routes que vous souhaitez protéger.
-func RateLimitMiddleware(limiter *IPRateLimiter) gin.HandlerFunc {
+// Les IPs de 'allowlist' (IPs exactes ou plages CIDR) ne sont jamais limitées ni décomptées.
+func RateLimitMiddleware(limiter RateLimiter, allowlist []string) gin.HandlerFunc {
+	allowed := NewIPList(allowlist)
 	return func(c *gin.Context) {
-		// Récupérer l'adresse IP du client
-		ip := c.ClientIP()
-
-		// Vérifier si l'IP est autorisée
-		if !limiter.isAllowed(ip) {
-			// L'IP a dépassé la limite
-			resetTime := limiter.getResetTime(ip)
-			secondsUntilReset := int(time.Until(resetTime).Seconds())
-
-			// Ajouter des headers informatifs
-			c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.maxRequest))
-			c.Header("X-RateLimit-Remaining", "0")
-			c.Header("X-RateLimit-Reset", resetTime.Format(time.RFC3339))
-			c.Header("Retry-After", fmt.Sprintf("%d", secondsUntilReset))
-
-			// Retourner une erreur 429 Too Many Requests
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":             "Trop de requêtes. Veuillez réessayer plus tard.",
-				"retry_after":       secondsUntilReset,
-				"reset_at":          resetTime.Format(time.RFC3339),
-				"max_requests":      limiter.maxRequest,
-				"window_minutes":    int(limiter.window.Minutes()),
-			})
-			c.Abort() // Arrêter le traitement de la requête
+		if allowed.Contains(c.ClientIP()) {
+			c.Next()
 			return
 		}
+		limitRequest(c, limiter)
+	}
+}
 
-		// L'IP est autorisée, ajouter des headers informatifs
-		remaining := limiter.getRemainingRequests(ip)
-		resetTime := limiter.getResetTime(ip)
-		c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.maxRequest))
-		c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+// limitRequest décompte la requête auprès du limiter : elle est rejetée (429) si l'IP a dépassé sa limite,
+// sinon les en-têtes X-RateLimit-* sont ajoutés et le traitement continue.
+func limitRequest(c *gin.Context, limiter RateLimiter) {
+	// Récupérer l'adresse IP du client
+	ip := c.ClientIP()
+
+	// Vérifier si l'IP est autorisée
+	allowed, remaining, resetTime := takeRequest(limiter, ip)
+	if !allowed {
+		// L'IP a dépassé la limite
+		// Arrondi au supérieur : un client qui réessaie après Retry-After ne doit pas être de nouveau refusé
+		secondsUntilReset := int(math.Ceil(time.Until(resetTime).Seconds()))
+
+		// Ajouter des headers informatifs
+		c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.limit()))
+		c.Header("X-RateLimit-Remaining", "0")
 		c.Header("X-RateLimit-Reset", resetTime.Format(time.RFC3339))
-
-		// Continuer le traitement de la requête
-		c.Next()
+		c.Header("Retry-After", fmt.Sprintf("%d", secondsUntilReset))
+
+		// Retourner une erreur 429 Too Many Requests
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":          "Trop de requêtes. Veuillez réessayer plus tard.",
+			"retry_after":    secondsUntilReset,
+			"reset_at":       resetTime.Format(time.RFC3339),
+			"max_requests":   limiter.limit(),
+			"window_minutes": int(limiter.windowDuration().Minutes()),
+		})
+		c.Abort() // Arrêter le traitement de la requête
+		return
 	}
+
+	// L'IP est autorisée, ajouter des headers informatifs
+	c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limiter.limit()))
+	c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+	c.Header("X-RateLimit-Reset", resetTime.Format(time.RFC3339))
+
+	// Continuer le traitement de la requête
+	c.Next()
 }
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// takerLimiter est un limiter à appel unique (comme RedisRateLimiter) qui compte ses appels
+// et échoue si le middleware repasse par les méthodes séparées de RateLimiter.
+type takerLimiter struct {
+	t         *testing.T
+	takes     int
+	allowed   bool
+	remaining int
+	resetTime time.Time
+}
+
+func (l *takerLimiter) take(string) (bool, int, time.Time) {
+	l.takes++
+	return l.allowed, l.remaining, l.resetTime
+}
+
+func (l *takerLimiter) isAllowed(string) bool {
+	l.t.Error("isAllowed appelé malgré take")
+	return true
+}
+
+func (l *takerLimiter) getRemainingRequests(string) int {
+	l.t.Error("getRemainingRequests appelé malgré take")
+	return 0
+}
+
+func (l *takerLimiter) getResetTime(string) time.Time {
+	l.t.Error("getResetTime appelé malgré take")
+	return time.Time{}
+}
+
+func (l *takerLimiter) limit() int                    { return 5 }
+func (l *takerLimiter) windowDuration() time.Duration { return time.Minute }
+
+func TestLimitRequestUsesSingleTake(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resetTime := time.Now().Add(30 * time.Second).Truncate(time.Second)
+
+	for _, allowed := range []bool{true, false} {
+		limiter := &takerLimiter{t: t, allowed: allowed, remaining: 3, resetTime: resetTime}
+		router := gin.New()
+		router.GET("/", RateLimitMiddleware(limiter, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if limiter.takes != 1 {
+			t.Errorf("allowed=%v: %d appels à take, attendu 1", allowed, limiter.takes)
+		}
+		wantStatus, wantRemaining := http.StatusOK, "3"
+		if !allowed {
+			wantStatus, wantRemaining = http.StatusTooManyRequests, "0"
+		}
+		if recorder.Code != wantStatus {
+			t.Errorf("allowed=%v: statut %d, attendu %d", allowed, recorder.Code, wantStatus)
+		}
+		if got := recorder.Header().Get("X-RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("allowed=%v: X-RateLimit-Remaining = %q, attendu %q", allowed, got, wantRemaining)
+		}
+		if got := recorder.Header().Get("X-RateLimit-Reset"); got != resetTime.Format(time.RFC3339) {
+			t.Errorf("allowed=%v: X-RateLimit-Reset = %q, attendu %q", allowed, got, resetTime.Format(time.RFC3339))
+		}
+	}
+}
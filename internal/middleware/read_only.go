@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadOnlyMiddleware rejette (503) toutes les requêtes d'écriture lorsque le service est en lecture seule.
+// Les méthodes de lecture (GET, HEAD, OPTIONS) passent : toute nouvelle route d'écriture est donc couverte.
+func ReadOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+		default:
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Service is read-only: create, update and delete requests are disabled",
+			})
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisRateLimitScript incrémente le compteur d'une IP et pose l'expiration de la fenêtre à la première requête,
+// de façon atomique : un compteur ne peut pas rester sans expiration si le serveur s'arrête entre les deux.
+// Il retourne {compteur, durée de vie restante en ms} pour que les en-têtes se calculent sans autre appel.
+var redisRateLimitScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {count, redis.call("PTTL", KEYS[1])}
+`)
+
+// RedisRateLimiter gère le rate limiting par IP avec une fenêtre fixe stockée dans Redis (rate_limiter.backend: redis),
+// partagée entre toutes les instances derrière un load balancer.
+// Chaque IP a un compteur "ratelimit:<nom>:<ip>" qui expire à la fin de sa fenêtre.
+// Si Redis est injoignable, les requêtes sont autorisées plutôt que de bloquer tout le trafic.
+type RedisRateLimiter struct {
+	client     *redis.Client
+	name       string        // Nom du limiter dans les clés, pour séparer les règles par route
+	maxRequest int           // Nombre maximum de requêtes autorisées
+	window     time.Duration // Fenêtre de temps pour le rate limiting
+	timeout    time.Duration // Délai maximal d'un appel à Redis
+}
+
+// NewRedisRateLimiter crée un RedisRateLimiter sur le client Redis donné, qui peut être partagé avec d'autres
+// composants ; sa fermeture reste à la charge de l'appelant.
+// name distingue les compteurs de plusieurs limiters partageant la même instance Redis.
+func NewRedisRateLimiter(client *redis.Client, name string, maxRequest int, windowMinutes int) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		client:     client,
+		name:       name,
+		maxRequest: maxRequest,
+		window:     time.Duration(windowMinutes) * time.Minute,
+		timeout:    time.Second,
+	}
+}
+
+// key retourne la clé Redis du compteur d'une IP.
+func (rl *RedisRateLimiter) key(ip string) string {
+	return "ratelimit:" + rl.name + ":" + ip
+}
+
+// take implémente rateLimitTaker : le compteur est incrémenté et l'état de l'IP lu en un seul aller-retour Redis.
+func (rl *RedisRateLimiter) take(ip string) (bool, int, time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), rl.timeout)
+	defer cancel()
+
+	result, err := redisRateLimitScript.Run(ctx, rl.client, []string{rl.key(ip)}, rl.window.Milliseconds()).Int64Slice()
+	if err != nil || len(result) != 2 {
+		slog.Warn("Rate limiting Redis indisponible, requête autorisée", "component", "rate_limiter", "ip", ip, "error", err)
+		return true, rl.maxRequest, time.Now().Add(rl.window)
+	}
+	count, ttl := int(result[0]), time.Duration(result[1])*time.Millisecond
+	resetTime := time.Now().Add(rl.window)
+	if ttl > 0 {
+		resetTime = time.Now().Add(ttl)
+	}
+	if count > rl.maxRequest {
+		slog.Warn("Limite de requêtes dépassée", "component", "rate_limiter", "ip", ip,
+			"max_requests", rl.maxRequest, "window", rl.window.String())
+		return false, 0, resetTime
+	}
+	return true, rl.maxRequest - count, resetTime
+}
+
+// isAllowed incrémente le compteur de l'IP et vérifie qu'il ne dépasse pas la limite.
+func (rl *RedisRateLimiter) isAllowed(ip string) bool {
+	allowed, _, _ := rl.take(ip)
+	return allowed
+}
+
+// getRemainingRequests retourne le nombre de requêtes restantes pour une IP dans sa fenêtre.
+func (rl *RedisRateLimiter) getRemainingRequests(ip string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), rl.timeout)
+	defer cancel()
+
+	count, err := rl.client.Get(ctx, rl.key(ip)).Int()
+	if err != nil {
+		// Compteur absent (fenêtre expirée) ou Redis injoignable
+		return rl.maxRequest
+	}
+	return max(rl.maxRequest-count, 0)
+}
+
+// getResetTime retourne le moment où le compteur de l'IP expirera.
+func (rl *RedisRateLimiter) getResetTime(ip string) time.Time {
+	ctx, cancel := context.WithTimeout(context.Background(), rl.timeout)
+	defer cancel()
+
+	ttl, err := rl.client.PTTL(ctx, rl.key(ip)).Result()
+	if err != nil || ttl <= 0 {
+		return time.Now().Add(rl.window)
+	}
+	return time.Now().Add(ttl)
+}
+
+// limit retourne le nombre maximum de requêtes autorisées par fenêtre.
+func (rl *RedisRateLimiter) limit() int {
+	return rl.maxRequest
+}
+
+// windowDuration retourne la durée de la fenêtre de comptage.
+func (rl *RedisRateLimiter) windowDuration() time.Duration {
+	return rl.window
+}
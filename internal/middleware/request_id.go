@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader est l'en-tête portant l'identifiant de requête, repris s'il est fourni par le client
+// (ou un proxy en amont) et renvoyé dans chaque réponse.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDMaxLength borne la taille d'un identifiant reçu, pour ne pas journaliser n'importe quoi.
+const requestIDMaxLength = 128
+
+// requestIDKey est la clé de l'identifiant de requête dans le context.Context de la requête.
+type requestIDKey struct{}
+
+// RequestIDMiddleware attribue un identifiant à chaque requête et l'attache à son contexte,
+// pour corréler les logs des handlers et des workers de clics avec le log d'accès.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// RequestIDFromContext retourne l'identifiant attribué par RequestIDMiddleware, ou une chaîne vide.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// validRequestID n'accepte qu'un identifiant non vide, de taille raisonnable et en ASCII imprimable.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > requestIDMaxLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID génère un identifiant aléatoire de 16 octets encodé en hexadécimal.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RouteRateLimit associe un limiter à une route (rate_limiter.rules).
+type RouteRateLimit struct {
+	Method  string      // Méthode HTTP concernée, vide pour toutes
+	Path    string      // Route Gin telle que déclarée (ex: /api/v1/links, /:shortCode)
+	Limiter RateLimiter // Limiter propre à la route, décompté indépendamment des autres
+}
+
+// RouteRateLimitMiddleware applique à chaque requête le limiter de la première règle correspondant à la route atteinte
+// (c.FullPath()) et à sa méthode. Sans règle correspondante, 'fallback' ne s'applique qu'aux requêtes acceptées par
+// 'fallbackScope' (rate_limiter.scope) ; les autres, comme toutes les requêtes si fallback est nil, passent sans limite.
+// Il doit être enregistré sur le routeur pour couvrir toutes les routes. Les IPs de 'allowlist' ne sont jamais limitées.
+func RouteRateLimitMiddleware(rules []RouteRateLimit, fallback RateLimiter, fallbackScope func(c *gin.Context) bool,
+	allowlist []string) gin.HandlerFunc {
+	allowed := NewIPList(allowlist)
+	return func(c *gin.Context) {
+		if allowed.Contains(c.ClientIP()) {
+			c.Next()
+			return
+		}
+
+		var limiter RateLimiter
+		route := c.FullPath()
+		for _, rule := range rules {
+			if rule.Path == route && (rule.Method == "" || rule.Method == c.Request.Method) {
+				limiter = rule.Limiter
+				break
+			}
+		}
+		if limiter == nil && fallbackScope(c) {
+			limiter = fallback
+		}
+
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		limitRequest(c, limiter)
+	}
+}
+
+// NewRateLimiter crée le limiter de l'algorithme demandé (rate_limiter.algorithm) : "token_bucket"
+// ou, par défaut, "fixed_window".
+func NewRateLimiter(algorithm string, maxRequests, windowMinutes int) RateLimiter {
+	if algorithm == "token_bucket" {
+		return NewTokenBucketRateLimiter(maxRequests, windowMinutes)
+	}
+	return NewIPRateLimiter(maxRequests, windowMinutes)
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// TokenBucketRateLimiter gère le rate limiting par adresse IP avec un seau à jetons (rate_limiter.algorithm: token_bucket).
+// Chaque IP dispose d'un seau de maxRequest jetons, rempli en continu au rythme de maxRequest par fenêtre :
+// contrairement à la fenêtre fixe, une IP ne peut pas enchaîner deux fois la limite de part et d'autre d'une réinitialisation.
+type TokenBucketRateLimiter struct {
+	buckets    map[string]*tokenBucket // Seau de chaque IP
+	mu         sync.Mutex              // Mutex pour protéger l'accès concurrent à la map
+	maxRequest int                     // Capacité du seau (nombre de requêtes en rafale)
+	window     time.Duration           // Durée nécessaire pour remplir un seau vide
+}
+
+// tokenBucket contient l'état du seau d'une IP.
+type tokenBucket struct {
+	tokens     float64   // Jetons disponibles au moment de lastRefill
+	lastRefill time.Time // Dernier calcul du remplissage
+}
+
+// NewTokenBucketRateLimiter crée un rate limiter à seau à jetons.
+// maxRequest: capacité du seau, windowMinutes: durée de remplissage complet d'un seau vide.
+func NewTokenBucketRateLimiter(maxRequest int, windowMinutes int) *TokenBucketRateLimiter {
+	limiter := &TokenBucketRateLimiter{
+		buckets:    make(map[string]*tokenBucket),
+		maxRequest: maxRequest,
+		window:     time.Duration(windowMinutes) * time.Minute,
+	}
+
+	// Un seau inutilisé depuis plus d'une fenêtre est plein : inutile de le garder en mémoire
+	go limiter.cleanupFullBuckets()
+
+	return limiter
+}
+
+// cleanupFullBuckets supprime périodiquement les seaux des IPs inactives depuis plus d'une fenêtre.
+func (rl *TokenBucketRateLimiter) cleanupFullBuckets() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rl.mu.Lock()
+		now := time.Now()
+		for ip, bucket := range rl.buckets {
+			if now.Sub(bucket.lastRefill) > rl.window {
+				delete(rl.buckets, ip)
+			}
+		}
+		tracked := len(rl.buckets)
+		rl.mu.Unlock()
+		slog.Debug("Nettoyage effectué", "component", "rate_limiter", "tracked_ips", tracked)
+	}
+}
+
+// refillRate retourne le nombre de jetons ajoutés par seconde.
+func (rl *TokenBucketRateLimiter) refillRate() float64 {
+	return float64(rl.maxRequest) / rl.window.Seconds()
+}
+
+// refill met à jour le seau d'une IP (créé plein s'il n'existe pas) et le retourne. rl.mu doit être verrouillé.
+func (rl *TokenBucketRateLimiter) refill(ip string, now time.Time) *tokenBucket {
+	bucket, exists := rl.buckets[ip]
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(rl.maxRequest), lastRefill: now}
+		rl.buckets[ip] = bucket
+		return bucket
+	}
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	bucket.tokens = math.Min(float64(rl.maxRequest), bucket.tokens+elapsed*rl.refillRate())
+	bucket.lastRefill = now
+	return bucket
+}
+
+// isAllowed consomme un jeton du seau de l'IP s'il en reste un.
+func (rl *TokenBucketRateLimiter) isAllowed(ip string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	bucket := rl.refill(ip, time.Now())
+	if bucket.tokens < 1 {
+		slog.Warn("Limite de requêtes dépassée", "component", "rate_limiter", "ip", ip,
+			"max_requests", rl.maxRequest, "window", rl.window.String())
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// getRemainingRequests retourne le nombre de jetons entiers disponibles pour une IP.
+func (rl *TokenBucketRateLimiter) getRemainingRequests(ip string) int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	return int(rl.refill(ip, time.Now()).tokens)
+}
+
+// getResetTime retourne, pour un seau vide, le moment où le prochain jeton sera disponible,
+// sinon celui où le seau sera de nouveau plein.
+func (rl *TokenBucketRateLimiter) getResetTime(ip string) time.Time {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	bucket := rl.refill(ip, now)
+	missing := float64(rl.maxRequest) - bucket.tokens
+	if bucket.tokens < 1 {
+		missing = 1 - bucket.tokens
+	}
+	return now.Add(time.Duration(missing / rl.refillRate() * float64(time.Second)))
+}
+
+// limit retourne la capacité du seau.
+func (rl *TokenBucketRateLimiter) limit() int {
+	return rl.maxRequest
+}
+
+// windowDuration retourne la durée de remplissage complet d'un seau vide.
+func (rl *TokenBucketRateLimiter) windowDuration() time.Duration {
+	return rl.window
+}
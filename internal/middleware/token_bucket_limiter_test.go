@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+// burst envoie n requêtes de l'IP et retourne le nombre de requêtes acceptées.
+func burst(limiter RateLimiter, ip string, n int) int {
+	allowed := 0
+	for i := 0; i < n; i++ {
+		if limiter.isAllowed(ip) {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+// advanceFixedWindow simule l'écoulement de 'd' pour l'IP : le limiteur lit l'horloge réelle,
+// on recule donc les instants enregistrés.
+func advanceFixedWindow(rl *IPRateLimiter, ip string, d time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	info := rl.ips[ip]
+	info.resetTime = info.resetTime.Add(-d)
+	info.lastAccess = info.lastAccess.Add(-d)
+}
+
+// advanceTokenBucket simule l'écoulement de 'd' pour l'IP en reculant son dernier remplissage.
+func advanceTokenBucket(rl *TokenBucketRateLimiter, ip string, d time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.buckets[ip].lastRefill = rl.buckets[ip].lastRefill.Add(-d)
+}
+
+func TestBurstAtWindowBoundary(t *testing.T) {
+	const limit, ip = 10, "203.0.113.7"
+	fixed := NewIPRateLimiter(limit, 1)
+	bucket := NewTokenBucketRateLimiter(limit, 1)
+
+	// Rafale juste avant la fin de la fenêtre, puis une autre juste après la réinitialisation
+	if got := burst(fixed, ip, limit); got != limit {
+		t.Fatalf("fenêtre fixe, première rafale: %d acceptées, attendu %d", got, limit)
+	}
+	advanceFixedWindow(fixed, ip, time.Minute+time.Millisecond)
+	if got := burst(fixed, ip, limit); got != limit {
+		t.Errorf("fenêtre fixe, rafale après la réinitialisation: %d acceptées, attendu %d (le double de la limite en quelques ms)", got, limit)
+	}
+
+	// Le seau à jetons, vidé par la première rafale, ne s'est presque pas rempli quelques ms plus tard
+	if got := burst(bucket, ip, limit); got != limit {
+		t.Fatalf("seau à jetons, première rafale: %d acceptées, attendu %d", got, limit)
+	}
+	advanceTokenBucket(bucket, ip, 2*time.Millisecond)
+	if got := burst(bucket, ip, limit); got != 0 {
+		t.Errorf("seau à jetons, seconde rafale: %d acceptées, attendu 0", got)
+	}
+}
+
+func TestTokenBucketRefillsProgressively(t *testing.T) {
+	const limit, ip = 10, "203.0.113.7"
+	bucket := NewTokenBucketRateLimiter(limit, 1)
+	burst(bucket, ip, limit)
+
+	// Une demi-fenêtre rend la moitié des jetons, une fenêtre entière remplit le seau sans le dépasser
+	advanceTokenBucket(bucket, ip, 30*time.Second)
+	if got := bucket.getRemainingRequests(ip); got != limit/2 {
+		t.Errorf("après une demi-fenêtre: %d jetons, attendu %d", got, limit/2)
+	}
+	advanceTokenBucket(bucket, ip, 2*time.Minute)
+	if got := burst(bucket, ip, 2*limit); got != limit {
+		t.Errorf("après deux fenêtres: %d acceptées, attendu %d", got, limit)
+	}
+}
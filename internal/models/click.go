@@ -2,22 +2,50 @@ package models
 
 import "time"
 
+// ServedPathPrimary est le chemin de redirection d'un lien simple, vers son URL principale.
+// Les URLs de repli ("fallback") et les variantes A/B ("variant:<id>") utiliseront les autres valeurs de ServedPath.
+const ServedPathPrimary = "primary"
+
+// ReferrerDirect est le référent enregistré pour un clic sans en-tête Referer (accès direct, favori, application).
+const ReferrerDirect = "direct"
+
+// ReferrerMaxLength est la taille de la colonne referrer : les référents plus longs sont tronqués.
+const ReferrerMaxLength = 255
+
+// CountryUnknown regroupe dans les statistiques les clics dont le pays n'a pas pu être résolu.
+const CountryUnknown = "unknown"
+
+// ClicksRolledUp regroupe dans les répartitions des statistiques (pays, navigateur, référent, chemin servi)
+// les clics compactés dans 'click_daily', qui ne conservent que le nombre de clics par jour.
+const ClicksRolledUp = "rolled_up"
+
+// UserAgentOther regroupe les navigateurs et systèmes non reconnus dans le User-Agent.
+const UserAgentOther = "Other"
+
 // Click représente un événement de clic sur un lien raccourci.
 // GORM utilisera ces tags pour créer la table 'clicks'.
 type Click struct {
-	ID        uint      `gorm:"primaryKey"`        // Clé primaire
-	LinkID    uint      `gorm:"index"`             // Clé étrangère vers la table 'links', indexée pour des requêtes efficaces
-	Link      Link      `gorm:"foreignKey:LinkID"` // Relation GORM: indique que LinkID est une FK vers le champ ID de Link
-	Timestamp time.Time // Horodatage précis du clic
-	UserAgent string    `gorm:"size:255"` // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
-	IPAddress string    `gorm:"size:50"`  // Adresse IP de l'utilisateur
+	ID         uint      `gorm:"primaryKey"`        // Clé primaire
+	LinkID     uint      `gorm:"index"`             // Clé étrangère vers la table 'links', indexée pour des requêtes efficaces
+	Link       Link      `gorm:"foreignKey:LinkID"` // Relation GORM: indique que LinkID est une FK vers le champ ID de Link
+	Timestamp  time.Time // Horodatage précis du clic
+	UserAgent  string    `gorm:"size:255"`                // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
+	IPAddress  string    `gorm:"size:50"`                 // Adresse IP de l'utilisateur
+	ServedPath string    `gorm:"size:50;default:primary"` // Chemin de redirection emprunté (primary, fallback, variant:<id>)
+	Referrer   string    `gorm:"size:255"`                // Page d'origine du clic (en-tête Referer), ReferrerDirect si absente
+	Country    string    `gorm:"size:2"`                  // Code pays ISO 3166-1 alpha-2 résolu depuis l'IP, vide si inconnu
+	Browser    string    `gorm:"size:50"`                 // Famille de navigateur extraite du User-Agent (UserAgentOther si non reconnue)
+	OS         string    `gorm:"size:50"`                 // Système d'exploitation extrait du User-Agent (UserAgentOther si non reconnu)
 }
 
 // ClickEvent représente un événement de clic brut, destiné à être passé via un channel
 // Ce n'est pas un modèle GORM direct.
 type ClickEvent struct {
-	LinkID    uint      // LinkID est l'ID du lien qui a été cliqué
-	Timestamp time.Time // Timestamp est l'horodatage précis du clic
-	UserAgent string    // UserAgent contient les informations sur le navigateur/OS de l'utilisateur
-	IPAddress string    // IPAddress est l'adresse IP de l'utilisateur qui a cliqué
+	LinkID     uint      // LinkID est l'ID du lien qui a été cliqué
+	Timestamp  time.Time // Timestamp est l'horodatage précis du clic
+	UserAgent  string    // UserAgent contient les informations sur le navigateur/OS de l'utilisateur
+	IPAddress  string    // IPAddress est l'adresse IP de l'utilisateur qui a cliqué
+	ServedPath string    // ServedPath est le chemin de redirection emprunté (voir les constantes ServedPath*)
+	Referrer   string    // Referrer est la page d'origine du clic, ReferrerDirect si l'en-tête Referer est absent
+	RequestID  string    // RequestID est l'identifiant de la requête de redirection (X-Request-ID), pour corréler les logs
 }
@@ -0,0 +1,16 @@
+package models
+
+// ClickDaily est un agrégat journalier des clics d'un lien.
+// Les clics bruts plus anciens que analytics.rollup.older_than_days sont résumés dans cette table
+// puis supprimés de 'clicks', ce qui borne la taille de la base tout en conservant l'historique agrégé.
+type ClickDaily struct {
+	ID     uint   `gorm:"primaryKey"`                                            // Clé primaire
+	LinkID uint   `gorm:"uniqueIndex:idx_click_daily_link_day;not null"`         // Lien concerné
+	Day    string `gorm:"uniqueIndex:idx_click_daily_link_day;size:10;not null"` // Jour au format YYYY-MM-DD (UTC)
+	Clicks int    `gorm:"not null"`                                              // Nombre de clics ce jour-là
+}
+
+// TableName force le nom de la table à 'click_daily'.
+func (ClickDaily) TableName() string {
+	return "click_daily"
+}
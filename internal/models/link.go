@@ -2,16 +2,72 @@ package models
 
 import "time"
 
+// ShortCodeMaxLength est la taille de la colonne short_code : aucun code ne peut être plus long.
+// Elle laisse de la marge au-delà des alias personnalisés (20 caractères au plus).
+const ShortCodeMaxLength = 30
+
 // Link représente un lien raccourci dans la base de données.
 // Les tags `gorm:"..."` définissent comment GORM doit mapper cette structure à une table SQL.
 type Link struct {
-ID        uint       `gorm:"primaryKey"`                        // ID est la clé primaire auto-incrémentée
-ShortCode string     `gorm:"uniqueIndex;size:10;not null"`      // ShortCode doit être unique, indexé pour des recherches rapides, taille max 10 caractères
-LongURL   string     `gorm:"not null"`                          // LongURL ne doit pas être null
-CreatedAt time.Time  `gorm:"autoCreateTime"`                    // Horodatage de la création du lien (géré automatiquement par GORM)
-IsActive  bool       `gorm:"default:true"`                      // Indicateur si le lien est actif (pour la surveillance)
-IsCustom  bool       `gorm:"default:false"`                     // Indicateur si le code court a été personnalisé par l'utilisateur (feature bonus)
-ExpiresAt *time.Time `gorm:"index"`                             // Date d'expiration optionnelle du lien (feature bonus), indexé pour des requêtes efficaces
+	ID          uint       `gorm:"primaryKey"`                   // ID est la clé primaire auto-incrémentée
+	ShortCode   string     `gorm:"uniqueIndex;size:30;not null"` // ShortCode doit être unique, indexé pour des recherches rapides, taille max 30 caractères (ShortCodeMaxLength)
+	LongURL     string     `gorm:"not null"`                     // LongURL ne doit pas être null
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`               // Horodatage de la création du lien (géré automatiquement par GORM)
+	IsActive    bool       `gorm:"default:true"`                 // Indicateur si le lien est actif (pour la surveillance)
+	IsCustom    bool       `gorm:"default:false"`                // Indicateur si le code court a été personnalisé par l'utilisateur (feature bonus)
+	ExpiresAt   *time.Time `gorm:"index"`                        // Date d'expiration optionnelle du lien (feature bonus), indexé pour des requêtes efficaces
+	CreatorIP   *string    `gorm:"size:50;index"`                // Adresse IP du créateur (nullable, capturée si security.store_creator_ip), réservée aux usages admin
+	TrackClicks *bool      `gorm:"default:true"`                 // Enregistrer les clics de ce lien (pointeur car GORM ignore un false explicite face à un default)
+	// Raison de la désactivation par le moniteur (ex: InactiveReasonMalware), vide si le lien est actif
+	InactiveReason string `gorm:"size:50"`
+	// Dernier code HTTP observé par le moniteur (0 = aucune vérification ou destination injoignable, ex: timeout)
+	LastCheckStatus int
+	LastCheckedAt   *time.Time // Date de la dernière vérification par le moniteur
+	Source          string     `gorm:"size:20;index"` // Chemin de création (LinkSourceAPI, LinkSourceCLI, ...), vide pour les anciens liens
+	// Hash bcrypt du mot de passe demandé avant la redirection (vide = lien non protégé). Jamais exposé.
+	PasswordHash string `gorm:"size:100"`
+	// Nombre de clics après lequel le lien expire (nil = illimité)
+	MaxClicks *int
+	// Lien canonique dont ce code est un alias supplémentaire (nil pour un lien ordinaire).
+	// La redirection et les clics d'un alias sont ceux de son lien canonique.
+	CanonicalLinkID *uint `gorm:"index"`
+	// Rediriger directement, sans la page intermédiaire de server.preview_redirect
+	SkipPreview bool `gorm:"default:false"`
+	// Nombre de redirections servies, indépendamment des analytics et du suivi des clics (au contraire des clics
+	// enregistrés, ni dédupliqués ni asynchrones). Incrémenté seulement si le lien a un max_clicks ou si
+	// server.expose_click_count_header est activé : ailleurs, il ne reflète pas l'audience du lien.
+	RedirectCount int `gorm:"not null;default:0"`
+	// Propriétaire du lien, dérivé de la clé d'API de création (vide pour un lien créé sans authentification)
+	OwnerID string `gorm:"size:64;index"`
+	// Lien protégé dont le hash n'a pas été chargé (lien lu dans le cache Redis, qui ne stocke pas le hash).
+	// Jamais persisté : le hash est relu en base pour vérifier le mot de passe.
+	PasswordProtected bool `gorm:"-"`
+}
+
+// Chemins de création d'un lien enregistrés dans Source.
+const (
+	LinkSourceAPI = "api" // Créé via POST /api/v1/links
+	LinkSourceCLI = "cli" // Créé via la commande 'create'
+)
+
+// Raisons de désactivation d'un lien enregistrées dans InactiveReason.
+const (
+	InactiveReasonMalware = "malware" // Destination signalée par le service de vérification de sécurité
+	InactiveReasonExpired = "expired" // Lien expiré retiré par la purge (monitor.purge_expired: soft)
+	// Destination injoignable ou en erreur lors de la dernière vérification (monitor.unreachable) ;
+	// le lien est réactivé automatiquement dès que la destination répond de nouveau.
+	InactiveReasonUnreachable = "unreachable"
+)
+
+// TracksClicks indique si les clics de ce lien doivent être enregistrés.
+// Un lien sans valeur explicite (anciens enregistrements) est suivi par défaut.
+func (l *Link) TracksClicks() bool {
+	return l.TrackClicks == nil || *l.TrackClicks
+}
+
+// IsProtected indique si le lien demande un mot de passe avant de rediriger.
+func (l *Link) IsProtected() bool {
+	return l.PasswordHash != "" || l.PasswordProtected
 }
 
 // IsExpired vérifie si le lien a expiré.
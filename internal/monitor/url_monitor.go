@@ -1,12 +1,17 @@
 package monitor
 
 import (
-	"log"
+	"crypto/tls"
+	"errors"
+	"log/slog"
 	"net/http"
 	"sync" // Pour protéger l'accès concurrentiel à knownStates
 	"time"
 
+	apperrors "github.com/axellelanca/urlshortener/internal/errors"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le repository de liens
+	"github.com/axellelanca/urlshortener/internal/services"
 )
 
 // UrlMonitor gère la surveillance périodique des URLs longues.
@@ -15,22 +20,52 @@ type UrlMonitor struct {
 	interval    time.Duration             // Intervalle entre chaque vérification (ex: 5 minutes)
 	knownStates map[uint]bool             // État connu de chaque URL: map[LinkID]estAccessible (true/false)
 	mu          sync.Mutex                // Mutex pour protéger l'accès concurrentiel à knownStates
+	client      *http.Client              // Client HTTP partagé par toutes les sondes
+	safety      *services.URLChecker      // Service de vérification de sécurité des destinations (nil si désactivé)
+	dryRun      bool                      // Journaliser les désactivations sans les appliquer
+	// Désactiver les liens dont la destination est injoignable et les réactiver quand elle répond de nouveau
+	deactivateUnreachable bool
+	unreachableThreshold  int          // Vérifications consécutives en échec avant de désactiver un lien
+	failures              map[uint]int // Échecs consécutifs par LinkID, protégé par mu
 }
 
 // NewUrlMonitor crée et retourne une nouvelle instance de UrlMonitor.
+// minTLSVersion est une constante crypto/tls (ex: tls.VersionTLS12) appliquée aux sondes HTTPS,
+// insecureSkipVerify désactive la vérification des certificats (à réserver au staging).
+// safety est optionnel (nil si désactivé) : les destinations qu'il refuse sont désactivées.
+// deactivateUnreachable désactive les liens dont la destination est injoignable (monitor.unreachable),
+// après unreachableThreshold vérifications consécutives en échec. En dryRun, ces désactivations sont seulement journalisées.
 // Attention: retourne un pointeur
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
+func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration, minTLSVersion uint16, insecureSkipVerify bool,
+	safety *services.URLChecker, dryRun, deactivateUnreachable bool, unreachableThreshold int) *UrlMonitor {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         minTLSVersion,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+
 	return &UrlMonitor{
 		linkRepo:    linkRepo,
 		interval:    interval,
 		knownStates: make(map[uint]bool),
+		safety:      safety,
+		dryRun:      dryRun,
+
+		deactivateUnreachable: deactivateUnreachable,
+		unreachableThreshold:  unreachableThreshold,
+		failures:              make(map[uint]int),
+		// Définir un timeout pour éviter de bloquer trop longtemps (5 secondes c'est bien)
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: transport,
+		},
 	}
 }
 
 // Start lance la boucle de surveillance périodique des URLs.
 // Cette fonction est conçue pour être lancée dans une goroutine séparée.
 func (m *UrlMonitor) Start() {
-	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v...", m.interval)
+	slog.Info("Démarrage du moniteur d'URLs", "component", "monitor", "interval", m.interval.String())
 	ticker := time.NewTicker(m.interval) // Crée un ticker qui envoie un signal à chaque intervalle
 	defer ticker.Stop()                  // S'assure que le ticker est arrêté quand Start se termine
 
@@ -45,19 +80,46 @@ func (m *UrlMonitor) Start() {
 
 // checkUrls effectue une vérification de l'état de toutes les URLs longues enregistrées.
 func (m *UrlMonitor) checkUrls() {
-	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
+	slog.Debug("Lancement de la vérification de l'état des URLs", "component", "monitor")
 
 	// Récupérer toutes les URLs longues actives depuis le linkRepo (GetAllLinks).
 	// Gérer l'erreur si la récupération échoue.
 	links, err := m.linkRepo.GetAllLinks()
 	if err != nil {
-		log.Printf("[MONITOR] ERREUR lors de la récupération des liens pour la surveillance : %v", err)
+		slog.Error("Récupération des liens pour la surveillance impossible", "component", "monitor", "error", err)
 		return
 	}
 
+	// Transitions du cycle, résumées à la fin en mode observation
+	wouldDeactivate, becameAccessible, becameInaccessible := 0, 0, 0
+
 	for _, link := range links {
-		// Pour chaque lien, vérifier son accessibilité (isUrlAccessible).
-		currentState := m.isUrlAccessible(link.LongURL)
+		// Les alias partagent la destination de leur lien canonique, déjà vérifiée
+		if link.CanonicalLinkID != nil {
+			continue
+		}
+
+		// Désactiver les liens dont la destination est signalée par le service de sécurité
+		flagged := false
+		if m.safety != nil && link.IsActive && m.checkSafety(link) {
+			wouldDeactivate++
+			flagged = true
+		}
+
+		// Pour chaque lien, vérifier son accessibilité et enregistrer le code HTTP observé.
+		status := m.probeStatus(link.LongURL)
+		currentState := isAccessibleStatus(status)
+		if err := m.linkRepo.UpdateLinkCheck(link.ID, status, time.Now()); err != nil {
+			slog.Error("Enregistrement de la vérification impossible", "component", "monitor", "short_code", link.ShortCode, "error", err)
+		}
+
+		// Désactiver un lien injoignable depuis unreachableThreshold vérifications,
+		// réactiver un lien désactivé pour cette raison qui répond de nouveau
+		failures := m.recordProbeResult(link.ID, currentState)
+		if m.deactivateUnreachable && !flagged && (currentState || failures >= m.unreachableThreshold) &&
+			m.updateReachability(link, status, currentState) && !currentState {
+			wouldDeactivate++
+		}
 
 		// Protéger l'accès à la map 'knownStates' car 'checkUrls' peut être exécuté concurremment
 		m.mu.Lock()
@@ -67,42 +129,143 @@ func (m *UrlMonitor) checkUrls() {
 
 		// Si c'est la première vérification pour ce lien, on initialise l'état sans notifier.
 		if !exists {
-			log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
-				link.ShortCode, link.LongURL, formatState(currentState))
+			slog.Info("État initial du lien", "component", "monitor",
+				"short_code", link.ShortCode, "long_url", link.LongURL, "state", formatState(currentState))
 			continue
 		}
 
 		// Comparer l'état actuel avec l'état précédent.
 		// Si l'état a changé, générer une fausse notification dans les logs.
 		if previousState != currentState {
-			log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
-				link.ShortCode, link.LongURL, formatState(previousState), formatState(currentState))
+			slog.Warn("Changement d'état du lien", "component", "notification", "short_code", link.ShortCode,
+				"long_url", link.LongURL, "previous_state", formatState(previousState), "state", formatState(currentState))
+			if currentState {
+				becameAccessible++
+			} else {
+				becameInaccessible++
+			}
 		}
 
 	}
-	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
+	if m.dryRun {
+		slog.Info("Résumé du cycle", "component", "monitor", "dry_run", true, "would_deactivate", wouldDeactivate,
+			"became_accessible", becameAccessible, "became_inaccessible", becameInaccessible)
+	}
+	slog.Debug("Vérification de l'état des URLs terminée", "component", "monitor", "links", len(links))
 }
 
-// isUrlAccessible effectue une requête HTTP HEAD pour vérifier l'accessibilité d'une URL.
-func (m *UrlMonitor) isUrlAccessible(url string) bool {
-	// Définir un timeout pour éviter de bloquer trop longtemps (5 secondes c'est bien)
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+// checkSafety soumet la destination du lien au service de vérification de sécurité
+// et désactive le lien si elle est refusée. Un service injoignable ne désactive rien.
+// Retourne true si la destination a été refusée (lien désactivé, ou qui l'aurait été en dryRun).
+func (m *UrlMonitor) checkSafety(link models.Link) bool {
+	err := m.safety.Check(link.LongURL)
+	var denied *apperrors.ErrURLDenied
+	if !errors.As(err, &denied) {
+		if err != nil {
+			slog.Warn("Vérification de sécurité impossible", "component", "monitor", "short_code", link.ShortCode, "error", err)
+		}
+		return false
+	}
+
+	if m.dryRun {
+		slog.Info("Le lien aurait été désactivé: destination signalée", "component", "monitor", "dry_run", true,
+			"short_code", link.ShortCode, "long_url", link.LongURL, "reason", denied.Reason)
+		return true
 	}
+	if err := m.linkRepo.DeactivateLink(link.ID, models.InactiveReasonMalware); err != nil {
+		slog.Error("Désactivation du lien impossible", "component", "monitor", "short_code", link.ShortCode, "error", err)
+		return false
+	}
+	slog.Warn("Lien désactivé: destination signalée", "component", "notification",
+		"short_code", link.ShortCode, "long_url", link.LongURL, "reason", denied.Reason)
+	return true
+}
 
+// recordProbeResult tient le nombre de vérifications consécutives en échec du lien et le retourne :
+// remis à zéro dès que la destination répond.
+func (m *UrlMonitor) recordProbeResult(linkID uint, accessible bool) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if accessible {
+		delete(m.failures, linkID)
+		return 0
+	}
+	m.failures[linkID]++
+	return m.failures[linkID]
+}
+
+// updateReachability met à jour l'activation d'un lien selon l'accessibilité de sa destination (monitor.unreachable).
+// Seuls les liens actifs sont désactivés, et seuls ceux désactivés pour InactiveReasonUnreachable sont réactivés :
+// une désactivation pour une autre raison (destination signalée, lien expiré purgé) n'est jamais levée ici.
+// Retourne true si l'activation a changé (ou aurait changé en dryRun).
+func (m *UrlMonitor) updateReachability(link models.Link, status int, accessible bool) bool {
+	switch {
+	case link.IsActive && !accessible:
+		if m.dryRun {
+			slog.Info("Le lien aurait été désactivé: destination injoignable", "component", "monitor", "dry_run", true,
+				"short_code", link.ShortCode, "long_url", link.LongURL, "status", status)
+			return true
+		}
+		if err := m.linkRepo.UpdateLinkActive(link.ID, false, models.InactiveReasonUnreachable); err != nil {
+			slog.Error("Désactivation du lien impossible", "component", "monitor", "short_code", link.ShortCode, "error", err)
+			return false
+		}
+		slog.Warn("Lien désactivé: destination injoignable", "component", "notification",
+			"short_code", link.ShortCode, "long_url", link.LongURL, "status", status)
+		return true
+	case !link.IsActive && link.InactiveReason == models.InactiveReasonUnreachable && accessible:
+		if m.dryRun {
+			slog.Info("Le lien aurait été réactivé: destination de nouveau accessible", "component", "monitor", "dry_run", true,
+				"short_code", link.ShortCode, "long_url", link.LongURL)
+			return true
+		}
+		if err := m.linkRepo.UpdateLinkActive(link.ID, true, ""); err != nil {
+			slog.Error("Réactivation du lien impossible", "component", "monitor", "short_code", link.ShortCode, "error", err)
+			return false
+		}
+		slog.Info("Lien réactivé: destination de nouveau accessible", "component", "notification",
+			"short_code", link.ShortCode, "long_url", link.LongURL)
+		return true
+	}
+	return false
+}
+
+// probeStatus effectue une requête HTTP HEAD sur une URL et retourne le code de statut obtenu,
+// ou 0 si la destination est injoignable (erreur réseau, timeout).
+// Les serveurs qui ne supportent pas HEAD (405, 501) sont sondés de nouveau en GET.
+func (m *UrlMonitor) probeStatus(url string) int {
 	// Effectuer une requête HEAD (plus légère que GET) sur l'URL.
-	// Un code de statut 2xx ou 3xx indique que l'URL est accessible.
-	resp, err := client.Head(url)
+	status := m.probe(http.MethodHead, url)
+	if status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented {
+		status = m.probe(http.MethodGet, url)
+	}
+	return status
+}
+
+// probe envoie une requête 'method' sur l'URL et retourne le code de statut obtenu, ou 0 en cas d'erreur.
+// Le corps d'une réponse GET n'est pas lu.
+func (m *UrlMonitor) probe(method, url string) int {
+	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
-		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
-		return false
+		slog.Warn("Requête de vérification invalide", "component", "monitor", "url", url, "error", err)
+		return 0
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		slog.Warn("Erreur d'accès à l'URL", "component", "monitor", "url", url, "method", method, "error", err)
+		return 0
 	}
 
 	// Assurez-vous de fermer le corps de la réponse pour libérer les ressources
 	defer resp.Body.Close()
 
-	// Déterminer l'accessibilité basée sur le code de statut HTTP.
-	return resp.StatusCode >= 200 && resp.StatusCode < 400 // Codes 2xx ou 3xx
+	return resp.StatusCode
+}
+
+// isAccessibleStatus indique si un code de statut correspond à une URL accessible.
+// Un code de statut 2xx ou 3xx indique que l'URL est accessible.
+func isAccessibleStatus(status int) bool {
+	return status >= 200 && status < 400
 }
 
 // formatState est une fonction utilitaire pour rendre l'état plus lisible dans les logs.
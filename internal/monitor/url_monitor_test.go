@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+func TestProbeStatusFallsBackToGet(t *testing.T) {
+	for _, headStatus := range []int{http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		var gets atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(headStatus)
+				return
+			}
+			gets.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		m := NewUrlMonitor(nil, time.Minute, tls.VersionTLS12, false, nil, false, true, 1)
+		if status := m.probeStatus(server.URL); status != http.StatusOK || gets.Load() != 1 {
+			t.Errorf("HEAD %d: statut %d après %d GET, attendu 200 après 1 GET", headStatus, status, gets.Load())
+		}
+		server.Close()
+	}
+}
+
+func TestProbeStatusKeepsHeadErrors(t *testing.T) {
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	m := NewUrlMonitor(nil, time.Minute, tls.VersionTLS12, false, nil, false, true, 1)
+	if status := m.probeStatus(server.URL); status != http.StatusNotFound || gets.Load() != 0 {
+		t.Errorf("statut %d après %d GET, attendu 404 sans GET", status, gets.Load())
+	}
+}
+
+// monitoredRepo sert un lien unique au moniteur et enregistre ses changements d'activation.
+type monitoredRepo struct {
+	repository.LinkRepository
+	link models.Link
+}
+
+func (r *monitoredRepo) GetAllLinks() ([]models.Link, error) {
+	return []models.Link{r.link}, nil
+}
+
+func (r *monitoredRepo) UpdateLinkCheck(linkID uint, status int, checkedAt time.Time) error {
+	return nil
+}
+
+func (r *monitoredRepo) UpdateLinkActive(linkID uint, active bool, reason string) error {
+	r.link.IsActive, r.link.InactiveReason = active, reason
+	return nil
+}
+
+func TestUnreachableLinkDeactivatedAfterThreshold(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthy.Load() {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := &monitoredRepo{link: models.Link{ID: 1, ShortCode: "abc123", LongURL: server.URL, IsActive: true}}
+	m := NewUrlMonitor(repo, time.Minute, tls.VersionTLS12, false, nil, false, true, 3)
+
+	// Deux échecs : le lien reste actif
+	m.checkUrls()
+	m.checkUrls()
+	if !repo.link.IsActive {
+		t.Fatal("lien désactivé avant le seuil de 3 échecs consécutifs")
+	}
+
+	// Une réponse remet le décompte à zéro
+	healthy.Store(true)
+	m.checkUrls()
+	healthy.Store(false)
+	m.checkUrls()
+	m.checkUrls()
+	if !repo.link.IsActive {
+		t.Fatal("lien désactivé alors que les échecs n'étaient pas consécutifs")
+	}
+
+	// Troisième échec consécutif : désactivation
+	m.checkUrls()
+	if repo.link.IsActive || repo.link.InactiveReason != models.InactiveReasonUnreachable {
+		t.Errorf("après 3 échecs: is_active = %v, reason = %q, attendu désactivé (%s)",
+			repo.link.IsActive, repo.link.InactiveReason, models.InactiveReasonUnreachable)
+	}
+
+	// Réactivation dès la première vérification réussie
+	healthy.Store(true)
+	m.checkUrls()
+	if !repo.link.IsActive {
+		t.Error("lien non réactivé après une vérification réussie")
+	}
+}
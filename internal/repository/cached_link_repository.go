@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// errLinkCacheMiss est retournée par LinkCache.Get quand le code court n'est pas en cache.
+var errLinkCacheMiss = errors.New("link cache miss")
+
+// staleCacheServes compte les liens servis depuis une entrée de cache expirée faute de pouvoir lire la base.
+var staleCacheServes atomic.Int64
+
+// StaleCacheServes retourne le nombre de liens servis depuis une entrée expirée (cache.serve_stale_on_error)
+// depuis le démarrage.
+func StaleCacheServes() int64 {
+	return staleCacheServes.Load()
+}
+
+// LinkCache est un cache de liens indexé par code court.
+// Get retourne errLinkCacheMiss si le code n'est pas en cache ; toute autre erreur signale un cache indisponible.
+// GetStale retourne aussi une entrée expirée, tant qu'elle est conservée (durée de rétention du cache).
+type LinkCache interface {
+	Get(shortCode string) (*models.Link, error)
+	GetStale(shortCode string) (*models.Link, error)
+	Set(link *models.Link, ttl time.Duration) error
+	Delete(shortCode string) error
+}
+
+// CachedLinkRepository est un cache de lecture devant un LinkRepository.
+// Seul GetLinkByShortCode, appelé à chaque redirection, passe par le cache ; les autres méthodes sont déléguées.
+// Le lien est mis en cache avec son état (la redirection en a besoin, pas seulement de sa destination),
+// et les écritures qui le modifient invalident son entrée. Le cache Redis n'en garde que les champs de la redirection :
+// un lien lu dans le cache ne sert qu'à rediriger, les vérifications et écritures relisent le lien en base.
+type CachedLinkRepository struct {
+	LinkRepository
+	cache      LinkCache
+	ttl        time.Duration
+	serveStale bool // Servir une entrée expirée si le repository est en erreur (cache.serve_stale_on_error)
+}
+
+// NewCachedLinkRepository crée un CachedLinkRepository devant 'inner'. Les entrées vivent au plus 'ttl'.
+// Avec 'serveStale', une entrée expirée mais conservée par le cache est servie quand le repository est en erreur.
+func NewCachedLinkRepository(inner LinkRepository, cache LinkCache, ttl time.Duration, serveStale bool) *CachedLinkRepository {
+	return &CachedLinkRepository{LinkRepository: inner, cache: cache, ttl: ttl, serveStale: serveStale}
+}
+
+// GetLinkByShortCode sert le lien depuis le cache, ou le lit dans le repository et le met en cache.
+// Une erreur du cache n'empêche jamais la lecture : le repository est alors interrogé directement,
+// sans tenter d'écrire dans un cache indisponible. Si c'est le repository qui est en erreur (base indisponible)
+// et que serveStale est activé, la dernière entrée connue du lien est servie, même expirée.
+func (r *CachedLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	link, err := r.cache.Get(shortCode)
+	if err == nil {
+		return link, nil
+	}
+	cacheable := errors.Is(err, errLinkCacheMiss)
+	if !cacheable {
+		slog.Warn("Lecture du cache impossible", "component", "cache", "short_code", shortCode, "error", err)
+	}
+
+	link, err = r.LinkRepository.GetLinkByShortCode(shortCode)
+	if err != nil {
+		if r.serveStale && !errors.Is(err, gorm.ErrRecordNotFound) {
+			if stale, staleErr := r.cache.GetStale(shortCode); staleErr == nil {
+				staleCacheServes.Add(1)
+				slog.Warn("Lecture du lien impossible, entrée de cache expirée servie", "component", "cache",
+					"short_code", shortCode, "error", err)
+				return stale, nil
+			}
+		}
+		return nil, err
+	}
+	if cacheable {
+		r.store(link)
+	}
+	return link, nil
+}
+
+// store met le lien en cache. La durée de vie est bornée par l'expiration du lien pour qu'un lien expiré
+// soit relu en base ; un lien déjà expiré n'est pas mis en cache.
+func (r *CachedLinkRepository) store(link *models.Link) {
+	ttl := r.ttl
+	if link.ExpiresAt != nil {
+		ttl = min(ttl, time.Until(*link.ExpiresAt))
+		if ttl <= 0 {
+			return
+		}
+	}
+	if err := r.cache.Set(link, ttl); err != nil {
+		slog.Warn("Écriture du cache impossible", "component", "cache", "short_code", link.ShortCode, "error", err)
+	}
+}
+
+// invalidate retire l'entrée d'un code court. Un échec est journalisé : l'entrée expirera d'elle-même.
+func (r *CachedLinkRepository) invalidate(shortCode string) {
+	if err := r.cache.Delete(shortCode); err != nil {
+		slog.Warn("Invalidation impossible", "component", "cache", "short_code", shortCode, "error", err)
+	}
+}
+
+// invalidateByID retire l'entrée du lien d'ID donné, dont le code court est relu dans le repository.
+func (r *CachedLinkRepository) invalidateByID(linkID uint) {
+	link, err := r.LinkRepository.GetLinkByID(linkID)
+	if err != nil {
+		slog.Warn("Lien introuvable pour l'invalidation", "component", "cache", "link_id", linkID, "error", err)
+		return
+	}
+	r.invalidate(link.ShortCode)
+}
+
+// DeleteLink supprime le lien puis invalide son entrée. Les alias supprimés avec lui peuvent rester en cache
+// jusqu'à leur expiration, mais leur résolution échoue faute de lien canonique.
+func (r *CachedLinkRepository) DeleteLink(shortCode string) error {
+	if err := r.LinkRepository.DeleteLink(shortCode); err != nil {
+		return err
+	}
+	r.invalidate(shortCode)
+	return nil
+}
+
+// UpdateLink enregistre la nouvelle destination puis invalide l'entrée du lien.
+// Les alias sont servis via leur lien canonique : leur entrée n'a pas besoin d'être invalidée.
+func (r *CachedLinkRepository) UpdateLink(link *models.Link) error {
+	if err := r.LinkRepository.UpdateLink(link); err != nil {
+		return err
+	}
+	r.invalidate(link.ShortCode)
+	return nil
+}
+
+// DeactivateLink désactive le lien puis invalide son entrée.
+func (r *CachedLinkRepository) DeactivateLink(linkID uint, reason string) error {
+	if err := r.LinkRepository.DeactivateLink(linkID, reason); err != nil {
+		return err
+	}
+	r.invalidateByID(linkID)
+	return nil
+}
+
+// UpdateLinkActive active ou désactive le lien puis invalide son entrée.
+func (r *CachedLinkRepository) UpdateLinkActive(linkID uint, active bool, reason string) error {
+	if err := r.LinkRepository.UpdateLinkActive(linkID, active, reason); err != nil {
+		return err
+	}
+	r.invalidateByID(linkID)
+	return nil
+}
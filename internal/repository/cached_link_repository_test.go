@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestLRULinkCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRULinkCache(2, 0)
+	for _, code := range []string{"aaa", "bbb"} {
+		cache.Set(&models.Link{ShortCode: code}, time.Hour)
+	}
+	// Lire "aaa" le rend plus récent que "bbb", qui doit être évincé à l'ajout de "ccc"
+	if _, err := cache.Get("aaa"); err != nil {
+		t.Fatalf("Get(aaa): %v", err)
+	}
+	cache.Set(&models.Link{ShortCode: "ccc"}, time.Hour)
+
+	if _, err := cache.Get("bbb"); !errors.Is(err, errLinkCacheMiss) {
+		t.Errorf("Get(bbb) = %v, attendu un cache miss", err)
+	}
+	for _, code := range []string{"aaa", "ccc"} {
+		if _, err := cache.Get(code); err != nil {
+			t.Errorf("Get(%s): %v", code, err)
+		}
+	}
+}
+
+func TestLRULinkCacheDropsExpiredEntries(t *testing.T) {
+	cache := NewLRULinkCache(10, 0)
+	cache.Set(&models.Link{ShortCode: "aaa"}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := cache.Get("aaa"); !errors.Is(err, errLinkCacheMiss) {
+		t.Errorf("Get = %v, attendu un cache miss", err)
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("entrées restantes = %d, attendu 0", len(cache.entries))
+	}
+}
+
+func TestLRULinkCacheCopiesLinks(t *testing.T) {
+	cache := NewLRULinkCache(10, 0)
+	link := &models.Link{ShortCode: "aaa", LongURL: "https://example.com/a"}
+	cache.Set(link, time.Hour)
+	link.LongURL = "https://example.com/modifie"
+
+	cached, _ := cache.Get("aaa")
+	cached.LongURL = "https://example.com/modifie-aussi"
+	cached, _ = cache.Get("aaa")
+	if cached.LongURL != "https://example.com/a" {
+		t.Errorf("LongURL en cache = %q, attendu la valeur d'origine", cached.LongURL)
+	}
+}
+
+func TestCachedLinkRepositoryInvalidatesOnWrites(t *testing.T) {
+	inner := NewLinkRepository(newTestDB(t))
+	repo := NewCachedLinkRepository(inner, NewLRULinkCache(10, 0), time.Hour, false)
+	link := createTestLink(t, inner, "abc123")
+
+	// Met le lien en cache, puis vérifie qu'une mise à jour est visible à la lecture suivante
+	if _, err := repo.GetLinkByShortCode("abc123"); err != nil {
+		t.Fatalf("GetLinkByShortCode: %v", err)
+	}
+	link.LongURL = "https://example.com/nouvelle"
+	if err := repo.UpdateLink(link); err != nil {
+		t.Fatalf("UpdateLink: %v", err)
+	}
+	got, err := repo.GetLinkByShortCode("abc123")
+	if err != nil {
+		t.Fatalf("GetLinkByShortCode après mise à jour: %v", err)
+	}
+	if got.LongURL != link.LongURL {
+		t.Errorf("LongURL = %q, attendu %q", got.LongURL, link.LongURL)
+	}
+
+	if err := repo.DeleteLink("abc123"); err != nil {
+		t.Fatalf("DeleteLink: %v", err)
+	}
+	if _, err := repo.GetLinkByShortCode("abc123"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetLinkByShortCode après suppression = %v, attendu ErrRecordNotFound", err)
+	}
+}
+
+func TestCachedLinkRepositorySkipsExpiredLinks(t *testing.T) {
+	inner := NewLinkRepository(newTestDB(t))
+	cache := NewLRULinkCache(10, 0)
+	repo := NewCachedLinkRepository(inner, cache, time.Hour, false)
+	expiredAt := time.Now().Add(-time.Minute)
+	link := &models.Link{ShortCode: "old123", LongURL: "https://example.com/old", ExpiresAt: &expiredAt, CreatedAt: time.Now()}
+	if err := inner.CreateLink(link); err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+
+	if _, err := repo.GetLinkByShortCode("old123"); err != nil {
+		t.Fatalf("GetLinkByShortCode: %v", err)
+	}
+	if _, err := cache.Get("old123"); !errors.Is(err, errLinkCacheMiss) {
+		t.Errorf("lien expiré mis en cache (Get = %v)", err)
+	}
+}
+
+// benchmarkGetLinkByShortCode mesure la lecture répétée d'un même lien via 'repo'.
+func benchmarkGetLinkByShortCode(b *testing.B, repo LinkRepository) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.GetLinkByShortCode("abc123"); err != nil {
+			b.Fatalf("GetLinkByShortCode: %v", err)
+		}
+	}
+}
+
+func BenchmarkGetLinkByShortCodeUncached(b *testing.B) {
+	repo := NewLinkRepository(newTestDB(b))
+	createTestLink(b, repo, "abc123")
+	benchmarkGetLinkByShortCode(b, repo)
+}
+
+func BenchmarkGetLinkByShortCodeLRUCached(b *testing.B) {
+	inner := NewLinkRepository(newTestDB(b))
+	createTestLink(b, inner, "abc123")
+	benchmarkGetLinkByShortCode(b, NewCachedLinkRepository(inner, NewLRULinkCache(100, 0), time.Hour, false))
+}
+
+func TestCachedLinkOmitsPrivateFields(t *testing.T) {
+	creatorIP := "203.0.113.7"
+	link := &models.Link{ID: 7, ShortCode: "abc123", LongURL: "https://example.com/secret", IsActive: true,
+		PasswordHash: "$2a$10$hash", CreatorIP: &creatorIP, OwnerID: "key-a"}
+
+	data, err := json.Marshal(newCachedLink(link))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	for _, secret := range []string{"$2a$10$hash", creatorIP, "key-a"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("entrée Redis %s: contient %q", data, secret)
+		}
+	}
+
+	var entry cachedLink
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	got := entry.toLink()
+	if got.ID != link.ID || got.LongURL != link.LongURL || !got.IsActive || !got.IsProtected() {
+		t.Errorf("lien relu = %+v, attendu ID, destination, état et protection conservés", got)
+	}
+}
+
+func TestLRULinkCacheKeepsExpiredEntriesForGetStale(t *testing.T) {
+	cache := NewLRULinkCache(10, time.Hour)
+	cache.Set(&models.Link{ShortCode: "aaa"}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := cache.Get("aaa"); !errors.Is(err, errLinkCacheMiss) {
+		t.Errorf("Get = %v, attendu un cache miss", err)
+	}
+	if _, err := cache.GetStale("aaa"); err != nil {
+		t.Errorf("GetStale = %v, attendu l'entrée expirée", err)
+	}
+}
+
+// failingLinkRepo simule une base indisponible pour les lectures par code court.
+type failingLinkRepo struct {
+	LinkRepository
+	err error
+}
+
+func (r *failingLinkRepo) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.LinkRepository.GetLinkByShortCode(shortCode)
+}
+
+func TestCachedLinkRepositoryServesStaleOnError(t *testing.T) {
+	for _, serveStale := range []bool{true, false} {
+		t.Run(fmt.Sprint(serveStale), func(t *testing.T) {
+			store := NewLinkRepository(newTestDB(t))
+			createTestLink(t, store, "abc123")
+			inner := &failingLinkRepo{LinkRepository: store}
+			repo := NewCachedLinkRepository(inner, NewLRULinkCache(10, time.Hour), time.Millisecond, serveStale)
+			if _, err := repo.GetLinkByShortCode("abc123"); err != nil {
+				t.Fatalf("GetLinkByShortCode: %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			inner.err = errors.New("database is down")
+			servedBefore := StaleCacheServes()
+			link, err := repo.GetLinkByShortCode("abc123")
+			if serveStale && (err != nil || link.ShortCode != "abc123") {
+				t.Errorf("GetLinkByShortCode = %v, %v, attendu l'entrée expirée", link, err)
+			}
+			if !serveStale && err == nil {
+				t.Error("GetLinkByShortCode: entrée expirée servie sans serve_stale_on_error")
+			}
+			want := int64(0)
+			if serveStale {
+				want = 1
+			}
+			if served := StaleCacheServes() - servedBefore; served != want {
+				t.Errorf("entrées expirées servies = %d, attendu %d", served, want)
+			}
+
+			// Un lien introuvable n'est jamais servi depuis le cache
+			inner.err = gorm.ErrRecordNotFound
+			if _, err := repo.GetLinkByShortCode("abc123"); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("lien introuvable: erreur = %v, attendu ErrRecordNotFound", err)
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ClickRepository est une interface qui définit les méthodes d'accès aux données
@@ -10,7 +13,9 @@ import (
 // de rester indépendante de l'implémentation spécifique de la base de données.
 type ClickRepository interface {
 	CreateClick(click *models.Click) error
+	CreateClicks(clicks []models.Click) error
 	CountClicksByLinkID(linkID uint) (int, error)
+	RollupClicksBefore(cutoff time.Time) (int, error)
 }
 
 // GormClickRepository est l'implémentation de l'interface ClickRepository utilisant GORM.
@@ -32,15 +37,92 @@ func (r *GormClickRepository) CreateClick(click *models.Click) error {
 	return result.Error
 }
 
+// CreateClicks insère un lot de clics en une seule requête (mode batch des workers de clics).
+func (r *GormClickRepository) CreateClicks(clicks []models.Click) error {
+	return r.db.CreateInBatches(clicks, len(clicks)).Error
+}
+
 // CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
 // Cette méthode est utilisée pour fournir des statistiques pour une URL courte.
+// Le total inclut les clics bruts et l'historique agrégé dans 'click_daily'.
 func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
-	var count int64 // GORM retourne un int64 pour les décomptes
-	// Utiliser GORM pour compter les enregistrements dans la table 'clicks'
-	// où 'LinkID' correspond à l'ID de lien fourni.
-	result := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count)
-	if result.Error != nil {
-		return 0, result.Error
+	return countClicksWithRollup(r.db, linkID)
+}
+
+// RollupClicksBefore agrège les clics antérieurs à 'cutoff' en lignes journalières dans 'click_daily',
+// puis supprime les clics bruts correspondants. Le tout est exécuté dans une transaction : une lecture
+// concurrente voit soit l'état avant, soit l'état après l'agrégation, jamais un mélange des deux.
+// Retourne le nombre de clics bruts agrégés.
+func (r *GormClickRepository) RollupClicksBefore(cutoff time.Time) (int, error) {
+	var rolledUp int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Borner l'agrégation aux clics existants à cet instant : un clic inséré pendant la transaction
+		// (ID auto-incrémenté plus grand) ne doit pas être supprimé sans avoir été compté.
+		var maxID int64
+		if err := tx.Model(&models.Click{}).
+			Select("COALESCE(MAX(id), 0)").
+			Where("timestamp < ?", cutoff).
+			Scan(&maxID).Error; err != nil {
+			return err
+		}
+		if maxID == 0 {
+			return nil
+		}
+
+		var rows []models.ClickDaily
+		day := clickDayExpr(tx)
+		if err := tx.Model(&models.Click{}).
+			Select("link_id, "+day+" AS day, COUNT(*) AS clicks").
+			Where("timestamp < ? AND id <= ?", cutoff, maxID).
+			Group("link_id, " + day).
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		// Ajouter les comptes aux agrégats existants pour le même (lien, jour)
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "link_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"clicks": gorm.Expr("click_daily.clicks + excluded.clicks")}),
+		}).Create(&rows).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("timestamp < ? AND id <= ?", cutoff, maxID).Delete(&models.Click{})
+		if result.Error != nil {
+			return result.Error
+		}
+		rolledUp = int(result.RowsAffected)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rolledUp, nil
+}
+
+// clickDayExpr retourne l'expression SQL du jour UTC (YYYY-MM-DD, comme ClickDaily.Day) d'un clic,
+// selon le moteur de base de données : date() n'existe que sous SQLite avec ce résultat textuel.
+func clickDayExpr(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return `to_char("timestamp" AT TIME ZONE 'UTC', 'YYYY-MM-DD')`
+	}
+	return "date(timestamp)"
+}
+
+// countClicksWithRollup compte les clics d'un lien en additionnant les clics bruts
+// et les agrégats journaliers de 'click_daily'.
+// Les deux décomptes sont faits dans une seule requête pour lire un instantané cohérent :
+// deux requêtes séparées pourraient encadrer une agrégation et compter deux fois les mêmes clics.
+func countClicksWithRollup(db *gorm.DB, linkID uint) (int, error) {
+	var total int64 // GORM retourne un int64 pour les décomptes
+	if err := db.Raw(`SELECT
+			(SELECT COUNT(*) FROM clicks WHERE link_id = ?) +
+			(SELECT COALESCE(SUM(clicks), 0) FROM click_daily WHERE link_id = ?)`,
+		linkID, linkID).Scan(&total).Error; err != nil {
+		return 0, err
 	}
-	return int(count), nil
+	return int(total), nil
 }
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestFileDB ouvre une base SQLite sur fichier (mode WAL) : contrairement à la base en mémoire partagée,
+// elle permet des lectures concurrentes pendant une transaction d'écriture. Les transactions prennent le verrou
+// d'écriture dès leur début (_txlock=immediate) : sinon le compactage, qui lit avant d'écrire, échoue avec
+// "database is locked" si une insertion concurrente est validée entre-temps.
+func newTestFileDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("ouverture de la base de test: %v", err)
+	}
+	if err := conn.AutoMigrate(&models.Link{}, &models.Click{}, &models.ClickDaily{}); err != nil {
+		t.Fatalf("migration de la base de test: %v", err)
+	}
+	sqlDB, _ := conn.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return conn
+}
+
+func TestRollupKeepsTotalsConsistentForConcurrentReads(t *testing.T) {
+	conn := newTestFileDB(t)
+	link := createTestLink(t, NewLinkRepository(conn), "abc123")
+	clickRepo := NewClickRepository(conn)
+
+	// 300 clics anciens répartis sur 30 jours, puis 20 clics récents qui ne sont pas agrégés
+	const oldClicks, recentClicks = 300, 20
+	start := time.Now().UTC().AddDate(0, 0, -60)
+	var clicks []models.Click
+	for i := 0; i < oldClicks; i++ {
+		clicks = append(clicks, models.Click{LinkID: link.ID, Timestamp: start.Add(time.Duration(i%30) * 24 * time.Hour)})
+	}
+	for i := 0; i < recentClicks; i++ {
+		clicks = append(clicks, models.Click{LinkID: link.ID, Timestamp: time.Now().UTC()})
+	}
+	if err := clickRepo.CreateClicks(clicks); err != nil {
+		t.Fatalf("création des clics: %v", err)
+	}
+
+	// Un lecteur relit le total pendant qu'un écrivain ajoute des clics récents et que le compactage s'exécute :
+	// le total ne doit jamais baisser ni compter deux fois des clics agrégés.
+	const insertedClicks = 50
+	done := make(chan struct{})
+	var reader, writer sync.WaitGroup
+	var readErr, writeErr error
+	reader.Add(1)
+	go func() {
+		defer reader.Done()
+		previous := oldClicks + recentClicks
+		for {
+			total, err := clickRepo.CountClicksByLinkID(link.ID)
+			if err != nil {
+				readErr = err
+				return
+			}
+			if total < previous || total > oldClicks+recentClicks+insertedClicks {
+				readErr = fmt.Errorf("total %d après %d (bornes %d..%d)", total, previous, oldClicks+recentClicks, oldClicks+recentClicks+insertedClicks)
+				return
+			}
+			previous = total
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}()
+	writer.Add(1)
+	go func() {
+		defer writer.Done()
+		for i := 0; i < insertedClicks; i++ {
+			if err := clickRepo.CreateClick(&models.Click{LinkID: link.ID, Timestamp: time.Now().UTC()}); err != nil {
+				writeErr = err
+				return
+			}
+		}
+	}()
+
+	rolledUp, err := clickRepo.RollupClicksBefore(time.Now().UTC().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("erreur de compactage: %v", err)
+	}
+	if rolledUp != oldClicks {
+		t.Errorf("clics agrégés = %d, attendu %d", rolledUp, oldClicks)
+	}
+
+	// Laisser l'écrivain terminer avant d'arrêter le lecteur
+	writer.Wait()
+	close(done)
+	reader.Wait()
+
+	if writeErr != nil {
+		t.Fatalf("insertion concurrente: %v", writeErr)
+	}
+	if readErr != nil {
+		t.Fatalf("lecture concurrente: %v", readErr)
+	}
+
+	total, err := clickRepo.CountClicksByLinkID(link.ID)
+	if err != nil || total != oldClicks+recentClicks+insertedClicks {
+		t.Fatalf("total après compactage = %d (erreur %v), attendu %d", total, err, oldClicks+recentClicks+insertedClicks)
+	}
+}
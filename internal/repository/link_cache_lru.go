@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// LRULinkCache est un LinkCache en mémoire, propre à l'instance, limité à maxEntries liens.
+// Au-delà, le lien le moins récemment lu est évincé. Les liens sont copiés à l'entrée et à la sortie
+// pour qu'une modification par l'appelant n'altère pas le cache.
+// Une entrée expirée est conservée encore staleTTL pour GetStale (0 = retirée dès son expiration).
+type LRULinkCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	staleTTL   time.Duration
+	order      *list.List               // Du plus récemment au moins récemment utilisé
+	entries    map[string]*list.Element // Code court -> élément de 'order' (valeur *lruLinkEntry)
+}
+
+// lruLinkEntry est un lien en cache, sa date d'expiration et la fin de sa conservation pour GetStale.
+type lruLinkEntry struct {
+	link       models.Link
+	expiresAt  time.Time
+	staleUntil time.Time
+}
+
+// NewLRULinkCache crée un LRULinkCache d'au plus maxEntries liens, qui conserve les entrées expirées encore staleTTL.
+func NewLRULinkCache(maxEntries int, staleTTL time.Duration) *LRULinkCache {
+	return &LRULinkCache{
+		maxEntries: maxEntries,
+		staleTTL:   staleTTL,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get implémente LinkCache. Une entrée expirée est traitée comme absente, et retirée une fois sa conservation écoulée.
+func (c *LRULinkCache) Get(shortCode string) (*models.Link, error) {
+	return c.get(shortCode, false)
+}
+
+// GetStale implémente LinkCache : comme Get, mais une entrée expirée est retournée tant qu'elle est conservée.
+func (c *LRULinkCache) GetStale(shortCode string) (*models.Link, error) {
+	return c.get(shortCode, true)
+}
+
+// get lit une entrée, expirée comprise si 'stale'.
+func (c *LRULinkCache) get(shortCode string, stale bool) (*models.Link, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[shortCode]
+	if !ok {
+		return nil, errLinkCacheMiss
+	}
+	entry := element.Value.(*lruLinkEntry)
+	now := time.Now()
+	if now.After(entry.staleUntil) {
+		c.remove(element)
+		return nil, errLinkCacheMiss
+	}
+	if !stale && now.After(entry.expiresAt) {
+		return nil, errLinkCacheMiss
+	}
+	c.order.MoveToFront(element)
+	link := entry.link
+	return &link, nil
+}
+
+// Set implémente LinkCache.
+func (c *LRULinkCache) Set(link *models.Link, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	entry := &lruLinkEntry{link: *link, expiresAt: expiresAt, staleUntil: expiresAt.Add(c.staleTTL)}
+	if element, ok := c.entries[link.ShortCode]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+	c.entries[link.ShortCode] = c.order.PushFront(entry)
+	if c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Delete implémente LinkCache.
+func (c *LRULinkCache) Delete(shortCode string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[shortCode]; ok {
+		c.remove(element)
+	}
+	return nil
+}
+
+// remove retire un élément du cache. L'appelant doit détenir le mutex.
+func (c *LRULinkCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruLinkEntry).link.ShortCode)
+}
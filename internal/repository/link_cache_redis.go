@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisLinkCache est un LinkCache stocké dans Redis, partagé entre toutes les répliques.
+// Les liens y sont sérialisés en JSON sous la clé "link:<code>", réduits aux champs de la redirection (cachedLink).
+// Une clé vit sa durée de vie plus staleTTL : au-delà de la durée de vie, seul GetStale la sert.
+type RedisLinkCache struct {
+	client   *redis.Client
+	timeout  time.Duration // Délai maximal d'un appel à Redis
+	staleTTL time.Duration // Conservation des entrées expirées pour GetStale (0 = aucune)
+}
+
+// NewRedisLinkCache crée un RedisLinkCache sur le client Redis donné, dont la fermeture reste à la charge de l'appelant.
+// Les entrées expirées sont conservées encore staleTTL pour GetStale.
+func NewRedisLinkCache(client *redis.Client, staleTTL time.Duration) *RedisLinkCache {
+	return &RedisLinkCache{
+		client:   client,
+		timeout:  time.Second,
+		staleTTL: staleTTL,
+	}
+}
+
+// cachedLink est la forme d'un lien stockée dans Redis : seuls les champs utiles à la redirection y figurent.
+// Le hash du mot de passe, l'IP du créateur et le propriétaire ne quittent jamais la base ; un lien protégé
+// est signalé par Protected, et son hash est relu en base à la soumission du mot de passe.
+type cachedLink struct {
+	ID              uint       `json:"id"`
+	ShortCode       string     `json:"short_code"`
+	LongURL         string     `json:"long_url"`
+	IsActive        bool       `json:"is_active"`
+	InactiveReason  string     `json:"inactive_reason,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	TrackClicks     *bool      `json:"track_clicks,omitempty"`
+	MaxClicks       *int       `json:"max_clicks,omitempty"`
+	RedirectCount   int        `json:"redirect_count"`
+	CanonicalLinkID *uint      `json:"canonical_link_id,omitempty"`
+	SkipPreview     bool       `json:"skip_preview,omitempty"`
+	Protected       bool       `json:"protected,omitempty"`
+	FreshUntil      time.Time  `json:"fresh_until"` // Fin de la durée de vie de l'entrée ; au-delà, seul GetStale la sert
+}
+
+// newCachedLink extrait d'un lien les champs mis en cache.
+func newCachedLink(link *models.Link) cachedLink {
+	return cachedLink{
+		ID:              link.ID,
+		ShortCode:       link.ShortCode,
+		LongURL:         link.LongURL,
+		IsActive:        link.IsActive,
+		InactiveReason:  link.InactiveReason,
+		ExpiresAt:       link.ExpiresAt,
+		TrackClicks:     link.TrackClicks,
+		MaxClicks:       link.MaxClicks,
+		RedirectCount:   link.RedirectCount,
+		CanonicalLinkID: link.CanonicalLinkID,
+		SkipPreview:     link.SkipPreview,
+		Protected:       link.IsProtected(),
+	}
+}
+
+// toLink reconstruit le lien partiel servi à la redirection.
+func (e cachedLink) toLink() *models.Link {
+	return &models.Link{
+		ID:                e.ID,
+		ShortCode:         e.ShortCode,
+		LongURL:           e.LongURL,
+		IsActive:          e.IsActive,
+		InactiveReason:    e.InactiveReason,
+		ExpiresAt:         e.ExpiresAt,
+		TrackClicks:       e.TrackClicks,
+		MaxClicks:         e.MaxClicks,
+		RedirectCount:     e.RedirectCount,
+		CanonicalLinkID:   e.CanonicalLinkID,
+		SkipPreview:       e.SkipPreview,
+		PasswordProtected: e.Protected,
+	}
+}
+
+// linkCacheKey retourne la clé Redis d'un code court.
+func linkCacheKey(shortCode string) string {
+	return "link:" + shortCode
+}
+
+// Get implémente LinkCache. Une entrée illisible ou expirée est traitée comme absente.
+func (c *RedisLinkCache) Get(shortCode string) (*models.Link, error) {
+	return c.get(shortCode, false)
+}
+
+// GetStale implémente LinkCache : comme Get, mais une entrée expirée est retournée tant que sa clé existe.
+func (c *RedisLinkCache) GetStale(shortCode string) (*models.Link, error) {
+	return c.get(shortCode, true)
+}
+
+// get lit une entrée, expirée comprise si 'stale'.
+func (c *RedisLinkCache) get(shortCode string, stale bool) (*models.Link, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	data, err := c.client.Get(ctx, linkCacheKey(shortCode)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errLinkCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry cachedLink
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, errLinkCacheMiss
+	}
+	if !stale && time.Now().After(entry.FreshUntil) {
+		return nil, errLinkCacheMiss
+	}
+	return entry.toLink(), nil
+}
+
+// Set implémente LinkCache.
+func (c *RedisLinkCache) Set(link *models.Link, ttl time.Duration) error {
+	entry := newCachedLink(link)
+	entry.FreshUntil = time.Now().Add(ttl)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	return c.client.Set(ctx, linkCacheKey(link.ShortCode), data, ttl+c.staleTTL).Err()
+}
+
+// Delete implémente LinkCache.
+func (c *RedisLinkCache) Delete(shortCode string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	return c.client.Del(ctx, linkCacheKey(shortCode)).Err()
+}
@@ -1,17 +1,65 @@
 package repository
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // LinkRepository est une interface qui définit les méthodes d'accès aux données
 // pour les opérations CRUD sur les liens.
 type LinkRepository interface {
 	CreateLink(link *models.Link) error
+	CreateLinkWithDerivedCode(link *models.Link, derive func(id uint, taken func(code string) (bool, error)) (string, error)) error
 	GetLinkByShortCode(shortCode string) (*models.Link, error)
+	GetLinkByID(linkID uint) (*models.Link, error)
+	GetLinkByLongURL(longURLs []string, ownerID string) (*models.Link, error)
+	GetExpiredLinks(before time.Time, afterID uint, limit int, skipPurged bool) ([]models.Link, error)
 	GetAllLinks() ([]models.Link, error)
 	CountClicksByLinkID(linkID uint) (int, error)
+	GetClickVersion(linkID uint) (ClickVersion, error)
+	CountClicksByDay(linkID uint, from, to time.Time) (map[string]int, error)
+	LinkExists(linkID uint) (bool, error)
+	ExistingLinkIDs(linkIDs []uint) (map[uint]bool, error)
+	CountLinksByCreatorIPSince(creatorIP string, since time.Time) (int, error)
+	GetTopLinks(limit int, since time.Time) ([]LinkClickCount, error)
+	CountClicksGroupedBy(linkID uint, column string) (map[string]int, error)
+	CountUniqueVisitorsByLinkID(linkID uint) (int, error)
+	GetLinksByCreatorIP(creatorIP string) ([]LinkClickCount, error)
+	DeactivateLink(linkID uint, reason string) error
+	UpdateLinkActive(linkID uint, active bool, reason string) error
+	UpdateLinkCheck(linkID uint, status int, checkedAt time.Time) error
+	IncrementRedirectCount(linkID uint) (int, bool, error)
+	DeleteLink(shortCode string) error
+	UpdateLink(link *models.Link) error
+	CountLinksBySource() (map[string]int, error)
+	ListLinks(offset, limit int) ([]models.Link, int64, error)
+	ListLinksByOwner(ownerID string, offset, limit int) ([]models.Link, int64, error)
+	GetLinksWithClickCountsAfter(afterID uint, createdSince time.Time, limit int) ([]LinkClickCount, error)
+	GetAllLinksWithClickCounts(orderByClicks bool, limit int) ([]LinkClickCount, error)
+	GetOwnerStats(ownerID string, now time.Time, topLimit int) (*OwnerStats, error)
+}
+
+// LinkClickCount associe un lien à son nombre de clics, tel que retourné par les requêtes agrégées.
+type LinkClickCount struct {
+	models.Link
+	ClickCount int
+}
+
+// ClickVersion résume l'état des clics d'un lien : elle change dès qu'un clic est enregistré ou compacté.
+// Elle sert de validateur (ETag) aux statistiques sans calculer les répartitions.
+type ClickVersion struct {
+	RawClicks   int  // Clics bruts de la table 'clicks'
+	LastClickID uint // Plus grand ID de clic brut (0 sans clic brut)
+	RolledUp    int  // Clics agrégés dans 'click_daily'
+}
+
+// Total retourne le nombre total de clics, historique agrégé compris (comme CountClicksByLinkID).
+func (v ClickVersion) Total() int {
+	return v.RawClicks + v.RolledUp
 }
 
 // GormLinkRepository est l'implémentation de LinkRepository utilisant GORM.
@@ -32,6 +80,39 @@ func (r *GormLinkRepository) CreateLink(link *models.Link) error {
 	return result.Error
 }
 
+// pendingShortCodePrefix préfixe le code provisoire d'un lien inséré avant le calcul de son code définitif.
+// '~' n'appartient ni au jeu de caractères des codes générés ni à celui des alias : aucune collision possible.
+const pendingShortCodePrefix = "~pending-"
+
+// CreateLinkWithDerivedCode insère le lien avec un code provisoire, puis lui attribue le code calculé par 'derive'
+// à partir de son ID, le tout dans une transaction. 'taken' permet à 'derive' de vérifier, dans la même transaction,
+// qu'un code n'est pas déjà utilisé. Une erreur de 'derive' annule l'insertion.
+func (r *GormLinkRepository) CreateLinkWithDerivedCode(link *models.Link,
+	derive func(id uint, taken func(code string) (bool, error)) (string, error)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		link.ShortCode = fmt.Sprintf("%s%d", pendingShortCodePrefix, time.Now().UnixNano())
+		if err := tx.Create(link).Error; err != nil {
+			return err
+		}
+
+		taken := func(code string) (bool, error) {
+			var count int64
+			err := tx.Model(&models.Link{}).Where("short_code = ?", code).Count(&count).Error
+			return count > 0, err
+		}
+		code, err := derive(link.ID, taken)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(link).Update("short_code", code).Error; err != nil {
+			return err
+		}
+		link.ShortCode = code
+		return nil
+	})
+}
+
 // GetLinkByShortCode récupère un lien de la base de données en utilisant son shortCode.
 // Il renvoie gorm.ErrRecordNotFound si aucun lien n'est trouvé avec ce shortCode.
 func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
@@ -45,6 +126,57 @@ func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link,
 	return &link, nil
 }
 
+// GetLinkByLongURL récupère le lien généré le plus récent vers l'une des URLs 'longURLs' (variantes équivalentes
+// d'une même destination) qui peut être réutilisé tel quel :
+// actif, non expiré, sans mot de passe ni limite de clics, avec suivi des clics, et qui n'est ni un alias personnalisé
+// ni un alias supplémentaire. Seuls les liens de 'ownerID' sont considérés (vide : liens sans propriétaire,
+// y compris ceux créés avant l'ajout de la colonne). Renvoie gorm.ErrRecordNotFound si aucun lien ne convient.
+func (r *GormLinkRepository) GetLinkByLongURL(longURLs []string, ownerID string) (*models.Link, error) {
+	owner := r.db.Where("owner_id = ?", ownerID)
+	if ownerID == "" {
+		owner = owner.Or("owner_id IS NULL")
+	}
+
+	var link models.Link
+	result := r.db.
+		Where("long_url IN ? AND is_active = ? AND is_custom = ?", longURLs, true, false).
+		Where(owner).
+		Where("(expires_at IS NULL OR expires_at > ?)", time.Now()).
+		Where("(password_hash IS NULL OR password_hash = '') AND max_clicks IS NULL AND canonical_link_id IS NULL").
+		Where("(track_clicks IS NULL OR track_clicks = ?)", true).
+		Order("id DESC").
+		First(&link)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &link, nil
+}
+
+// GetExpiredLinks récupère au plus 'limit' liens dont la date d'expiration est antérieure à 'before',
+// par identifiant croissant à partir de 'afterID' exclu (0 pour commencer) : un lot de la purge.
+// Avec skipPurged, les liens déjà désactivés pour expiration (purge "soft" précédente) sont ignorés.
+func (r *GormLinkRepository) GetExpiredLinks(before time.Time, afterID uint, limit int, skipPurged bool) ([]models.Link, error) {
+	var links []models.Link
+	query := r.db.Where("expires_at IS NOT NULL AND expires_at < ? AND id > ?", before, afterID)
+	if skipPurged {
+		query = query.Where("inactive_reason IS NULL OR inactive_reason <> ?", models.InactiveReasonExpired)
+	}
+	result := query.Order("id").Limit(limit).Find(&links)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return links, nil
+}
+
+// GetLinkByID récupère un lien par son ID (ex: lien canonique d'un alias).
+func (r *GormLinkRepository) GetLinkByID(linkID uint) (*models.Link, error) {
+	var link models.Link
+	if err := r.db.First(&link, linkID).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
 // GetAllLinks récupère tous les liens de la base de données.
 // Cette méthode est utilisée par le moniteur d'URLs.
 func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
@@ -57,14 +189,443 @@ func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	return links, nil
 }
 
-// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
+// ListLinks retourne une page de liens, du plus ancien au plus récent, ainsi que le nombre total de liens.
+// Seule la page demandée est chargée en mémoire.
+func (r *GormLinkRepository) ListLinks(offset, limit int) ([]models.Link, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Link{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var links []models.Link
+	if err := r.db.Order("id").Offset(offset).Limit(limit).Find(&links).Error; err != nil {
+		return nil, 0, err
+	}
+	return links, total, nil
+}
+
+// ListLinksByOwner retourne une page des liens d'un propriétaire, du plus ancien au plus récent,
+// ainsi que le nombre total de ses liens.
+func (r *GormLinkRepository) ListLinksByOwner(ownerID string, offset, limit int) ([]models.Link, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Link{}).Where("owner_id = ?", ownerID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var links []models.Link
+	if err := r.db.Where("owner_id = ?", ownerID).Order("id").Offset(offset).Limit(limit).Find(&links).Error; err != nil {
+		return nil, 0, err
+	}
+	return links, total, nil
+}
+
+// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné,
+// historique agrégé ('click_daily') compris.
 func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
-	var count int64 // GORM retourne un int64 pour les comptes
-	// Utiliser GORM pour compter les enregistrements dans la table 'clicks'
-	// où 'LinkID' correspond à l'ID du lien donné.
-	result := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count)
+	return countClicksWithRollup(r.db, linkID)
+}
+
+// GetClickVersion lit en une seule requête l'état des clics d'un lien (voir ClickVersion).
+// Un nouveau clic augmente le nombre et le plus grand ID des clics bruts ; le compactage déplace des clics
+// bruts vers 'click_daily' : les deux cas changent la version, même si le total reste identique.
+func (r *GormLinkRepository) GetClickVersion(linkID uint) (ClickVersion, error) {
+	var version ClickVersion
+	err := r.db.Raw(`SELECT
+			(SELECT COUNT(*) FROM clicks WHERE link_id = ?) AS raw_clicks,
+			(SELECT COALESCE(MAX(id), 0) FROM clicks WHERE link_id = ?) AS last_click_id,
+			(SELECT COALESCE(SUM(clicks), 0) FROM click_daily WHERE link_id = ?) AS rolled_up`,
+		linkID, linkID, linkID).Scan(&version).Error
+	return version, err
+}
+
+// CountClicksByDay compte les clics d'un lien par jour (YYYY-MM-DD, UTC) entre 'from' et 'to' inclus,
+// en additionnant les clics bruts et l'historique agrégé de 'click_daily'. Les jours sans clic sont absents.
+func (r *GormLinkRepository) CountClicksByDay(linkID uint, from, to time.Time) (map[string]int, error) {
+	var rows []struct {
+		Day    string
+		Clicks int
+	}
+	day := clickDayExpr(r.db)
+	result := r.db.Raw(`SELECT day, SUM(n) AS clicks FROM (
+		SELECT `+day+` AS day, COUNT(*) AS n FROM clicks
+		WHERE link_id = ? AND timestamp >= ? AND timestamp <= ? GROUP BY `+day+`
+		UNION ALL
+		SELECT day, SUM(clicks) AS n FROM click_daily
+		WHERE link_id = ? AND day >= ? AND day <= ? GROUP BY day
+	) AS merged GROUP BY day`,
+		linkID, from, to, linkID, from.UTC().Format("2006-01-02"), to.UTC().Format("2006-01-02")).Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Day] = row.Clicks
+	}
+	return counts, nil
+}
+
+// LinkExists vérifie qu'un lien existe encore pour un ID donné.
+// Utilisée par les workers de clics pour ne pas enregistrer de clics orphelins.
+func (r *GormLinkRepository) LinkExists(linkID uint) (bool, error) {
+	var count int64
+	result := r.db.Model(&models.Link{}).Where("id = ?", linkID).Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
+// ExistingLinkIDs retourne, parmi 'linkIDs', ceux qui correspondent encore à un lien, en une seule requête.
+// Utilisée par les workers de clics par lots pour écarter les clics orphelins d'un lot entier.
+func (r *GormLinkRepository) ExistingLinkIDs(linkIDs []uint) (map[uint]bool, error) {
+	existing := make(map[uint]bool, len(linkIDs))
+	if len(linkIDs) == 0 {
+		return existing, nil
+	}
+	var ids []uint
+	if err := r.db.Model(&models.Link{}).Where("id IN ?", linkIDs).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+// UpdateLink enregistre la destination modifiée d'un lien existant et de ses alias,
+// avec l'état de vérification qui en dépend (activation, raison de désactivation, dernière vérification).
+func (r *GormLinkRepository) UpdateLink(link *models.Link) error {
+	return r.db.Model(&models.Link{}).Where("id = ? OR canonical_link_id = ?", link.ID, link.ID).
+		Updates(map[string]interface{}{
+			"long_url":          link.LongURL,
+			"is_active":         link.IsActive,
+			"inactive_reason":   link.InactiveReason,
+			"last_check_status": link.LastCheckStatus,
+			"last_checked_at":   link.LastCheckedAt,
+		}).Error
+}
+
+// DeleteLink supprime un lien ainsi que ses alias et ses clics bruts et agrégés, dans une même transaction.
+// Il renvoie gorm.ErrRecordNotFound si aucun lien n'existe avec ce shortCode.
+func (r *GormLinkRepository) DeleteLink(shortCode string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var link models.Link
+		if err := tx.Where("short_code = ?", shortCode).First(&link).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("link_id = ?", link.ID).Delete(&models.Click{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("link_id = ?", link.ID).Delete(&models.ClickDaily{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("canonical_link_id = ?", link.ID).Delete(&models.Link{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&link).Error
+	})
+}
+
+// DeactivateLink désactive un lien et enregistre la raison de la désactivation.
+func (r *GormLinkRepository) DeactivateLink(linkID uint, reason string) error {
+	return r.db.Model(&models.Link{}).Where("id = ?", linkID).
+		Updates(map[string]interface{}{"is_active": false, "inactive_reason": reason}).Error
+}
+
+// UpdateLinkActive active ou désactive un lien. La raison est enregistrée pour une désactivation
+// et effacée pour une réactivation.
+func (r *GormLinkRepository) UpdateLinkActive(linkID uint, active bool, reason string) error {
+	if active {
+		reason = ""
+	}
+	return r.db.Model(&models.Link{}).Where("id = ?", linkID).
+		Updates(map[string]interface{}{"is_active": active, "inactive_reason": reason}).Error
+}
+
+// UpdateLinkCheck enregistre le résultat de la dernière vérification d'un lien par le moniteur.
+func (r *GormLinkRepository) UpdateLinkCheck(linkID uint, status int, checkedAt time.Time) error {
+	return r.db.Model(&models.Link{}).Where("id = ?", linkID).
+		Updates(map[string]interface{}{"last_check_status": status, "last_checked_at": checkedAt}).Error
+}
+
+// IncrementRedirectCount incrémente le compteur de redirections d'un lien en une seule requête
+// et retourne sa nouvelle valeur. L'incrément est conditionné à la limite max_clicks dans la même requête :
+// deux redirections simultanées ne peuvent pas consommer le dernier clic. Si la limite est atteinte,
+// le compteur n'est pas modifié et la méthode retourne sa valeur courante et false.
+// Retourne gorm.ErrRecordNotFound si le lien n'existe plus.
+func (r *GormLinkRepository) IncrementRedirectCount(linkID uint) (int, bool, error) {
+	var link models.Link
+	result := r.db.Model(&link).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "redirect_count"}}}).
+		Where("id = ? AND (max_clicks IS NULL OR redirect_count < max_clicks)", linkID).
+		UpdateColumn("redirect_count", gorm.Expr("redirect_count + 1"))
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Aucune ligne modifiée : lien supprimé ou limite atteinte
+		if err := r.db.Select("redirect_count").First(&link, linkID).Error; err != nil {
+			return 0, false, err
+		}
+		return link.RedirectCount, false, nil
+	}
+	return link.RedirectCount, true, nil
+}
+
+// BackfillRedirectCounts initialise le compteur de redirections des liens existants à partir des clics enregistrés
+// (clics bruts et agrégats journaliers). Appelé par la migration qui ajoute la colonne redirect_count.
+func (r *GormLinkRepository) BackfillRedirectCounts() error {
+	return r.db.Exec(`UPDATE links SET redirect_count =
+		(SELECT COUNT(*) FROM clicks WHERE clicks.link_id = links.id) +
+		(SELECT COALESCE(SUM(clicks), 0) FROM click_daily WHERE click_daily.link_id = links.id)`).Error
+}
+
+// CountLinksByCreatorIPSince compte les liens créés depuis une IP donnée après 'since'.
+// Cette méthode est utilisée pour appliquer le quota de création par IP.
+func (r *GormLinkRepository) CountLinksByCreatorIPSince(creatorIP string, since time.Time) (int, error) {
+	var count int64
+	result := r.db.Model(&models.Link{}).
+		Where("creator_ip = ? AND created_at >= ?", creatorIP, since).
+		Count(&count)
 	if result.Error != nil {
 		return 0, result.Error
 	}
 	return int(count), nil
 }
+
+// GetTopLinks retourne les 'limit' liens les plus cliqués depuis 'since', triés par nombre de clics décroissant.
+// Le comptage est effectué en base par une seule requête (voir linksWithClickCounts) ;
+// les liens sans clic complètent la liste avec un compte de 0.
+// Une valeur zéro pour 'since' compte tous les clics.
+func (r *GormLinkRepository) GetTopLinks(limit int, since time.Time) ([]LinkClickCount, error) {
+	var results []LinkClickCount
+	result := r.linksWithClickCounts(since).
+		Order("click_count DESC, links.id").
+		Limit(limit).
+		Scan(&results)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return results, nil
+}
+
+// GetAllLinksWithClickCounts retourne les liens avec leur nombre total de clics, les plus cliqués d'abord
+// si 'orderByClicks', sinon les plus récents d'abord. 'limit' <= 0 retourne tous les liens.
+func (r *GormLinkRepository) GetAllLinksWithClickCounts(orderByClicks bool, limit int) ([]LinkClickCount, error) {
+	var results []LinkClickCount
+	query := r.linksWithClickCounts(time.Time{})
+	if orderByClicks {
+		query = query.Order("click_count DESC, links.id")
+	} else {
+		query = query.Order("links.created_at DESC, links.id DESC")
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if result := query.Scan(&results); result.Error != nil {
+		return nil, result.Error
+	}
+	return results, nil
+}
+
+// GetLinksByCreatorIP retourne tous les liens créés depuis une IP avec leur nombre total de clics,
+// du plus récent au plus ancien. La recherche s'appuie sur l'index de 'creator_ip'.
+func (r *GormLinkRepository) GetLinksByCreatorIP(creatorIP string) ([]LinkClickCount, error) {
+	var results []LinkClickCount
+	result := r.linksWithClickCounts(time.Time{}).
+		Where("links.creator_ip = ?", creatorIP).
+		Order("links.created_at DESC").
+		Scan(&results)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return results, nil
+}
+
+// GetLinksWithClickCountsAfter retourne au plus 'limit' liens d'ID supérieur à 'afterID', créés depuis
+// 'createdSince', avec leur nombre total de clics, triés par ID. La pagination par curseur (dernier ID lu)
+// garde un coût constant par page, contrairement à un OFFSET sur une grande table.
+func (r *GormLinkRepository) GetLinksWithClickCountsAfter(afterID uint, createdSince time.Time, limit int) ([]LinkClickCount, error) {
+	var results []LinkClickCount
+	// Les IDs de la page, pour ne compter que les clics de ses liens et non de toute la table
+	pageIDs := r.db.Model(&models.Link{}).Select("id").
+		Where("id > ? AND created_at >= ?", afterID, createdSince).
+		Order("id").
+		Limit(limit)
+	result := r.linksWithClickCountsFor(time.Time{}, pageIDs).
+		Where("links.id IN (?)", pageIDs).
+		Order("links.id").
+		Scan(&results)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return results, nil
+}
+
+// OwnerStats résume les liens d'un propriétaire (GET /api/v1/me/stats). Les alias ne sont pas comptés :
+// leurs clics le sont sur leur lien canonique.
+type OwnerStats struct {
+	TotalLinks   int
+	ActiveLinks  int // Actifs et ni expirés ni épuisés
+	ExpiredLinks int // Date d'expiration passée, retirés par la purge ou limite de clics atteinte
+	TotalClicks  int
+	TopLinks     []LinkClickCount // Les plus cliqués d'abord
+}
+
+// ownerLinkExpired est la condition SQL d'un lien expiré pour GetOwnerStats ; le paramètre est l'instant de référence.
+const ownerLinkExpired = `((expires_at IS NOT NULL AND expires_at <= ?) OR inactive_reason = '` + models.InactiveReasonExpired +
+	`' OR (max_clicks IS NOT NULL AND redirect_count >= max_clicks))`
+
+// GetOwnerStats calcule les statistiques des liens de 'ownerID' en trois requêtes groupées, quel que soit
+// le nombre de liens : les compteurs de liens, le total des clics et les 'topLimit' liens les plus cliqués.
+// Un propriétaire sans lien obtient des statistiques à zéro.
+func (r *GormLinkRepository) GetOwnerStats(ownerID string, now time.Time, topLimit int) (*OwnerStats, error) {
+	owned := func() *gorm.DB {
+		return r.db.Model(&models.Link{}).Where("owner_id = ? AND canonical_link_id IS NULL", ownerID)
+	}
+
+	var counts struct {
+		TotalLinks   int
+		ActiveLinks  int
+		ExpiredLinks int
+	}
+	if err := owned().Select("COUNT(*) AS total_links, "+
+		"COALESCE(SUM(CASE WHEN "+ownerLinkExpired+" THEN 1 ELSE 0 END), 0) AS expired_links, "+
+		"COALESCE(SUM(CASE WHEN is_active = ? AND NOT "+ownerLinkExpired+" THEN 1 ELSE 0 END), 0) AS active_links",
+		now, true, now).Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	stats := &OwnerStats{TotalLinks: counts.TotalLinks, ActiveLinks: counts.ActiveLinks, ExpiredLinks: counts.ExpiredLinks}
+	linkIDs := owned().Select("id")
+	if err := r.db.Raw("SELECT COALESCE(SUM(click_count), 0) FROM (?) AS counts",
+		r.clickCountsSince(time.Time{}, linkIDs)).Scan(&stats.TotalClicks).Error; err != nil {
+		return nil, err
+	}
+	if err := r.linksWithClickCountsFor(time.Time{}, linkIDs).
+		Where("links.owner_id = ? AND links.canonical_link_id IS NULL", ownerID).
+		Order("click_count DESC, links.id").
+		Limit(topLimit).
+		Scan(&stats.TopLinks).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// linksWithClickCounts construit la requête de base de toutes les listes de liens avec leur nombre de clics.
+// Les comptes sont joints en une seule requête (pas de CountClicksByLinkID par ligne) et la jointure
+// externe conserve les liens sans clic avec un compte de 0.
+func (r *GormLinkRepository) linksWithClickCounts(since time.Time) *gorm.DB {
+	return r.linksWithClickCountsFor(since, nil)
+}
+
+// linksWithClickCountsFor est linksWithClickCounts avec des comptes limités aux liens dont l'ID est retourné
+// par la sous-requête 'linkIDs' (nil pour tous les liens) : une page ne fait alors pas agréger toute la table des clics.
+func (r *GormLinkRepository) linksWithClickCountsFor(since time.Time, linkIDs *gorm.DB) *gorm.DB {
+	return r.db.Model(&models.Link{}).
+		Select("links.*, COALESCE(counts.click_count, 0) AS click_count").
+		Joins("LEFT JOIN (?) AS counts ON counts.link_id = links.id", r.clickCountsSince(since, linkIDs))
+}
+
+// clickCountsSince construit la sous-requête (link_id, click_count) qui additionne les clics bruts
+// depuis 'since' et l'historique agrégé de 'click_daily' (à la granularité du jour).
+// Si 'linkIDs' n'est pas nil, seuls les clics des liens qu'elle retourne sont agrégés.
+func (r *GormLinkRepository) clickCountsSince(since time.Time, linkIDs *gorm.DB) *gorm.DB {
+	if linkIDs != nil {
+		return r.db.Raw(`SELECT link_id, SUM(n) AS click_count FROM (
+		SELECT link_id, COUNT(*) AS n FROM clicks WHERE timestamp >= ? AND link_id IN (?) GROUP BY link_id
+		UNION ALL
+		SELECT link_id, SUM(clicks) AS n FROM click_daily WHERE day >= ? AND link_id IN (?) GROUP BY link_id
+	) AS merged GROUP BY link_id`, since, linkIDs, since.UTC().Format("2006-01-02"), linkIDs)
+	}
+	return r.db.Raw(`SELECT link_id, SUM(n) AS click_count FROM (
+		SELECT link_id, COUNT(*) AS n FROM clicks WHERE timestamp >= ? GROUP BY link_id
+		UNION ALL
+		SELECT link_id, SUM(clicks) AS n FROM click_daily WHERE day >= ? GROUP BY link_id
+	) AS merged GROUP BY link_id`, since, since.UTC().Format("2006-01-02"))
+}
+
+// CountLinksBySource compte les liens par chemin de création.
+// Les liens créés avant l'enregistrement de la source sont regroupés sous "unknown".
+func (r *GormLinkRepository) CountLinksBySource() (map[string]int, error) {
+	var rows []struct {
+		Source string
+		Count  int
+	}
+	result := r.db.Model(&models.Link{}).
+		Select("COALESCE(NULLIF(source, ''), 'unknown') AS source, COUNT(*) AS count").
+		Group("COALESCE(NULLIF(source, ''), 'unknown')").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Source] = row.Count
+	}
+	return counts, nil
+}
+
+// CountUniqueVisitorsByLinkID compte les adresses IP distinctes ayant cliqué sur un lien.
+// Seuls les clics bruts sont pris en compte : l'historique agrégé dans 'click_daily' ne conserve pas les IPs.
+func (r *GormLinkRepository) CountUniqueVisitorsByLinkID(linkID uint) (int, error) {
+	var count int64
+	result := r.db.Model(&models.Click{}).
+		Where("link_id = ? AND ip_address <> ''", linkID).
+		Distinct("ip_address").
+		Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(count), nil
+}
+
+// groupableClickColumns sont les colonnes de la table 'clicks' acceptées par CountClicksGroupedBy.
+// Le nom de colonne étant inséré dans la requête SQL, toute autre valeur est refusée.
+var groupableClickColumns = map[string]bool{
+	"served_path": true,
+	"referrer":    true,
+	"country":     true,
+	"browser":     true,
+	"os":          true,
+}
+
+// CountClicksGroupedBy compte les clics d'un lien regroupés par la valeur d'une colonne de la table 'clicks'.
+// 'column' doit faire partie de groupableClickColumns, sinon une erreur est retournée.
+// L'historique agrégé ne conserve pas le détail par colonne : ses clics sont comptés sous models.ClicksRolledUp,
+// pour que la répartition totalise le même nombre de clics que CountClicksByLinkID.
+func (r *GormLinkRepository) CountClicksGroupedBy(linkID uint, column string) (map[string]int, error) {
+	if !groupableClickColumns[column] {
+		return nil, fmt.Errorf("colonne de regroupement des clics non autorisée: %q", column)
+	}
+
+	var rows []struct {
+		Value string
+		Count int
+	}
+	result := r.db.Model(&models.Click{}).
+		Select(column+" AS value, COUNT(*) AS count").
+		Where("link_id = ?", linkID).
+		Group(column).
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var rolledUp int64
+	if err := r.db.Model(&models.ClickDaily{}).Select("COALESCE(SUM(clicks), 0)").
+		Where("link_id = ?", linkID).Scan(&rolledUp).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows)+1)
+	for _, row := range rows {
+		counts[row.Value] = row.Count
+	}
+	if rolledUp > 0 {
+		counts[models.ClicksRolledUp] += int(rolledUp)
+	}
+	return counts, nil
+}
@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB ouvre une base SQLite en mémoire propre au test, migrée.
+func newTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("ouverture de la base de test: %v", err)
+	}
+	if err := conn.AutoMigrate(&models.Link{}, &models.Click{}, &models.ClickDaily{}); err != nil {
+		t.Fatalf("migration de la base de test: %v", err)
+	}
+	sqlDB, _ := conn.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return conn
+}
+
+// createTestLink insère un lien de test et le retourne.
+func createTestLink(t testing.TB, repo *GormLinkRepository, shortCode string) *models.Link {
+	t.Helper()
+	link := &models.Link{ShortCode: shortCode, LongURL: "https://example.com/" + shortCode, CreatedAt: time.Now()}
+	if err := repo.CreateLink(link); err != nil {
+		t.Fatalf("création du lien %s: %v", shortCode, err)
+	}
+	return link
+}
+
+func TestCountClicksGroupedBy(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewLinkRepository(conn)
+	link := createTestLink(t, repo, "abc123")
+
+	for _, country := range []string{"FR", "FR", "DE"} {
+		if err := conn.Create(&models.Click{LinkID: link.ID, Timestamp: time.Now(), Country: country}).Error; err != nil {
+			t.Fatalf("création du clic: %v", err)
+		}
+	}
+
+	counts, err := repo.CountClicksGroupedBy(link.ID, "country")
+	if err != nil {
+		t.Fatalf("erreur inattendue: %v", err)
+	}
+	if counts["FR"] != 2 || counts["DE"] != 1 {
+		t.Errorf("répartition inattendue: %v", counts)
+	}
+	if _, ok := counts[models.ClicksRolledUp]; ok {
+		t.Errorf("répartition sans historique agrégé: entrée %q inattendue: %v", models.ClicksRolledUp, counts)
+	}
+}
+
+func TestCountClicksGroupedByIncludesRolledUpClicks(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewLinkRepository(conn)
+	link := createTestLink(t, repo, "abc123")
+
+	conn.Create(&models.Click{LinkID: link.ID, Timestamp: time.Now(), Browser: "Firefox"})
+	conn.Create(&models.ClickDaily{LinkID: link.ID, Day: "2026-01-01", Clicks: 5})
+	conn.Create(&models.ClickDaily{LinkID: link.ID, Day: "2026-01-02", Clicks: 2})
+
+	counts, err := repo.CountClicksGroupedBy(link.ID, "browser")
+	if err != nil {
+		t.Fatalf("erreur inattendue: %v", err)
+	}
+	if counts["Firefox"] != 1 || counts[models.ClicksRolledUp] != 7 {
+		t.Errorf("répartition = %v, attendu Firefox: 1, %s: 7", counts, models.ClicksRolledUp)
+	}
+
+	// La répartition totalise le même nombre de clics que le décompte global
+	total, err := repo.CountClicksByLinkID(link.ID)
+	if err != nil {
+		t.Fatalf("erreur inattendue: %v", err)
+	}
+	sum := 0
+	for _, n := range counts {
+		sum += n
+	}
+	if sum != total {
+		t.Errorf("somme de la répartition = %d, attendu %d", sum, total)
+	}
+}
+
+func TestCountClicksGroupedByRejectsUnknownColumn(t *testing.T) {
+	repo := NewLinkRepository(newTestDB(t))
+
+	for _, column := range []string{"ip_address", "country; DROP TABLE links", ""} {
+		if _, err := repo.CountClicksGroupedBy(1, column); err == nil {
+			t.Errorf("colonne %q acceptée, attendu une erreur", column)
+		}
+	}
+}
+
+func TestExistingLinkIDs(t *testing.T) {
+	repo := NewLinkRepository(newTestDB(t))
+	a := createTestLink(t, repo, "aaa111")
+	b := createTestLink(t, repo, "bbb222")
+
+	existing, err := repo.ExistingLinkIDs([]uint{a.ID, b.ID, b.ID + 100})
+	if err != nil {
+		t.Fatalf("erreur inattendue: %v", err)
+	}
+	if len(existing) != 2 || !existing[a.ID] || !existing[b.ID] {
+		t.Errorf("IDs existants = %v, attendu {%d, %d}", existing, a.ID, b.ID)
+	}
+}
+
+func TestIncrementRedirectCount(t *testing.T) {
+	repo := NewLinkRepository(newTestDB(t))
+	link := createTestLink(t, repo, "abc123")
+
+	for want := 1; want <= 3; want++ {
+		got, allowed, err := repo.IncrementRedirectCount(link.ID)
+		if err != nil {
+			t.Fatalf("erreur inattendue: %v", err)
+		}
+		if !allowed || got != want {
+			t.Errorf("compteur = %d (autorisé: %v), attendu %d (autorisé)", got, allowed, want)
+		}
+	}
+
+	if _, _, err := repo.IncrementRedirectCount(link.ID + 100); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("lien inexistant: erreur = %v, attendu gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestIncrementRedirectCountEnforcesMaxClicksConcurrently(t *testing.T) {
+	conn := newTestFileDB(t)
+	repo := NewLinkRepository(conn)
+	maxClicks := 5
+	link := &models.Link{ShortCode: "limited", LongURL: "https://example.com/limited", MaxClicks: &maxClicks, CreatedAt: time.Now()}
+	if err := repo.CreateLink(link); err != nil {
+		t.Fatalf("création du lien: %v", err)
+	}
+
+	// 50 redirections simultanées : exactement max_clicks doivent être autorisées
+	const requests = 50
+	var allowedCount atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, allowed, err := repo.IncrementRedirectCount(link.ID)
+			if err != nil {
+				t.Errorf("erreur inattendue: %v", err)
+				return
+			}
+			if allowed {
+				allowedCount.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := int(allowedCount.Load()); got != maxClicks {
+		t.Errorf("redirections autorisées = %d, attendu %d", got, maxClicks)
+	}
+	got, allowed, err := repo.IncrementRedirectCount(link.ID)
+	if err != nil || allowed || got != maxClicks {
+		t.Errorf("après la limite: compteur = %d, autorisé = %v, erreur = %v, attendu %d, false, nil", got, allowed, err, maxClicks)
+	}
+}
+
+func TestBackfillRedirectCounts(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewLinkRepository(conn)
+	link := createTestLink(t, repo, "abc123")
+
+	conn.Create(&models.Click{LinkID: link.ID, Timestamp: time.Now()})
+	conn.Create(&models.Click{LinkID: link.ID, Timestamp: time.Now()})
+	conn.Create(&models.ClickDaily{LinkID: link.ID, Day: "2026-01-01", Clicks: 5})
+
+	if err := repo.BackfillRedirectCounts(); err != nil {
+		t.Fatalf("erreur inattendue: %v", err)
+	}
+	stored, err := repo.GetLinkByID(link.ID)
+	if err != nil {
+		t.Fatalf("erreur inattendue: %v", err)
+	}
+	if stored.RedirectCount != 7 {
+		t.Errorf("redirect_count = %d, attendu 7", stored.RedirectCount)
+	}
+}
+
+// statementCounter est un logger GORM qui compte les requêtes SQL exécutées.
+type statementCounter struct {
+	logger.Interface
+	count int
+}
+
+func (s *statementCounter) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	s.count++
+}
+
+func TestLinkListingsCountClicksInOneQuery(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewLinkRepository(conn)
+	creatorIP := "203.0.113.7"
+
+	clicked := createTestLink(t, repo, "clicked")
+	createTestLink(t, repo, "unclicked")
+	rolledUp := createTestLink(t, repo, "rolledup")
+	conn.Model(&models.Link{}).Where("1 = 1").Update("creator_ip", creatorIP)
+
+	for i := 0; i < 3; i++ {
+		conn.Create(&models.Click{LinkID: clicked.ID, Timestamp: time.Now()})
+	}
+	conn.Create(&models.ClickDaily{LinkID: rolledUp.ID, Day: "2026-01-01", Clicks: 4})
+
+	want := map[string]int{"clicked": 3, "unclicked": 0, "rolledup": 4}
+	listings := map[string]func() ([]LinkClickCount, error){
+		"GetAllLinksWithClickCounts": func() ([]LinkClickCount, error) { return repo.GetAllLinksWithClickCounts(true, 0) },
+		"GetTopLinks":                func() ([]LinkClickCount, error) { return repo.GetTopLinks(10, time.Time{}) },
+		"GetLinksByCreatorIP":        func() ([]LinkClickCount, error) { return repo.GetLinksByCreatorIP(creatorIP) },
+		"GetLinksWithClickCountsAfter": func() ([]LinkClickCount, error) {
+			return repo.GetLinksWithClickCountsAfter(0, time.Time{}, 10)
+		},
+	}
+
+	for name, list := range listings {
+		counter := &statementCounter{Interface: logger.Default.LogMode(logger.Silent)}
+		repo.db = conn.Session(&gorm.Session{Logger: counter})
+
+		links, err := list()
+		if err != nil {
+			t.Fatalf("%s: erreur inattendue: %v", name, err)
+		}
+		if counter.count != 1 {
+			t.Errorf("%s: %d requêtes exécutées, attendu 1", name, counter.count)
+		}
+		if len(links) != len(want) {
+			t.Fatalf("%s: %d liens, attendu %d", name, len(links), len(want))
+		}
+		for _, link := range links {
+			if link.ClickCount != want[link.ShortCode] {
+				t.Errorf("%s: %s a %d clics, attendu %d", name, link.ShortCode, link.ClickCount, want[link.ShortCode])
+			}
+		}
+	}
+}
+
+func TestGetLinksWithClickCountsAfterPages(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewLinkRepository(conn)
+
+	var links []*models.Link
+	for i := 0; i < 5; i++ {
+		link := createTestLink(t, repo, fmt.Sprintf("code%d", i))
+		for j := 0; j < i; j++ {
+			conn.Create(&models.Click{LinkID: link.ID, Timestamp: time.Now()})
+		}
+		links = append(links, link)
+	}
+	conn.Create(&models.ClickDaily{LinkID: links[4].ID, Day: "2026-01-01", Clicks: 10})
+
+	recorder := &sqlRecorder{Interface: logger.Default.LogMode(logger.Silent)}
+	repo.db = conn.Session(&gorm.Session{Logger: recorder})
+
+	first, err := repo.GetLinksWithClickCountsAfter(0, time.Time{}, 2)
+	if err != nil || len(first) != 2 {
+		t.Fatalf("première page: %d liens, erreur %v", len(first), err)
+	}
+	if first[0].ClickCount != 0 || first[1].ClickCount != 1 {
+		t.Errorf("première page: comptes %d, %d, attendu 0, 1", first[0].ClickCount, first[1].ClickCount)
+	}
+	if strings.Count(recorder.last, "link_id IN (SELECT") != 2 {
+		t.Errorf("l'agrégation des clics doit être limitée aux liens de la page: %s", recorder.last)
+	}
+
+	last, err := repo.GetLinksWithClickCountsAfter(first[1].ID, time.Time{}, 10)
+	if err != nil || len(last) != 3 {
+		t.Fatalf("dernière page: %d liens, erreur %v", len(last), err)
+	}
+	if last[2].ShortCode != "code4" || last[2].ClickCount != 14 {
+		t.Errorf("dernière page: %s a %d clics, attendu code4 avec 14", last[2].ShortCode, last[2].ClickCount)
+	}
+}
+
+// sqlRecorder garde la dernière requête SQL exécutée.
+type sqlRecorder struct {
+	logger.Interface
+	last string
+}
+
+func (s *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	s.last, _ = fc()
+}
+
+func TestGetLinkByLongURLFiltersByOwner(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewLinkRepository(conn)
+	longURL := "https://example.com/shared"
+	for _, link := range []*models.Link{
+		{ShortCode: "legacy", LongURL: longURL, IsActive: true},
+		{ShortCode: "ownera", LongURL: longURL, IsActive: true, OwnerID: "a"},
+		{ShortCode: "ownerb", LongURL: longURL, IsActive: true, OwnerID: "b"},
+	} {
+		if err := repo.CreateLink(link); err != nil {
+			t.Fatalf("création du lien %s: %v", link.ShortCode, err)
+		}
+	}
+	// Lien créé avant l'ajout de la colonne : propriétaire NULL
+	conn.Model(&models.Link{}).Where("short_code = ?", "legacy").Update("owner_id", gorm.Expr("NULL"))
+
+	tests := []struct{ ownerID, want string }{{"a", "ownera"}, {"b", "ownerb"}, {"", "legacy"}}
+	for _, tt := range tests {
+		link, err := repo.GetLinkByLongURL([]string{longURL}, tt.ownerID)
+		if err != nil {
+			t.Errorf("propriétaire %q: erreur inattendue: %v", tt.ownerID, err)
+			continue
+		}
+		if link.ShortCode != tt.want {
+			t.Errorf("propriétaire %q: lien %s, attendu %s", tt.ownerID, link.ShortCode, tt.want)
+		}
+	}
+	if _, err := repo.GetLinkByLongURL([]string{longURL}, "c"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("propriétaire sans lien: erreur = %v, attendu ErrRecordNotFound", err)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	apperrors "github.com/axellelanca/urlshortener/internal/errors"
+)
+
+// États possibles du circuit breaker.
+const (
+	BreakerClosed   = "closed"    // Fonctionnement normal, les requêtes passent
+	BreakerOpen     = "open"      // Trop d'erreurs consécutives, les requêtes sont rejetées
+	BreakerHalfOpen = "half-open" // Cooldown écoulé, les requêtes passent pour tester la récupération
+)
+
+// CircuitBreaker protège la base de données pendant un incident sur le chemin de création.
+// Après 'threshold' erreurs consécutives, il s'ouvre pendant 'cooldown' et rejette les créations
+// sans toucher la base. Il passe ensuite en half-open : le premier succès le referme,
+// le premier échec le rouvre pour un nouveau cooldown.
+type CircuitBreaker struct {
+	mu        sync.Mutex    // Mutex pour protéger l'accès concurrent à l'état
+	state     string        // État courant (closed, open, half-open)
+	failures  int           // Nombre d'erreurs consécutives observées
+	threshold int           // Nombre d'erreurs consécutives avant ouverture
+	cooldown  time.Duration // Durée pendant laquelle le circuit reste ouvert
+	openedAt  time.Time     // Moment de la dernière ouverture
+}
+
+// NewCircuitBreaker crée un circuit breaker fermé.
+// threshold: nombre d'erreurs consécutives avant ouverture
+// cooldown: durée d'ouverture avant de tester la récupération
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		state:     BreakerClosed,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow vérifie si une opération peut être tentée.
+// Retourne un *errors.ErrCircuitOpen si le circuit est ouvert.
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != BreakerOpen {
+		return nil
+	}
+
+	elapsed := time.Since(cb.openedAt)
+	if elapsed >= cb.cooldown {
+		// Le cooldown est écoulé, on laisse passer les requêtes pour tester la récupération
+		cb.state = BreakerHalfOpen
+		slog.Info("Passage en half-open, test de la récupération de la base de données", "component", "circuit_breaker")
+		return nil
+	}
+
+	return &apperrors.ErrCircuitOpen{RetryAfter: cb.cooldown - elapsed}
+}
+
+// RecordSuccess signale une opération réussie et referme le circuit.
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != BreakerClosed {
+		slog.Info("Base de données rétablie, circuit refermé", "component", "circuit_breaker")
+	}
+	cb.state = BreakerClosed
+	cb.failures = 0
+}
+
+// RecordFailure signale une erreur de base de données.
+// Ouvre le circuit si le seuil est atteint ou si le test en half-open échoue.
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	if cb.state == BreakerHalfOpen || cb.failures >= cb.threshold {
+		if cb.state != BreakerOpen {
+			slog.Error("Circuit ouvert, créations rejetées", "component", "circuit_breaker",
+				"consecutive_failures", cb.failures, "cooldown", cb.cooldown.String())
+		}
+		cb.state = BreakerOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+// State retourne l'état courant du circuit breaker.
+func (cb *CircuitBreaker) State() string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// ClickDeduplicator détermine si un clic est un doublon d'un clic récent du même visiteur sur le même lien.
+// Un visiteur est identifié par le couple (IP, User-Agent), sous forme de hachés.
+type ClickDeduplicator interface {
+	// IsDuplicate marque le clic comme vu et indique s'il l'avait déjà été dans la fenêtre de déduplication.
+	IsDuplicate(event models.ClickEvent) (bool, error)
+}
+
+// clickDedupKey construit la clé de déduplication (link_id, hash de l'IP, hash du User-Agent).
+// Les valeurs sont hachées pour ne pas stocker d'IP en clair dans le backend partagé.
+func clickDedupKey(event models.ClickEvent) string {
+	ipHash := sha256.Sum256([]byte(event.IPAddress))
+	uaHash := sha256.Sum256([]byte(event.UserAgent))
+	return fmt.Sprintf("%d:%s:%s", event.LinkID, hex.EncodeToString(ipHash[:8]), hex.EncodeToString(uaHash[:8]))
+}
+
+// MemoryClickDeduplicator déduplique les clics en mémoire, par instance.
+// Derrière un load balancer, deux répliques ne partagent pas cet état : un même visiteur servi
+// par deux instances différentes peut être compté plusieurs fois dans la fenêtre.
+type MemoryClickDeduplicator struct {
+	seen   map[string]time.Time // Date d'expiration de chaque clé vue
+	mu     sync.Mutex           // Mutex pour protéger l'accès concurrent à la map
+	window time.Duration        // Fenêtre de déduplication
+}
+
+// NewMemoryClickDeduplicator crée un MemoryClickDeduplicator et lance le nettoyage périodique des clés expirées.
+func NewMemoryClickDeduplicator(window time.Duration) *MemoryClickDeduplicator {
+	d := &MemoryClickDeduplicator{
+		seen:   make(map[string]time.Time),
+		window: window,
+	}
+	go d.cleanupExpired()
+	return d
+}
+
+// IsDuplicate implémente ClickDeduplicator.
+func (d *MemoryClickDeduplicator) IsDuplicate(event models.ClickEvent) (bool, error) {
+	key := clickDedupKey(event)
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if expiresAt, ok := d.seen[key]; ok && now.Before(expiresAt) {
+		return true, nil
+	}
+	d.seen[key] = now.Add(d.window)
+	return false, nil
+}
+
+// cleanupExpired supprime périodiquement les clés dont la fenêtre est terminée.
+func (d *MemoryClickDeduplicator) cleanupExpired() {
+	ticker := time.NewTicker(d.window)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		d.mu.Lock()
+		for key, expiresAt := range d.seen {
+			if now.After(expiresAt) {
+				delete(d.seen, key)
+			}
+		}
+		d.mu.Unlock()
+	}
+}
+
+// RedisClickDeduplicator déduplique les clics dans Redis, partagé entre toutes les répliques.
+// Chaque clé est posée avec SET NX et un TTL égal à la fenêtre : seul le premier clic la crée.
+type RedisClickDeduplicator struct {
+	client  *redis.Client
+	window  time.Duration
+	timeout time.Duration // Délai maximal d'un appel à Redis
+}
+
+// NewRedisClickDeduplicator crée un RedisClickDeduplicator sur le client Redis donné,
+// dont la fermeture reste à la charge de l'appelant.
+func NewRedisClickDeduplicator(client *redis.Client, window time.Duration) *RedisClickDeduplicator {
+	return &RedisClickDeduplicator{
+		client:  client,
+		window:  window,
+		timeout: time.Second,
+	}
+}
+
+// IsDuplicate implémente ClickDeduplicator.
+func (d *RedisClickDeduplicator) IsDuplicate(event models.ClickEvent) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	created, err := d.client.SetNX(ctx, "click_dedup:"+clickDedupKey(event), 1, d.window).Result()
+	if err != nil {
+		return false, err
+	}
+	return !created, nil
+}
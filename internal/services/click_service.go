@@ -1,6 +1,8 @@
 package services
 
 import (
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
 )
@@ -33,3 +35,9 @@ func (s *ClickService) GetClicksCountByLinkID(linkID uint) (int, error) {
 	// Appeler le ClickRepository (CountClicksByLinkID) pour compter les clics par LinkID.
 	return s.clickRepo.CountClicksByLinkID(linkID)
 }
+
+// RollupClicks agrège en lignes journalières les clics plus anciens que 'olderThan' et supprime les clics bruts.
+// Retourne le nombre de clics bruts agrégés.
+func (s *ClickService) RollupClicks(olderThan time.Duration) (int, error) {
+	return s.clickRepo.RollupClicksBefore(time.Now().Add(-olderThan))
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// CountryResolver résout l'adresse IP d'un visiteur en code pays ISO 3166-1 alpha-2.
+type CountryResolver interface {
+	// Country retourne le code pays de l'IP, ou une chaîne vide s'il est inconnu.
+	Country(ip string) string
+}
+
+// GeoIPCountryResolver résout les pays à partir d'une base MaxMind (GeoLite2-Country, GeoIP2-Country ou City).
+type GeoIPCountryResolver struct {
+	reader *geoip2.Reader
+}
+
+// NewGeoIPCountryResolver ouvre la base MaxMind (.mmdb) située à 'path'.
+func NewGeoIPCountryResolver(path string) (*GeoIPCountryResolver, error) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &GeoIPCountryResolver{reader: reader}, nil
+}
+
+// Country implémente CountryResolver. Les IPs invalides, privées ou absentes de la base donnent une chaîne vide.
+func (r *GeoIPCountryResolver) Country(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	record, err := r.reader.Country(parsed)
+	if err != nil {
+		return ""
+	}
+	return record.Country.IsoCode
+}
+
+// Close ferme la base MaxMind.
+func (r *GeoIPCountryResolver) Close() error {
+	return r.reader.Close()
+}
@@ -1,16 +1,26 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
+	"net"
+	"net/url"
 	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
-	"gorm.io/gorm" // Nécessaire pour la gestion spécifique de gorm.ErrRecordNotFound
+	"golang.org/x/crypto/bcrypt" // Hachage des mots de passe des liens protégés
+	"golang.org/x/net/idna"      // Conversion des domaines internationalisés en punycode
+	"gorm.io/gorm"               // Nécessaire pour la gestion spécifique de gorm.ErrRecordNotFound
 
+	"github.com/axellelanca/urlshortener/internal/config"
+	apperrors "github.com/axellelanca/urlshortener/internal/errors"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
 )
@@ -18,91 +28,713 @@ import (
 // Définition du jeu de caractères pour la génération des codes courts.
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
+// alphaCharset est le sous-ensemble alphabétique de charset, utilisé pour le premier caractère
+// si server.code_first_char_alpha est activé.
+const alphaCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// unambiguousCharset et unambiguousAlphaCharset excluent les caractères confondus à la lecture ou à l'oral
+// (l, I, 1, O, 0), utilisés si server.unambiguous_codes est activé.
+const (
+	unambiguousCharset      = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	unambiguousAlphaCharset = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+)
+
 // LinkService est une structure qui fournit des méthodes pour la logique métier des liens.
 // Elle détient linkRepo qui est une référence vers une interface LinkRepository.
 // IMPORTANT : Le champ doit être du type de l'interface (non-pointeur).
 type LinkService struct {
-	linkRepo repository.LinkRepository
+	linkRepo  repository.LinkRepository
+	storeRepo repository.LinkRepository // linkRepo sans son cache de lecture : lien complet et à jour (hash, propriétaire)
+	breaker   *CircuitBreaker           // Circuit breaker du chemin de création (nil si désactivé)
+	checker   *URLChecker               // Service externe de vérification des URLs (nil si non configuré)
+
+	routePrefixes   []string // Préfixes de routes interdits comme alias, seuls ou suivis d'un tiret
+	routeCollisions []string // Alias masqués par une route existante, refusés même avec AllowReserved
+	reserveVersions bool     // Refuser les alias de version pure (v1, v2, ...)
+	defaultScheme   string   // Schéma ajouté aux URLs longues sans schéma (vide pour les refuser)
+
+	codeLength    int // Longueur par défaut des codes courts générés
+	minCodeLength int // Longueur minimale acceptée par requête
+	maxCodeLength int // Longueur maximale acceptée par requête
+
+	firstCharAlpha bool   // Le premier caractère des codes générés est une lettre
+	maxRetries     int    // Nombre de codes tirés avant d'abandonner en cas de collisions
+	codeStrategy   string // "random" ou "sequential" (code dérivé de l'ID)
+	charset        string // Jeu de caractères des codes générés
+	alphaCharset   string // Sous-ensemble alphabétique de charset (premier caractère)
+
+	allowPrivateURLs bool     // Accepter les destinations qui résolvent vers une adresse interne
+	blockedDomains   []string // Domaines refusés comme destination (minuscules, punycode), sous-domaines compris
+	dedupeURLs       bool     // Réutiliser un lien existant vers la même URL longue
+	collapseWWW      bool     // Pour la réutilisation, "www.example.com" et "example.com" sont le même hôte
+
+	purgeBatchSize  int           // Nombre maximal de liens expirés chargés et purgés par lot
+	purgeBatchPause time.Duration // Pause entre deux lots de la purge
 }
 
+// MaxExpirationMinutes est la durée d'expiration maximale d'un lien : 1 an.
+const MaxExpirationMinutes = 525600
+
+// reservedWords sont les alias interdits tels quels pour éviter les conflits avec les routes existantes.
+var reservedWords = []string{"api", "health", "stats", "admin", "create", "delete"}
+
+// versionTokenPattern reconnaît les alias de version pure (v1, v2, V10, ...).
+var versionTokenPattern = regexp.MustCompile(`^[vV][0-9]+$`)
+
 // NewLinkService crée et retourne une nouvelle instance de LinkService.
-func NewLinkService(linkRepo repository.LinkRepository) *LinkService {
-	return &LinkService{
-		linkRepo: linkRepo,
+// La configuration permet d'activer le circuit breaker autour des créations.
+func NewLinkService(linkRepo repository.LinkRepository, cfg *config.Config) *LinkService {
+	s := &LinkService{
+		linkRepo:        linkRepo,
+		routePrefixes:   reservedRoutePrefixes(cfg),
+		routeCollisions: collidingRoutes(cfg),
+		reserveVersions: cfg.Server.ReserveVersionPrefixes,
+		defaultScheme:   cfg.Server.DefaultScheme,
+		codeLength:      cfg.Server.ShortCodeLength,
+		minCodeLength:   cfg.Server.MinShortCodeLength,
+		maxCodeLength:   cfg.Server.MaxShortCodeLength,
+		firstCharAlpha:  cfg.Server.CodeFirstCharAlpha,
+		maxRetries:      cfg.Server.MaxGenerationRetries,
+		codeStrategy:    cfg.Server.CodeStrategy,
+
+		allowPrivateURLs: cfg.Server.AllowPrivateURLs,
+		blockedDomains:   normalizeBlockedDomains(cfg.Security.BlockedDomains),
+		dedupeURLs:       cfg.Server.DedupeURLs,
+		collapseWWW:      cfg.Server.CollapseWWW,
+
+		purgeBatchSize:  cfg.Cleanup.BatchSize,
+		purgeBatchPause: time.Duration(cfg.Cleanup.BatchPauseMs) * time.Millisecond,
+	}
+	s.storeRepo = linkRepo
+	if cached, ok := linkRepo.(*repository.CachedLinkRepository); ok {
+		s.storeRepo = cached.LinkRepository
+	}
+	if cfg.Server.UnambiguousCodes {
+		s.charset, s.alphaCharset = unambiguousCharset, unambiguousAlphaCharset
+	} else {
+		s.charset, s.alphaCharset = charset, alphaCharset
+	}
+	if cfg.CircuitBreaker.Enabled {
+		s.breaker = NewCircuitBreaker(cfg.CircuitBreaker.FailureThreshold,
+			time.Duration(cfg.CircuitBreaker.CooldownSeconds)*time.Second)
+	}
+	if cfg.Security.URLCheckEndpoint != "" {
+		s.checker = NewURLChecker(cfg.Security.URLCheckEndpoint,
+			time.Duration(cfg.Security.URLCheckTimeoutMs)*time.Millisecond, cfg.Security.URLCheckFailOpen)
+	}
+	return s
+}
+
+// reservedRoutePrefixes construit la liste des préfixes de routes réservés :
+// "api", le premier segment du chemin de health check, "metrics" si les métriques sont exposées et les préfixes configurés.
+func reservedRoutePrefixes(cfg *config.Config) []string {
+	prefixes := []string{"api"}
+	if segment := strings.SplitN(strings.Trim(cfg.Server.HealthPath, "/"), "/", 2)[0]; segment != "" {
+		prefixes = append(prefixes, strings.ToLower(segment))
+	}
+	if cfg.Monitor.MetricsEnabled {
+		prefixes = append(prefixes, "metrics")
+	}
+	for _, prefix := range cfg.Server.ReservedRoutePrefixes {
+		if prefix = strings.ToLower(strings.Trim(prefix, "/")); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// collidingRoutes retourne les alias qui seraient réellement masqués par une route à un seul segment
+// enregistrée avant la route de redirection (le health check s'il est de la forme "/health", et /metrics).
+func collidingRoutes(cfg *config.Config) []string {
+	var routes []string
+	if cfg.Monitor.MetricsEnabled {
+		routes = append(routes, "metrics")
+	}
+	healthPath := strings.ToLower(strings.Trim(cfg.Server.HealthPath, "/"))
+	if healthPath == "" {
+		healthPath = "health"
 	}
+	if strings.Contains(healthPath, "/") {
+		return routes
+	}
+	return append(routes, healthPath)
+}
+
+// checkReservedAlias vérifie que l'alias n'est ni un mot réservé, ni un préfixe de route réservé
+// (seul ou suivi d'un tiret, ex: "api-docs"), ni une version pure si reserve_version_prefixes est activé.
+// Avec allowReserved (admin), seules les collisions avec les routes existantes sont refusées.
+// Le message d'erreur rappelle l'alias fautif et l'ensemble réservé.
+func (s *LinkService) checkReservedAlias(customAlias string, allowReserved bool) error {
+	alias := strings.ToLower(customAlias)
+	for _, route := range s.routeCollisions {
+		if alias == route {
+			return &apperrors.ErrInvalidAlias{Alias: customAlias,
+				Reason: fmt.Sprintf("l'alias '%s' est masqué par une route existante et ne peut pas être utilisé", customAlias)}
+		}
+	}
+	if allowReserved {
+		return nil
+	}
+
+	reserved := false
+	for _, word := range reservedWords {
+		if alias == word {
+			reserved = true
+		}
+	}
+	for _, prefix := range s.routePrefixes {
+		if alias == prefix || strings.HasPrefix(alias, prefix+"-") {
+			reserved = true
+		}
+	}
+	if s.reserveVersions && versionTokenPattern.MatchString(alias) {
+		reserved = true
+	}
+	if !reserved {
+		return nil
+	}
+
+	reservedSet := fmt.Sprintf("mots réservés: %s ; préfixes de routes réservés (seuls ou suivis d'un tiret): %s",
+		strings.Join(reservedWords, ", "), strings.Join(s.routePrefixes, ", "))
+	if s.reserveVersions {
+		reservedSet += " ; versions d'API (v1, v2, ...)"
+	}
+	return &apperrors.ErrInvalidAlias{Alias: customAlias,
+		Reason: fmt.Sprintf("l'alias '%s' est un mot réservé et ne peut pas être utilisé (%s)", customAlias, reservedSet)}
+}
+
+// normalizeBlockedDomains met les domaines bloqués sous la forme des hôtes des URLs stockées :
+// minuscules, punycode, sans point initial ni final (".evil.com" et "evil.com." valent "evil.com").
+func normalizeBlockedDomains(domains []string) []string {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain == "" {
+			continue
+		}
+		if ascii, err := idna.Lookup.ToASCII(domain); err == nil {
+			domain = ascii
+		}
+		normalized = append(normalized, domain)
+	}
+	return normalized
+}
+
+// checkBlockedDomain retourne une ErrDomainBlocked si l'hôte de l'URL est un domaine bloqué ou l'un de ses sous-domaines.
+func (s *LinkService) checkBlockedDomain(longURL string) error {
+	if len(s.blockedDomains) == 0 {
+		return nil
+	}
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return &apperrors.ErrInvalidURL{URL: longURL}
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for _, domain := range s.blockedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return &apperrors.ErrDomainBlocked{URL: longURL, Domain: domain}
+		}
+	}
+	return nil
+}
+
+// checkURL refuse les domaines bloqués et les destinations internes (sauf si server.allow_private_urls est activé),
+// puis soumet l'URL au service de vérification externe s'il est configuré.
+func (s *LinkService) checkURL(longURL string) error {
+	if err := s.checkBlockedDomain(longURL); err != nil {
+		return err
+	}
+	if !s.allowPrivateURLs {
+		if err := checkPublicDestination(longURL); err != nil {
+			return err
+		}
+	}
+	if s.checker == nil {
+		return nil
+	}
+	return s.checker.Check(longURL)
+}
+
+// destinationLookupTimeout borne la résolution DNS de l'hôte de destination lors de la création.
+const destinationLookupTimeout = 2 * time.Second
+
+// checkPublicDestination retourne une ErrInvalidURL si l'hôte de l'URL est, ou résout vers, une adresse
+// loopback, privée, link-local ou non spécifiée. Un nom qui ne résout pas n'est pas refusé ici :
+// il ne peut atteindre aucune adresse interne et le moniteur le signalera.
+func checkPublicDestination(longURL string) error {
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return &apperrors.ErrInvalidURL{URL: longURL}
+	}
+	hostname := u.Hostname()
+
+	var ips []net.IP
+	if ip := net.ParseIP(hostname); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), destinationLookupTimeout)
+		defer cancel()
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, hostname)
+		if err != nil {
+			return nil
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+			return &apperrors.ErrInvalidURL{URL: longURL, Reason: "la destination pointe vers une adresse interne"}
+		}
+	}
+	return nil
+}
+
+// CircuitBreakerState retourne l'état du circuit breaker de création,
+// ou une chaîne vide s'il est désactivé.
+func (s *LinkService) CircuitBreakerState() string {
+	if s.breaker == nil {
+		return ""
+	}
+	return s.breaker.State()
+}
+
+// allowCreate vérifie auprès du circuit breaker qu'une création peut solliciter la base de données.
+func (s *LinkService) allowCreate() error {
+	if s.breaker == nil {
+		return nil
+	}
+	return s.breaker.Allow()
+}
+
+// recordDBResult informe le circuit breaker du résultat d'un appel à la base de données.
+// gorm.ErrRecordNotFound est une réponse normale de la base et compte comme un succès.
+func (s *LinkService) recordDBResult(err error) {
+	if s.breaker == nil {
+		return
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.breaker.RecordFailure()
+		return
+	}
+	s.breaker.RecordSuccess()
+}
+
+// CreateLinkOptions regroupe les métadonnées optionnelles enregistrées avec un lien lors de sa création.
+type CreateLinkOptions struct {
+	CreatorIP   string // Adresse IP du créateur, vide si la capture est désactivée
+	TrackClicks *bool  // Enregistrer les clics du lien (nil = valeur par défaut, true)
+
+	// AllowReserved lève la vérification des mots réservés pour un alias personnalisé (appels admin uniquement).
+	// Elle n'est pas enregistrée avec le lien.
+	AllowReserved bool
+
+	// Source est le chemin de création du lien (models.LinkSourceAPI, models.LinkSourceCLI, ...)
+	Source string
+
+	// CodeLength est la longueur du code court généré (0 = longueur par défaut), ignorée pour un alias personnalisé.
+	CodeLength int
+
+	// MaxClicks est le nombre de clics après lequel le lien expire (0 = illimité).
+	MaxClicks int
+
+	// Password protège la redirection par un mot de passe (vide = lien non protégé).
+	// Seul son hash bcrypt est enregistré.
+	Password string
+
+	// SkipPreview fait rediriger le lien directement, sans la page intermédiaire (server.preview_redirect).
+	SkipPreview bool
+
+	// OwnerID est le propriétaire du lien, dérivé de la clé d'API (vide sans authentification).
+	// Un lien existant n'est réutilisé (server.dedupe_urls) que s'il appartient au même propriétaire.
+	OwnerID string
+}
+
+// reusable indique si une création avec ces options peut renvoyer un lien existant (server.dedupe_urls) :
+// les options qui modifient le lien créé (mot de passe, limite de clics, longueur de code, suivi désactivé,
+// sans page intermédiaire) l'excluent.
+func (o CreateLinkOptions) reusable() bool {
+	return o.Password == "" && o.MaxClicks == 0 && o.CodeLength == 0 && (o.TrackClicks == nil || *o.TrackClicks) && !o.SkipPreview
+}
+
+// Longueurs acceptées pour le mot de passe d'un lien protégé (bcrypt ignore au-delà de 72 octets).
+const (
+	minLinkPasswordLength = 8
+	maxLinkPasswordLength = 72
+)
+
+// shortCodeLength retourne la longueur de code court à générer pour une création,
+// ou un *errors.ErrInvalidCodeLength si la longueur demandée sort des bornes configurées.
+func (s *LinkService) shortCodeLength(opts CreateLinkOptions) (int, error) {
+	if opts.CodeLength == 0 {
+		return s.codeLength, nil
+	}
+	maxLength := min(s.maxCodeLength, models.ShortCodeMaxLength)
+	if opts.CodeLength < s.minCodeLength || opts.CodeLength > maxLength {
+		return 0, &apperrors.ErrInvalidCodeLength{Length: opts.CodeLength, Min: s.minCodeLength, Max: maxLength}
+	}
+	return opts.CodeLength, nil
+}
+
+// applyTo recopie les options renseignées sur le lien avant sa persistance.
+// Le mot de passe éventuel est haché : une erreur est retournée s'il est invalide.
+func (o CreateLinkOptions) applyTo(link *models.Link) error {
+	if o.CreatorIP != "" {
+		creatorIP := o.CreatorIP
+		link.CreatorIP = &creatorIP
+	}
+	if o.TrackClicks != nil {
+		trackClicks := *o.TrackClicks
+		link.TrackClicks = &trackClicks
+	}
+	link.Source = o.Source
+	link.SkipPreview = o.SkipPreview
+	link.OwnerID = o.OwnerID
+	if o.MaxClicks > 0 {
+		maxClicks := o.MaxClicks
+		link.MaxClicks = &maxClicks
+	}
+	if o.Password != "" {
+		if len(o.Password) < minLinkPasswordLength || len(o.Password) > maxLinkPasswordLength {
+			return &apperrors.ErrInvalidPassword{Min: minLinkPasswordLength, Max: maxLinkPasswordLength}
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(o.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return fmt.Errorf("error hashing link password: %w", err)
+		}
+		link.PasswordHash = string(hash)
+	}
+	return nil
+}
+
+// normalizeLongURL garantit que l'URL stockée est absolue et convertit un nom de domaine internationalisé
+// (IDN, ex: münchen.de) en punycode (xn--mnchen-3ya.de) avant stockage. On conserve la forme punycode
+// car c'est la seule forme valide dans l'en-tête Location d'une redirection ; les navigateurs l'affichent
+// ensuite en Unicode. Une URL sans schéma ("www.example.com", "//example.com") reçoit le schéma par défaut
+// s'il est configuré. Retourne un *errors.ErrInvalidURL si l'URL ou le domaine est invalide.
+func (s *LinkService) normalizeLongURL(longURL string) (string, error) {
+	original := longURL
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return "", &apperrors.ErrInvalidURL{URL: original}
+	}
+
+	if s.defaultScheme != "" && !hasScheme(u) {
+		if strings.HasPrefix(longURL, "//") {
+			longURL = s.defaultScheme + ":" + longURL
+		} else {
+			longURL = s.defaultScheme + "://" + longURL
+		}
+		if u, err = url.Parse(longURL); err != nil {
+			return "", &apperrors.ErrInvalidURL{URL: original}
+		}
+	}
+
+	// Équivalent de l'ancienne validation binding:"url" : un schéma et un hôte sont obligatoires
+	if u.Scheme == "" || u.Host == "" {
+		return "", &apperrors.ErrInvalidURL{URL: original}
+	}
+
+	hostname := u.Hostname()
+	// Les adresses IP (v4/v6) n'ont pas besoin de conversion
+	if net.ParseIP(hostname) != nil {
+		return longURL, nil
+	}
+
+	asciiHost, err := idna.Lookup.ToASCII(hostname)
+	if err != nil {
+		return "", &apperrors.ErrInvalidURL{URL: longURL}
+	}
+	if asciiHost == hostname {
+		return longURL, nil // Domaine déjà ASCII, on conserve l'URL telle quelle
+	}
+
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(asciiHost, port)
+	} else {
+		u.Host = asciiHost
+	}
+	return u.String(), nil
+}
+
+// wwwVariant retourne 'longURL' avec "www." retiré de l'hôte, ou ajouté s'il n'y figure pas, le reste de l'URL
+// étant conservé à l'identique. Retourne "" s'il n'y a pas de variante : adresse IP, hôte sans point (localhost),
+// "www." devant un domaine de premier niveau seul, ou URL avec identifiants.
+func wwwVariant(longURL string) string {
+	u, err := url.Parse(longURL)
+	if err != nil || u.User != nil {
+		return ""
+	}
+	hostname := u.Hostname()
+	if net.ParseIP(hostname) != nil {
+		return ""
+	}
+	start := strings.Index(longURL, "://") + len("://")
+	if start < len("://") || !strings.HasPrefix(longURL[start:], hostname) {
+		return ""
+	}
+
+	var variant string
+	if bare, ok := strings.CutPrefix(strings.ToLower(hostname), "www."); ok {
+		variant = hostname[len("www."):]
+		if !strings.Contains(bare, ".") {
+			return ""
+		}
+	} else {
+		if !strings.Contains(hostname, ".") {
+			return ""
+		}
+		variant = "www." + hostname
+	}
+	return longURL[:start] + variant + longURL[start+len(hostname):]
+}
+
+// hostPortPattern reconnaît la partie opaque d'un "hôte:port" sans schéma (ex: "example.com:8080/a"),
+// que url.Parse interprète comme un schéma "example.com" suivi de "8080/a".
+var hostPortPattern = regexp.MustCompile(`^[0-9]+(/|$)`)
+
+// hasScheme indique si l'URL analysée porte réellement un schéma : "example.com:8080/a" n'en a pas.
+func hasScheme(u *url.URL) bool {
+	return u.Scheme != "" && !(u.Host == "" && hostPortPattern.MatchString(u.Opaque))
 }
 
 // GenerateShortCode génère un code court aléatoire d'une longueur spécifiée.
 // Il utilise le package 'crypto/rand' pour éviter la prévisibilité.
+// Les caractères sont tirés dans le jeu configuré (sans caractères ambigus si server.unambiguous_codes est activé) ;
+// si server.code_first_char_alpha est activé, le premier caractère est tiré parmi les lettres uniquement.
 func (s *LinkService) GenerateShortCode(length int) (string, error) {
 	result := make([]byte, length)
-	charsetLen := big.NewInt(int64(len(charset)))
 
 	for i := 0; i < length; i++ {
-		randomIndex, err := rand.Int(rand.Reader, charsetLen)
+		set := s.charset
+		if i == 0 && s.firstCharAlpha {
+			set = s.alphaCharset
+		}
+		randomIndex, err := rand.Int(rand.Reader, big.NewInt(int64(len(set))))
 		if err != nil {
 			return "", fmt.Errorf("error generating random number: %w", err)
 		}
-		result[i] = charset[randomIndex.Int64()]
+		result[i] = set[randomIndex.Int64()]
 	}
 
 	return string(result), nil
 }
 
-// CreateLink crée un nouveau lien raccourci.
-// Il génère un code court unique, puis persiste le lien dans la base de données.
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
-	// Implémenter la logique de retry pour générer un code court unique.
-	// Essayez de générer un code, vérifiez s'il existe déjà en base, et retentez si une collision est trouvée.
-	// Limitez le nombre de tentatives pour éviter une boucle infinie.
+// encodeID encode 'n' dans la base formée par le jeu de caractères 'set' (base 62 avec charset),
+// complété à gauche jusqu'à 'minLength' caractères (set[0] valant zéro).
+func encodeID(n uint64, minLength int, set string) string {
+	base := uint64(len(set))
+	var encoded []byte
+	for n > 0 {
+		encoded = append(encoded, set[n%base])
+		n /= base
+	}
+	for len(encoded) < minLength {
+		encoded = append(encoded, set[0])
+	}
+	slices.Reverse(encoded)
+	return string(encoded)
+}
+
+// shortCodeTaken indique si un code court est déjà utilisé en base de données.
+func (s *LinkService) shortCodeTaken(code string) (bool, error) {
+	_, err := s.linkRepo.GetLinkByShortCode(code)
+	s.recordDBResult(err)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil // 'record not found' : le code est libre
+	}
+	return err == nil, err
+}
 
-	var shortCode string
-	maxRetries := 5
+// insertGeneratedLink attribue un code généré au lien et l'enregistre, selon server.code_strategy :
+// "random" tire un code aléatoire libre puis insère le lien ; "sequential" insère le lien puis dérive
+// son code de son ID en base 62, dans une transaction, sans tirage ni vérification préalable.
+func (s *LinkService) insertGeneratedLink(link *models.Link, codeLength int) error {
+	if s.codeStrategy == "sequential" {
+		err := s.linkRepo.CreateLinkWithDerivedCode(link, func(id uint, taken func(code string) (bool, error)) (string, error) {
+			code := encodeID(uint64(id), codeLength, s.charset)
+			isTaken, err := taken(code)
+			if err != nil || !isTaken {
+				return code, err
+			}
+			// Code déjà utilisé (alias personnalisé ou code aléatoire créé avant le changement de stratégie) :
+			// ce lien reçoit un code aléatoire pour ne pas bloquer les créations suivantes
+			slog.Warn("Derived short code already exists, falling back to a random code", "short_code", code, "link_id", id)
+			return s.generateUniqueShortCode(codeLength, taken)
+		})
+		var generationErr *apperrors.ErrCodeGenerationFailed
+		if !errors.As(err, &generationErr) {
+			s.recordDBResult(err)
+		}
+		if err != nil {
+			return fmt.Errorf("error creating link in database: %w", err)
+		}
+		return nil
+	}
 
-	for i := 0; i < maxRetries; i++ {
-		// Génère un code de 6 caractères
-		code, err := s.GenerateShortCode(6)
+	shortCode, err := s.generateUniqueShortCode(codeLength, s.shortCodeTaken)
+	if err != nil {
+		return err
+	}
+	link.ShortCode = shortCode
+
+	// Persiste le nouveau lien dans la base de données via le repository
+	err = s.linkRepo.CreateLink(link)
+	s.recordDBResult(err)
+	if err != nil {
+		return fmt.Errorf("error creating link in database: %w", err)
+	}
+	return nil
+}
+
+// generateUniqueShortCode tire des codes de longueur 'length' jusqu'à en trouver un que 'taken' déclare libre,
+// au plus server.max_generation_retries fois. Retourne *errors.ErrCodeGenerationFailed si tous les tirages
+// sont en collision ; une erreur de base de données interrompt immédiatement la recherche.
+func (s *LinkService) generateUniqueShortCode(length int, taken func(code string) (bool, error)) (string, error) {
+	for attempt := 1; attempt <= s.maxRetries; attempt++ {
+		code, err := s.GenerateShortCode(length)
 		if err != nil {
-			return nil, fmt.Errorf("error generating short code: %w", err)
+			return "", fmt.Errorf("error generating short code: %w", err)
 		}
 
 		// Vérifie si le code généré existe déjà en base de données
-		_, err = s.linkRepo.GetLinkByShortCode(code)
-
+		found, err := taken(code)
 		if err != nil {
-			// Si l'erreur est 'record not found' de GORM, cela signifie que le code est unique.
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				shortCode = code // Le code est unique, on peut l'utiliser
-				break            // Sort de la boucle de retry
-			}
-			// Si c'est une autre erreur de base de données, retourne l'erreur.
-			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
+			return "", fmt.Errorf("database error checking short code uniqueness: %w", err)
+		}
+		if !found {
+			return code, nil
 		}
 
-		// Si aucune erreur (le code a été trouvé), cela signifie une collision.
-		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", code, i+1, maxRetries)
-		// La boucle continuera pour générer un nouveau code.
+		// Le code existe déjà : collision, on en tire un autre
+		slog.Warn("Short code already exists, retrying generation", "short_code", code, "attempt", attempt, "max_attempts", s.maxRetries)
 	}
+	return "", &apperrors.ErrCodeGenerationFailed{Attempts: s.maxRetries}
+}
 
-	// Si après toutes les tentatives, aucun code unique n'a été trouvé
-	if shortCode == "" {
-		return nil, errors.New("failed to generate unique short code after multiple retries")
+// CreateLink crée un nouveau lien raccourci.
+// Il génère un code court unique, puis persiste le lien dans la base de données.
+// Si server.dedupe_urls est activé, un lien existant vers la même URL peut être retourné (voir CreateOrReuseLink).
+func (s *LinkService) CreateLink(longURL string, opts CreateLinkOptions) (*models.Link, error) {
+	link, _, err := s.CreateOrReuseLink(longURL, opts)
+	return link, err
+}
+
+// FindReusableLink retourne le lien existant que CreateOrReuseLink réutiliserait pour cette URL et ces options,
+// sans rien créer, ou nil si aucun lien n'est réutilisable (déduplication désactivée, options propres au lien,
+// aucun lien vers la même URL). Permet de servir une réutilisation quand le quota de création est atteint.
+func (s *LinkService) FindReusableLink(longURL string, opts CreateLinkOptions) (*models.Link, error) {
+	if !s.dedupeURLs || !opts.reusable() {
+		return nil, nil
+	}
+	longURL, err := s.normalizeLongURL(longURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkURL(longURL); err != nil {
+		return nil, err
+	}
+	if err := s.allowCreate(); err != nil {
+		return nil, err
+	}
+	return s.findReusableLink(longURL, opts)
+}
+
+// findReusableLink cherche le lien réutilisable vers 'longURL' (déjà normalisée et vérifiée) appartenant au même
+// propriétaire que le lien demandé, nil s'il n'y en a pas : le lien d'un autre propriétaire n'est jamais réutilisé.
+// Avec server.collapse_www, un lien vers la même URL avec ou sans "www." devant l'hôte convient aussi.
+func (s *LinkService) findReusableLink(longURL string, opts CreateLinkOptions) (*models.Link, error) {
+	candidates := []string{longURL}
+	if s.collapseWWW {
+		if variant := wwwVariant(longURL); variant != "" {
+			candidates = append(candidates, variant)
+		}
+	}
+	existing, err := s.linkRepo.GetLinkByLongURL(candidates, opts.OwnerID)
+	s.recordDBResult(err)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("database error looking up existing link: %w", err)
+	}
+	return existing, nil
+}
+
+// CreateOrReuseLink crée un lien comme CreateLink et indique en plus si un lien existant a été réutilisé :
+// avec server.dedupe_urls et des options sans effet sur le lien créé, le lien le plus récent du même propriétaire vers la même URL
+// (actif, non expiré, voir LinkRepository.GetLinkByLongURL) est retourné au lieu d'un nouveau code.
+func (s *LinkService) CreateOrReuseLink(longURL string, opts CreateLinkOptions) (*models.Link, bool, error) {
+	// Normaliser les domaines internationalisés en punycode
+	longURL, err := s.normalizeLongURL(longURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Faire valider l'URL par le service de vérification externe
+	if err := s.checkURL(longURL); err != nil {
+		return nil, false, err
+	}
+
+	// Rejeter immédiatement si le circuit breaker est ouvert
+	if err := s.allowCreate(); err != nil {
+		return nil, false, err
+	}
+
+	// Réutiliser un lien existant vers la même URL si la déduplication est activée
+	if s.dedupeURLs && opts.reusable() {
+		existing, err := s.findReusableLink(longURL, opts)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			return existing, true, nil
+		}
 	}
 
+	codeLength, err := s.shortCodeLength(opts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Génère un code unique de la longueur demandée (6 caractères par défaut)
 	// Crée une nouvelle instance du modèle Link.
 	link := &models.Link{
-		ShortCode: shortCode,
-		LongURL:   longURL,
+		LongURL: longURL,
+	}
+	if err := opts.applyTo(link); err != nil {
+		return nil, false, err
 	}
 
-	// Persiste le nouveau lien dans la base de données via le repository
-	err := s.linkRepo.CreateLink(link)
-	if err != nil {
-		return nil, fmt.Errorf("error creating link in database: %w", err)
+	// Génère un code unique de la longueur demandée (6 caractères par défaut) et persiste le lien
+	if err := s.insertGeneratedLink(link, codeLength); err != nil {
+		return nil, false, err
 	}
 
 	// Retourne le lien créé
-	return link, nil
+	return link, false, nil
+}
+
+// CreateProtectedLink crée un lien raccourci dont la redirection demande un mot de passe.
+// Le mot de passe est haché avec bcrypt avant stockage.
+func (s *LinkService) CreateProtectedLink(longURL, password string, opts CreateLinkOptions) (*models.Link, error) {
+	opts.Password = password
+	return s.CreateLink(longURL, opts)
+}
+
+// CheckLinkPassword indique si 'password' est le mot de passe d'un lien protégé.
+// Un lien servi par le cache ne porte pas son hash : il est alors relu en base, à la seule soumission du mot de passe.
+func (s *LinkService) CheckLinkPassword(link *models.Link, password string) (bool, error) {
+	hash := link.PasswordHash
+	if hash == "" {
+		stored, err := s.storeRepo.GetLinkByID(link.ID)
+		if err != nil {
+			return false, fmt.Errorf("error loading password of %s: %w", link.ShortCode, err)
+		}
+		hash = stored.PasswordHash
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil, nil
 }
 
 // GetLinkByShortCode récupère un lien via son code court.
@@ -113,11 +745,307 @@ func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error)
 	return s.linkRepo.GetLinkByShortCode(shortCode)
 }
 
+// ResolveLink récupère le lien servi par un code court : le lien lui-même,
+// ou son lien canonique si le code est un alias ajouté avec AddAlias.
+func (s *LinkService) ResolveLink(shortCode string) (*models.Link, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil || link.CanonicalLinkID == nil {
+		return link, err
+	}
+	canonical, err := s.linkRepo.GetLinkByID(*link.CanonicalLinkID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading canonical link of %s: %w", shortCode, err)
+	}
+	return canonical, nil
+}
+
+// ResolveStoredLink est ResolveLink sans le cache de lecture : le lien complet (propriétaire, hash du mot de passe)
+// et à jour (compteur de redirections, dernière vérification du moniteur), pour les statistiques,
+// les vérifications et les écritures qui en dépendent.
+func (s *LinkService) ResolveStoredLink(shortCode string) (*models.Link, error) {
+	link, err := s.storeRepo.GetLinkByShortCode(shortCode)
+	if err != nil || link.CanonicalLinkID == nil {
+		return link, err
+	}
+	canonical, err := s.storeRepo.GetLinkByID(*link.CanonicalLinkID)
+	if err != nil {
+		return nil, fmt.Errorf("error loading canonical link of %s: %w", shortCode, err)
+	}
+	return canonical, nil
+}
+
+// AddAlias ajoute un code court supplémentaire 'newAlias' pointant vers le lien 'shortCode'.
+// L'alias redirige vers la même destination et ses clics sont comptés sur le lien canonique :
+// les statistiques sont partagées. Un alias d'alias est rattaché directement au lien canonique.
+// Renvoie gorm.ErrRecordNotFound si 'shortCode' n'existe pas.
+func (s *LinkService) AddAlias(shortCode, newAlias string) (*models.Link, error) {
+	if err := s.validateCustomAlias(newAlias, false); err != nil {
+		return nil, err
+	}
+
+	canonical, err := s.ResolveStoredLink(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAliasAvailable(newAlias); err != nil {
+		return nil, err
+	}
+
+	alias := &models.Link{
+		ShortCode:       newAlias,
+		LongURL:         canonical.LongURL, // Copie tenue à jour par UpdateLink, la redirection utilise le lien canonique
+		IsCustom:        true,
+		CanonicalLinkID: &canonical.ID,
+		Source:          canonical.Source,
+		OwnerID:         canonical.OwnerID,
+	}
+	if err := s.linkRepo.CreateLink(alias); err != nil {
+		return nil, fmt.Errorf("erreur lors de la création de l'alias: %w", err)
+	}
+
+	slog.Info("Alias ajouté au lien", "alias", newAlias, "short_code", canonical.ShortCode)
+	return alias, nil
+}
+
+// CountRecentLinksByCreator compte les liens créés depuis une IP sur la fenêtre de temps donnée.
+// Utilisé pour appliquer le quota de création par IP.
+func (s *LinkService) CountRecentLinksByCreator(creatorIP string, window time.Duration) (int, error) {
+	return s.linkRepo.CountLinksByCreatorIPSince(creatorIP, time.Now().Add(-window))
+}
+
+// GetServedPathBreakdown retourne la répartition des clics d'un lien par chemin de redirection.
+// Comme toutes les répartitions, elle compte les clics compactés sous models.ClicksRolledUp.
+func (s *LinkService) GetServedPathBreakdown(linkID uint) (map[string]int, error) {
+	return s.linkRepo.CountClicksGroupedBy(linkID, "served_path")
+}
+
+// GetCountryBreakdown retourne la répartition des clics d'un lien par code pays.
+// Les clics dont le pays est inconnu (résolution désactivée, IP privée ou absente de la base) sont regroupés sous models.CountryUnknown.
+func (s *LinkService) GetCountryBreakdown(linkID uint) (map[string]int, error) {
+	counts, err := s.linkRepo.CountClicksGroupedBy(linkID, "country")
+	if err != nil {
+		return nil, err
+	}
+	if unknown, ok := counts[""]; ok {
+		counts[models.CountryUnknown] += unknown
+		delete(counts, "")
+	}
+	return counts, nil
+}
+
+// GetBrowserBreakdown retourne la répartition des clics d'un lien par famille de navigateur.
+// Les clics enregistrés avant l'analyse du User-Agent sont regroupés sous models.UserAgentOther.
+func (s *LinkService) GetBrowserBreakdown(linkID uint) (map[string]int, error) {
+	counts, err := s.linkRepo.CountClicksGroupedBy(linkID, "browser")
+	if err != nil {
+		return nil, err
+	}
+	if legacy, ok := counts[""]; ok {
+		counts[models.UserAgentOther] += legacy
+		delete(counts, "")
+	}
+	return counts, nil
+}
+
+// GetClickVersion retourne l'état des clics d'un lien, qui change dès qu'un clic est enregistré ou compacté.
+func (s *LinkService) GetClickVersion(linkID uint) (repository.ClickVersion, error) {
+	return s.linkRepo.GetClickVersion(linkID)
+}
+
+// CountUniqueVisitors retourne le nombre de visiteurs distincts (adresses IP) d'un lien.
+func (s *LinkService) CountUniqueVisitors(linkID uint) (int, error) {
+	return s.linkRepo.CountUniqueVisitorsByLinkID(linkID)
+}
+
+// DailyClicks est le nombre de clics d'un lien sur une journée (UTC).
+type DailyClicks struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	Clicks int    `json:"clicks"`
+}
+
+// GetClicksByDay retourne la série des clics d'un lien jour par jour entre 'from' et 'to' inclus,
+// du plus ancien au plus récent. Les jours sans clic figurent dans la série avec 0 clic.
+func (s *LinkService) GetClicksByDay(linkID uint, from, to time.Time) ([]DailyClicks, error) {
+	counts, err := s.linkRepo.CountClicksByDay(linkID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []DailyClicks
+	last := to.UTC().Format("2006-01-02")
+	for day := from.UTC(); ; day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if date > last {
+			break
+		}
+		series = append(series, DailyClicks{Date: date, Clicks: counts[date]})
+	}
+	return series, nil
+}
+
+// ReferrerCount est le nombre de clics d'un lien venant d'un même référent.
+type ReferrerCount struct {
+	Referrer string `json:"referrer"`
+	Clicks   int    `json:"clicks"`
+}
+
+// GetTopReferrers retourne les 'limit' référents ayant amené le plus de clics sur un lien, du plus fréquent au moins fréquent.
+func (s *LinkService) GetTopReferrers(linkID uint, limit int) ([]ReferrerCount, error) {
+	counts, err := s.linkRepo.CountClicksGroupedBy(linkID, "referrer")
+	if err != nil {
+		return nil, err
+	}
+
+	// Les anciens clics sans référent (NULL) sont fusionnés avec les accès directs
+	if legacy, ok := counts[""]; ok {
+		counts[models.ReferrerDirect] += legacy
+		delete(counts, "")
+	}
+
+	referrers := make([]ReferrerCount, 0, len(counts))
+	for referrer, clicks := range counts {
+		referrers = append(referrers, ReferrerCount{Referrer: referrer, Clicks: clicks})
+	}
+	sort.Slice(referrers, func(i, j int) bool {
+		if referrers[i].Clicks != referrers[j].Clicks {
+			return referrers[i].Clicks > referrers[j].Clicks
+		}
+		return referrers[i].Referrer < referrers[j].Referrer
+	})
+	if len(referrers) > limit {
+		referrers = referrers[:limit]
+	}
+	return referrers, nil
+}
+
+// GetTopLinks retourne les liens les plus cliqués depuis 'since' (zéro pour tout l'historique).
+func (s *LinkService) GetTopLinks(limit int, since time.Time) ([]repository.LinkClickCount, error) {
+	return s.linkRepo.GetTopLinks(limit, since)
+}
+
+// ownerTopLinks est le nombre de liens les plus cliqués retournés par GetOwnerStats.
+const ownerTopLinks = 5
+
+// GetOwnerStats retourne les statistiques de tous les liens de 'ownerID' : nombres de liens (total, actifs, expirés),
+// total des clics et les liens les plus cliqués.
+func (s *LinkService) GetOwnerStats(ownerID string) (*repository.OwnerStats, error) {
+	return s.linkRepo.GetOwnerStats(ownerID, time.Now(), ownerTopLinks)
+}
+
+// GetAllLinksWithClickCounts retourne les liens avec leur nombre de clics, triés par clics ou par date de création.
+func (s *LinkService) GetAllLinksWithClickCounts(orderByClicks bool, limit int) ([]repository.LinkClickCount, error) {
+	return s.linkRepo.GetAllLinksWithClickCounts(orderByClicks, limit)
+}
+
+// GetLinksByCreatorIP retourne tous les liens créés depuis une IP, avec leur nombre de clics (usage admin).
+func (s *LinkService) GetLinksByCreatorIP(creatorIP string) ([]repository.LinkClickCount, error) {
+	return s.linkRepo.GetLinksByCreatorIP(creatorIP)
+}
+
+// UpdateLongURL modifie la destination d'un lien existant, alias personnalisés compris.
+// L'URL est normalisée et vérifiée comme à la création. Renvoie gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *LinkService) UpdateLongURL(shortCode, longURL string) (*models.Link, error) {
+	longURL, err := s.normalizeLongURL(longURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkURL(longURL); err != nil {
+		return nil, err
+	}
+
+	// Modifier un alias modifie la destination de son lien canonique (et donc de tous ses alias).
+	// Le lien est lu sans le cache : il est réécrit en entier, hash du mot de passe et propriétaire compris.
+	link, err := s.ResolveStoredLink(shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Le résultat des vérifications du moniteur portait sur l'ancienne destination : il est effacé,
+	// et un lien désactivé pour cette destination (injoignable ou signalée dangereuse) est réactivé.
+	// Un lien retiré par la purge des liens expirés le reste.
+	if link.LongURL != longURL {
+		if !link.IsActive && link.InactiveReason != models.InactiveReasonExpired {
+			link.IsActive = true
+			link.InactiveReason = ""
+		}
+		link.LastCheckStatus = 0
+		link.LastCheckedAt = nil
+	}
+
+	link.LongURL = longURL
+	if err := s.linkRepo.UpdateLink(link); err != nil {
+		return nil, fmt.Errorf("database error updating link: %w", err)
+	}
+	return link, nil
+}
+
+// DeleteLink supprime un lien et toutes ses statistiques.
+// Renvoie gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *LinkService) DeleteLink(shortCode string) error {
+	return s.linkRepo.DeleteLink(shortCode)
+}
+
+// CountLinksBySource retourne le nombre de liens par chemin de création ("unknown" pour les anciens liens).
+func (s *LinkService) CountLinksBySource() (map[string]int, error) {
+	return s.linkRepo.CountLinksBySource()
+}
+
+// ListLinks retourne la page demandée (numérotée à partir de 1) et le nombre total de liens.
+func (s *LinkService) ListLinks(page, pageSize int) ([]models.Link, int64, error) {
+	return s.linkRepo.ListLinks((page-1)*pageSize, pageSize)
+}
+
+// ListLinksByOwner retourne la page demandée (numérotée à partir de 1) des liens d'un propriétaire et leur nombre total.
+func (s *LinkService) ListLinksByOwner(ownerID string, page, pageSize int) ([]models.Link, int64, error) {
+	return s.linkRepo.ListLinksByOwner(ownerID, (page-1)*pageSize, pageSize)
+}
+
+// CheckLinkOwner vérifie que 'ownerID' peut modifier le lien 'shortCode' : un lien sans propriétaire
+// (créé sans authentification) est modifiable par tous, un alias appartient au propriétaire de son lien canonique.
+// Renvoie gorm.ErrRecordNotFound si le lien n'existe pas, *errors.ErrNotLinkOwner s'il appartient à un autre.
+func (s *LinkService) CheckLinkOwner(shortCode, ownerID string) error {
+	link, err := s.ResolveStoredLink(shortCode)
+	if err != nil {
+		return err
+	}
+	if link.OwnerID != "" && link.OwnerID != ownerID {
+		return &apperrors.ErrNotLinkOwner{ShortCode: shortCode}
+	}
+	return nil
+}
+
+// GetLinksWithClickCountsAfter retourne la page de liens suivant 'afterID' avec leur nombre de clics (rapports).
+func (s *LinkService) GetLinksWithClickCountsAfter(afterID uint, createdSince time.Time, limit int) ([]repository.LinkClickCount, error) {
+	return s.linkRepo.GetLinksWithClickCountsAfter(afterID, createdSince, limit)
+}
+
+// RecordRedirect compte une redirection servie par le lien et retourne le nouveau total de redirections.
+// Le compteur est tenu en base de façon synchrone, y compris quand les analytics ou le suivi des clics sont désactivés ;
+// le handler de redirection ne l'appelle que si server.expose_click_count_header est activé ou si le lien a un max_clicks.
+// Pour un lien limité en clics, l'incrément et la vérification de la limite forment une seule requête :
+// retourne un *errors.ErrClickLimitReached (compteur inchangé) si la limite est déjà atteinte.
+func (s *LinkService) RecordRedirect(link *models.Link) (int, error) {
+	count, allowed, err := s.linkRepo.IncrementRedirectCount(link.ID)
+	if err != nil {
+		return 0, err
+	}
+	if !allowed {
+		maxClicks := 0
+		if link.MaxClicks != nil {
+			maxClicks = *link.MaxClicks
+		}
+		return count, &apperrors.ErrClickLimitReached{ShortCode: link.ShortCode, MaxClicks: maxClicks}
+	}
+	return count, nil
+}
+
 // GetLinkStats récupère les statistiques pour un lien donné (nombre total de clics).
-// Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository
+// Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository.
+// Pour un alias, ce sont les statistiques du lien canonique, partagées par tous ses alias.
 func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
-	// Récupérer le lien par son shortCode
-	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	// Récupérer le lien (canonique) par son shortCode, sans le cache : clics restants et dernière vérification
+	// du moniteur doivent refléter la base, pas une entrée mise en cache par une redirection.
+	link, err := s.ResolveStoredLink(shortCode)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -135,103 +1063,145 @@ func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error)
 // CreateLinkWithExpiration crée un nouveau lien raccourci avec une date d'expiration.
 // Cette méthode fait partie des features bonus et permet de créer des liens temporaires.
 // Le paramètre expirationMinutes définit la durée de vie du lien en minutes.
-func (s *LinkService) CreateLinkWithExpiration(longURL string, expirationMinutes int) (*models.Link, error) {
+func (s *LinkService) CreateLinkWithExpiration(longURL string, expirationMinutes int, opts CreateLinkOptions) (*models.Link, error) {
 	// Validation de la durée d'expiration
 	if expirationMinutes <= 0 {
 		return nil, errors.New("la durée d'expiration doit être supérieure à 0 minutes")
 	}
 
 	// Limiter la durée maximale d'expiration à 1 an (525600 minutes)
-	if expirationMinutes > 525600 {
+	if expirationMinutes > MaxExpirationMinutes {
 		return nil, errors.New("la durée d'expiration ne peut pas dépasser 1 an (525600 minutes)")
 	}
 
-	// Générer un code court unique (même logique que CreateLink)
-	var shortCode string
-	maxRetries := 5
+	// Calculer la date d'expiration
+	return s.createExpiringLink(longURL, time.Now().Add(time.Duration(expirationMinutes)*time.Minute), opts)
+}
 
-	for i := 0; i < maxRetries; i++ {
-		code, err := s.GenerateShortCode(6)
-		if err != nil {
-			return nil, fmt.Errorf("error generating short code: %w", err)
-		}
+// CreateLinkExpiringAt crée un nouveau lien raccourci qui expire à la date absolue 'expiresAt'.
+// La date doit être dans le futur et au plus à 1 an, comme pour CreateLinkWithExpiration.
+func (s *LinkService) CreateLinkExpiringAt(longURL string, expiresAt time.Time, opts CreateLinkOptions) (*models.Link, error) {
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return nil, errors.New("la date d'expiration doit être dans le futur")
+	}
+	if expiresAt.After(now.Add(MaxExpirationMinutes * time.Minute)) {
+		return nil, errors.New("la date d'expiration ne peut pas être à plus d'1 an")
+	}
+	return s.createExpiringLink(longURL, expiresAt, opts)
+}
 
-		_, err = s.linkRepo.GetLinkByShortCode(code)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				shortCode = code
-				break
-			}
-			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
-		}
-		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", code, i+1, maxRetries)
+// createExpiringLink crée un lien expirant à 'expiresAt', date déjà validée par l'appelant.
+func (s *LinkService) createExpiringLink(longURL string, expiresAt time.Time, opts CreateLinkOptions) (*models.Link, error) {
+	// Normaliser les domaines internationalisés en punycode
+	longURL, err := s.normalizeLongURL(longURL)
+	if err != nil {
+		return nil, err
 	}
 
-	if shortCode == "" {
-		return nil, errors.New("failed to generate unique short code after multiple retries")
+	// Faire valider l'URL par le service de vérification externe
+	if err := s.checkURL(longURL); err != nil {
+		return nil, err
 	}
 
-	// Calculer la date d'expiration
-	expiresAt := time.Now().Add(time.Duration(expirationMinutes) * time.Minute)
+	// Rejeter immédiatement si le circuit breaker est ouvert
+	if err := s.allowCreate(); err != nil {
+		return nil, err
+	}
+
+	codeLength, err := s.shortCodeLength(opts)
+	if err != nil {
+		return nil, err
+	}
 
 	// Créer le lien avec la date d'expiration
 	link := &models.Link{
-		ShortCode: shortCode,
 		LongURL:   longURL,
 		ExpiresAt: &expiresAt, // Pointeur vers la date d'expiration
 	}
+	if err := opts.applyTo(link); err != nil {
+		return nil, err
+	}
 
-	// Persister le lien dans la base de données
-	err := s.linkRepo.CreateLink(link)
-	if err != nil {
-		return nil, fmt.Errorf("error creating link with expiration in database: %w", err)
+	// Générer un code court unique (même logique que CreateLink) et persister le lien
+	if err := s.insertGeneratedLink(link, codeLength); err != nil {
+		return nil, err
 	}
 
-	log.Printf("Lien créé avec succès avec expiration dans %d minutes (expire le %s)",
-		expirationMinutes, expiresAt.Format("2006-01-02 15:04:05"))
+	slog.Info("Lien créé avec succès avec expiration", "short_code", link.ShortCode, "expires_at", expiresAt)
 	return link, nil
 }
 
+// PurgeExpiredLinks retire les liens expirés et retourne le nombre de liens purgés.
+// Avec hardDelete, les liens sont supprimés avec leurs clics et leurs alias ; sinon ils sont seulement
+// désactivés (inactive_reason "expired") : la redirection répond toujours 410 et les statistiques restent consultables.
+// Les liens sont traités par lots de cleanup.batch_size, avec une pause de cleanup.batch_pause_ms entre deux lots,
+// pour ne jamais charger toute la table en mémoire ni monopoliser la base.
+func (s *LinkService) PurgeExpiredLinks(hardDelete bool) (int, error) {
+	now := time.Now()
+	purged := 0
+	var afterID uint
+	for batch := 1; ; batch++ {
+		// En mode soft, les liens déjà désactivés lors d'un passage précédent ne sont pas relus
+		links, err := s.linkRepo.GetExpiredLinks(now, afterID, s.purgeBatchSize, !hardDelete)
+		if err != nil {
+			return purged, err
+		}
+
+		batchPurged := 0 // Liens du lot purgés, pour un total exact en cas d'erreur en cours de lot
+		for _, link := range links {
+			if hardDelete {
+				err = s.linkRepo.DeleteLink(link.ShortCode)
+			} else {
+				err = s.linkRepo.DeactivateLink(link.ID, models.InactiveReasonExpired)
+			}
+			if err != nil {
+				return purged + batchPurged, fmt.Errorf("error purging expired link %s: %w", link.ShortCode, err)
+			}
+			batchPurged++
+		}
+		purged += batchPurged
+		if len(links) > 0 {
+			slog.Info("Lot de liens expirés purgé", "component", "purge", "batch", batch,
+				"processed", len(links), "purged_total", purged)
+		}
+
+		// Un lot incomplet est le dernier : inutile d'attendre pour relire une page vide
+		if len(links) < s.purgeBatchSize {
+			return purged, nil
+		}
+		afterID = links[len(links)-1].ID
+		time.Sleep(s.purgeBatchPause)
+	}
+}
+
 // CreateLinkWithCustomAlias crée un nouveau lien raccourci avec un alias personnalisé fourni par l'utilisateur.
 // Cette méthode fait partie des features bonus et permet aux utilisateurs de choisir leur propre code court.
 // Elle valide que l'alias respecte certaines règles (longueur, caractères autorisés) et qu'il n'existe pas déjà.
-func (s *LinkService) CreateLinkWithCustomAlias(longURL, customAlias string) (*models.Link, error) {
-	// Validation de l'alias personnalisé
-	// 1. Vérifier que l'alias n'est pas vide
-	if customAlias == "" {
-		return nil, errors.New("l'alias personnalisé ne peut pas être vide")
+func (s *LinkService) CreateLinkWithCustomAlias(longURL, customAlias string, opts CreateLinkOptions) (*models.Link, error) {
+	if err := s.validateCustomAlias(customAlias, opts.AllowReserved); err != nil {
+		return nil, err
 	}
 
-	// 2. Vérifier la longueur de l'alias (entre 3 et 20 caractères)
-	if len(customAlias) < 3 || len(customAlias) > 20 {
-		return nil, errors.New("l'alias personnalisé doit contenir entre 3 et 20 caractères")
+	// Normaliser les domaines internationalisés en punycode
+	longURL, err := s.normalizeLongURL(longURL)
+	if err != nil {
+		return nil, err
 	}
 
-	// 3. Vérifier que l'alias ne contient que des caractères alphanumériques et des tirets
-	// On utilise une regex pour valider le format
-	validAliasPattern := regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
-	if !validAliasPattern.MatchString(customAlias) {
-		return nil, errors.New("l'alias personnalisé ne peut contenir que des lettres, chiffres et tirets")
+	// Faire valider l'URL par le service de vérification externe
+	if err := s.checkURL(longURL); err != nil {
+		return nil, err
 	}
 
-	// 4. Vérifier que l'alias n'est pas un mot réservé (pour éviter les conflits avec les routes API)
-	reservedWords := []string{"api", "health", "stats", "admin", "create", "delete"}
-	for _, reserved := range reservedWords {
-		if customAlias == reserved {
-			return nil, fmt.Errorf("l'alias '%s' est un mot réservé et ne peut pas être utilisé", customAlias)
-		}
+	// Rejeter immédiatement si le circuit breaker est ouvert
+	if err := s.allowCreate(); err != nil {
+		return nil, err
 	}
 
 	// 5. Vérifier que l'alias n'existe pas déjà en base de données
-	existingLink, err := s.linkRepo.GetLinkByShortCode(customAlias)
-	if err == nil && existingLink != nil {
-		// Si aucune erreur et qu'un lien existe, cela signifie que l'alias est déjà pris
-		return nil, fmt.Errorf("l'alias '%s' est déjà utilisé, veuillez en choisir un autre", customAlias)
-	}
-
-	// Si l'erreur n'est pas 'record not found', c'est une erreur de base de données
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, fmt.Errorf("erreur lors de la vérification de l'alias: %w", err)
+	if err := s.checkAliasAvailable(customAlias); err != nil {
+		return nil, err
 	}
 
 	// L'alias est valide et disponible, on peut créer le lien
@@ -240,13 +1210,58 @@ func (s *LinkService) CreateLinkWithCustomAlias(longURL, customAlias string) (*m
 		LongURL:   longURL,
 		IsCustom:  true, // Marquer ce lien comme ayant un alias personnalisé
 	}
+	if err := opts.applyTo(link); err != nil {
+		return nil, err
+	}
 
 	// Persister le lien dans la base de données
 	err = s.linkRepo.CreateLink(link)
+	s.recordDBResult(err)
 	if err != nil {
 		return nil, fmt.Errorf("erreur lors de la création du lien avec alias personnalisé: %w", err)
 	}
 
-	log.Printf("Lien créé avec succès avec l'alias personnalisé '%s'", customAlias)
+	slog.Info("Lien créé avec succès avec l'alias personnalisé", "short_code", customAlias)
 	return link, nil
 }
+
+// validateCustomAlias vérifie le format d'un alias personnalisé (étapes 1 à 4 de CreateLinkWithCustomAlias).
+func (s *LinkService) validateCustomAlias(customAlias string, allowReserved bool) error {
+	// Validation de l'alias personnalisé
+	// 1. Vérifier que l'alias n'est pas vide
+	if customAlias == "" {
+		return &apperrors.ErrInvalidAlias{Alias: customAlias, Reason: "l'alias personnalisé ne peut pas être vide"}
+	}
+
+	// 2. Vérifier la longueur de l'alias (entre 3 et 20 caractères)
+	if len(customAlias) < 3 || len(customAlias) > 20 {
+		return &apperrors.ErrInvalidAlias{Alias: customAlias, Reason: "l'alias personnalisé doit contenir entre 3 et 20 caractères"}
+	}
+
+	// 3. Vérifier que l'alias ne contient que des caractères alphanumériques et des tirets
+	// On utilise une regex pour valider le format
+	validAliasPattern := regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+	if !validAliasPattern.MatchString(customAlias) {
+		return &apperrors.ErrInvalidAlias{Alias: customAlias, Reason: "l'alias personnalisé ne peut contenir que des lettres, chiffres et tirets"}
+	}
+
+	// 4. Vérifier que l'alias n'est pas réservé (pour éviter les conflits avec les routes API actuelles et futures)
+	return s.checkReservedAlias(customAlias, allowReserved)
+}
+
+// checkAliasAvailable vérifie qu'aucun lien n'utilise déjà le code 'customAlias'.
+func (s *LinkService) checkAliasAvailable(customAlias string) error {
+	existingLink, err := s.linkRepo.GetLinkByShortCode(customAlias)
+	s.recordDBResult(err)
+	if err == nil && existingLink != nil {
+		// Si aucune erreur et qu'un lien existe, cela signifie que l'alias est déjà pris
+		return &apperrors.ErrInvalidAlias{Alias: customAlias,
+			Reason: fmt.Sprintf("l'alias '%s' est déjà utilisé, veuillez en choisir un autre", customAlias)}
+	}
+
+	// Si l'erreur n'est pas 'record not found', c'est une erreur de base de données
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("erreur lors de la vérification de l'alias: %w", err)
+	}
+	return nil
+}
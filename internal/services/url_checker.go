@@ -0,0 +1,89 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	apperrors "github.com/axellelanca/urlshortener/internal/errors"
+)
+
+// URLChecker interroge un service externe (anti-abus, anti-malware) avant chaque création de lien.
+// Le service reçoit un POST JSON {"url": "..."} et doit répondre {"decision": "allow"|"deny", "reason": "..."}.
+type URLChecker struct {
+	endpoint string       // URL du service de vérification
+	client   *http.Client // Client HTTP avec timeout
+	failOpen bool         // Autoriser la création si le service est injoignable
+}
+
+// urlCheckRequest est le corps envoyé au service de vérification.
+type urlCheckRequest struct {
+	URL string `json:"url"`
+}
+
+// urlCheckResponse est la réponse attendue du service de vérification.
+type urlCheckResponse struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+// NewURLChecker crée un URLChecker.
+// endpoint: URL du service de vérification
+// timeout: durée maximale d'un appel
+// failOpen: true pour autoriser les créations quand le service est injoignable
+func NewURLChecker(endpoint string, timeout time.Duration, failOpen bool) *URLChecker {
+	return &URLChecker{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+		failOpen: failOpen,
+	}
+}
+
+// Check soumet l'URL au service de vérification.
+// Retourne *errors.ErrURLDenied si l'URL est refusée, *errors.ErrURLCheckUnavailable si le service
+// est injoignable en mode fail-closed, et nil si la création peut continuer.
+func (uc *URLChecker) Check(longURL string) error {
+	decision, err := uc.query(longURL)
+	if err != nil {
+		if uc.failOpen {
+			slog.Warn("Service de vérification injoignable, création autorisée (fail-open)", "component", "url_check", "error", err)
+			return nil
+		}
+		return &apperrors.ErrURLCheckUnavailable{Err: err}
+	}
+
+	if decision.Decision != "allow" {
+		return &apperrors.ErrURLDenied{URL: longURL, Reason: decision.Reason}
+	}
+	return nil
+}
+
+// query effectue l'appel HTTP au service de vérification et décode sa réponse.
+func (uc *URLChecker) query(longURL string) (*urlCheckResponse, error) {
+	body, err := json.Marshal(urlCheckRequest{URL: longURL})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := uc.client.Post(uc.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("statut HTTP inattendu: %d", resp.StatusCode)
+	}
+
+	var decision urlCheckResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return nil, fmt.Errorf("réponse invalide: %w", err)
+	}
+	if decision.Decision != "allow" && decision.Decision != "deny" {
+		return nil, fmt.Errorf("décision inconnue: '%s'", decision.Decision)
+	}
+	return &decision, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// uaRule associe un marqueur du User-Agent à une famille de navigateur ou de système.
+type uaRule struct {
+	marker string
+	name   string
+}
+
+// browserRules est parcourue dans l'ordre : les navigateurs basés sur Chromium ou WebKit
+// reprennent les marqueurs de Chrome et Safari, ils doivent donc être testés avant eux.
+var browserRules = []uaRule{
+	{"edg/", "Edge"},
+	{"edge/", "Edge"},
+	{"opr/", "Opera"},
+	{"opera", "Opera"},
+	{"samsungbrowser/", "Samsung Internet"},
+	{"yabrowser/", "Yandex"},
+	{"firefox/", "Firefox"},
+	{"fxios/", "Firefox"},
+	{"crios/", "Chrome"},
+	{"chrome/", "Chrome"},
+	{"chromium/", "Chrome"},
+	{"msie ", "Internet Explorer"},
+	{"trident/", "Internet Explorer"},
+	{"safari/", "Safari"},
+	{"curl/", "curl"},
+	{"wget/", "Wget"},
+	{"bot", "Bot"},
+	{"spider", "Bot"},
+	{"crawler", "Bot"},
+}
+
+// osRules est parcourue dans l'ordre : Android et ChromeOS annoncent aussi Linux, iOS annonce aussi Mac OS X.
+var osRules = []uaRule{
+	{"windows", "Windows"},
+	{"android", "Android"},
+	{"iphone", "iOS"},
+	{"ipad", "iOS"},
+	{"ipod", "iOS"},
+	{"cros", "ChromeOS"},
+	{"mac os x", "macOS"},
+	{"macintosh", "macOS"},
+	{"linux", "Linux"},
+}
+
+// ParseUserAgent extrait la famille de navigateur et le système d'exploitation d'un User-Agent.
+// Les valeurs non reconnues valent models.UserAgentOther.
+func ParseUserAgent(userAgent string) (browser, os string) {
+	ua := strings.ToLower(userAgent)
+	return matchUARule(ua, browserRules), matchUARule(ua, osRules)
+}
+
+// matchUARule retourne le nom de la première règle dont le marqueur apparaît dans 'ua'.
+func matchUARule(ua string, rules []uaRule) string {
+	for _, rule := range rules {
+		if strings.Contains(ua, rule.marker) {
+			return rule.name
+		}
+	}
+	return models.UserAgentOther
+}
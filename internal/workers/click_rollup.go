@@ -0,0 +1,33 @@
+package workers
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// StartClickRollup lance périodiquement le compactage des clics plus anciens que 'olderThan'.
+// Cette fonction est conçue pour être lancée dans une goroutine séparée.
+func StartClickRollup(clickService *services.ClickService, interval, olderThan time.Duration) {
+	slog.Info("Démarrage du compactage des clics", "component", "rollup", "older_than", olderThan.String(), "interval", interval.String())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Exécute un premier compactage immédiatement au démarrage
+	runClickRollup(clickService, olderThan)
+
+	for range ticker.C {
+		runClickRollup(clickService, olderThan)
+	}
+}
+
+// runClickRollup exécute un compactage et loggue son résultat.
+func runClickRollup(clickService *services.ClickService, olderThan time.Duration) {
+	rolledUp, err := clickService.RollupClicks(olderThan)
+	if err != nil {
+		slog.Error("Erreur lors du compactage des clics", "component", "rollup", "error", err)
+		return
+	}
+	slog.Info("Compactage effectué", "component", "rollup", "rolled_up_clicks", rolledUp)
+}
@@ -1,33 +1,85 @@
 package workers
 
 import (
-	"log"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Nécessaire pour interagir avec le ClickRepository
+	"github.com/axellelanca/urlshortener/internal/services"
 )
 
+// droppedOrphanClicks compte les clics ignorés car leur lien a été supprimé entre la redirection et l'enregistrement.
+var droppedOrphanClicks atomic.Int64
+
+// DroppedOrphanClicks retourne le nombre de clics ignorés pour des liens supprimés depuis le démarrage.
+func DroppedOrphanClicks() int64 {
+	return droppedOrphanClicks.Load()
+}
+
 // StartClickWorkers lance un pool de goroutines "workers" pour traiter les événements de clic.
 // Chaque worker lira depuis le même 'clickEventsChan' et utilisera le 'clickRepo' pour la persistance.
-func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	log.Printf("Starting %d click worker(s)...", workerCount)
+// Le 'linkRepo' permet de vérifier que le lien existe toujours avant d'enregistrer le clic.
+// 'dedup' est optionnel (nil si désactivé) et écarte les clics répétés d'un même visiteur.
+// 'geo' est optionnel (nil si désactivé) et renseigne le pays du clic à partir de l'IP.
+// Avec 'batchSize' > 1, chaque worker regroupe ses clics et les insère par lots de 'batchSize',
+// ou toutes les 'flushInterval' si le lot n'est pas plein.
+// Les workers s'arrêtent une fois le channel fermé et vidé (le lot partiel est alors inséré) ; le WaitGroup
+// retourné permet d'attendre qu'ils aient enregistré les derniers clics lors de l'arrêt du serveur.
+func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository,
+	linkRepo repository.LinkRepository, dedup services.ClickDeduplicator, geo services.CountryResolver,
+	batchSize int, flushInterval time.Duration) *sync.WaitGroup {
+	slog.Info("Starting click workers", "component", "click_workers", "workers", workerCount)
+	var wg sync.WaitGroup
 	for i := 0; i < workerCount; i++ {
 		// Lance chaque worker dans sa propre goroutine.
 		// Le channel est passé en lecture seule (<-chan) pour renforcer l'immutabilité du channel à l'intérieur du worker.
-		go clickWorker(clickEventsChan, clickRepo)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if batchSize > 1 {
+				batchClickWorker(clickEventsChan, clickRepo, linkRepo, dedup, geo, batchSize, flushInterval)
+			} else {
+				clickWorker(clickEventsChan, clickRepo, linkRepo, dedup, geo)
+			}
+		}()
+	}
+	return &wg
+}
+
+// DrainClickWorkers ferme le channel des clics puis attend, au plus 'timeout', que les workers aient enregistré
+// les événements restants et se soient arrêtés. Retourne false si le délai a expiré avant la fin du vidage.
+// Le channel ne doit plus recevoir d'événement : l'appelant s'assure que plus aucune requête n'est en cours.
+func DrainClickWorkers(clickEventsChan chan models.ClickEvent, wg *sync.WaitGroup, timeout time.Duration) bool {
+	close(clickEventsChan)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
 	}
 }
 
 // clickWorker est la fonction exécutée par chaque goroutine worker.
 // Elle tourne indéfiniment, lisant les événements de clic dès qu'ils sont disponibles dans le channel.
-func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
+func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository,
+	linkRepo repository.LinkRepository, dedup services.ClickDeduplicator, geo services.CountryResolver) {
 	for event := range clickEventsChan { // Boucle qui lit les événements du channel
-		// Convertir le 'ClickEvent' (reçu du channel) en un modèle 'models.Click'.
-		click := &models.Click{
-			LinkID:    event.LinkID,
-			Timestamp: event.Timestamp,
-			UserAgent: event.UserAgent,
-			IPAddress: event.IPAddress,
+		if !linkStillExists(event, linkRepo) {
+			continue
+		}
+		click, ok := toClick(event, dedup, geo)
+		if !ok {
+			continue
 		}
 
 		// Persister le clic en base de données via le 'clickRepo' (CreateClick).
@@ -39,12 +91,139 @@ func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.
 			// Si une erreur se produit lors de l'enregistrement, logguez-la.
 			// L'événement est "perdu" pour ce TP, mais dans un vrai système,
 			// vous pourriez le remettre dans une file de retry ou une file d'erreurs.
-			log.Printf("ERROR: Failed to save click for LinkID %d (UserAgent: %s, IP: %s): %v",
-				event.LinkID, event.UserAgent, event.IPAddress, err)
+			slog.Error("Failed to save click", "component", "click_workers", "request_id", event.RequestID, "link_id", event.LinkID,
+				"user_agent", event.UserAgent, "ip", event.IPAddress, "error", err)
 
 		} else {
 			// Log optionnel pour confirmer l'enregistrement (utile pour le débogage)
-			log.Printf("Click recorded successfully for LinkID %d", event.LinkID)
+			slog.Debug("Click recorded successfully", "component", "click_workers", "request_id", event.RequestID, "link_id", event.LinkID)
+		}
+	}
+}
+
+// batchClickWorker accumule les clics et les insère en une requête dès que le lot atteint 'batchSize'
+// ou que 'flushInterval' s'est écoulé. Le lot partiel est inséré à la fermeture du channel.
+// L'existence des liens est vérifiée une seule fois par lot, juste avant l'insertion.
+func batchClickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository,
+	linkRepo repository.LinkRepository, dedup services.ClickDeduplicator, geo services.CountryResolver,
+	batchSize int, flushInterval time.Duration) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.Click, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		batch = dropOrphanClicks(batch, linkRepo)
+		if len(batch) == 0 {
+			return
+		}
+		if err := clickRepo.CreateClicks(batch); err != nil {
+			slog.Error("Failed to save batch of clicks", "component", "click_workers", "clicks", len(batch), "error", err)
+		} else {
+			slog.Debug("Batch of clicks recorded successfully", "component", "click_workers", "clicks", len(batch))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case event, open := <-clickEventsChan:
+			if !open {
+				flush()
+				return
+			}
+			if click, ok := toClick(event, dedup, geo); ok {
+				batch = append(batch, *click)
+			}
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
 		}
 	}
 }
+
+// linkStillExists vérifie que le lien d'un événement existe encore. Un lien peut être supprimé alors qu'une
+// redirection en cours a déjà mis son clic en file : on ignore alors le clic plutôt que de créer une ligne orpheline.
+// Le clic est aussi ignoré si la vérification échoue.
+func linkStillExists(event models.ClickEvent, linkRepo repository.LinkRepository) bool {
+	exists, err := linkRepo.LinkExists(event.LinkID)
+	if err != nil {
+		slog.Error("Failed to check link existence", "component", "click_workers", "request_id", event.RequestID,
+			"link_id", event.LinkID, "error", err)
+		return false
+	}
+	if !exists {
+		droppedOrphanClicks.Add(1)
+		slog.Warn("Link no longer exists, dropping click", "component", "click_workers", "request_id", event.RequestID, "link_id", event.LinkID,
+			"dropped_total", droppedOrphanClicks.Load())
+		return false
+	}
+	return true
+}
+
+// dropOrphanClicks retire d'un lot les clics dont le lien a été supprimé depuis la redirection,
+// avec une seule requête d'existence pour tous les liens du lot. Le lot entier est écarté si la vérification échoue.
+func dropOrphanClicks(batch []models.Click, linkRepo repository.LinkRepository) []models.Click {
+	linkIDs := make([]uint, 0, len(batch))
+	seen := make(map[uint]bool, len(batch))
+	for _, click := range batch {
+		if !seen[click.LinkID] {
+			seen[click.LinkID] = true
+			linkIDs = append(linkIDs, click.LinkID)
+		}
+	}
+	existing, err := linkRepo.ExistingLinkIDs(linkIDs)
+	if err != nil {
+		slog.Error("Failed to check link existence, dropping batch of clicks", "component", "click_workers",
+			"clicks", len(batch), "error", err)
+		return batch[:0]
+	}
+
+	kept := batch[:0]
+	for _, click := range batch {
+		if existing[click.LinkID] {
+			kept = append(kept, click)
+			continue
+		}
+		droppedOrphanClicks.Add(1)
+		slog.Warn("Link no longer exists, dropping click", "component", "click_workers", "link_id", click.LinkID,
+			"dropped_total", droppedOrphanClicks.Load())
+	}
+	return kept
+}
+
+// toClick convertit un événement en clic à enregistrer. Il retourne false si le clic doit être ignoré,
+// répété dans la fenêtre de déduplication. L'existence du lien est vérifiée par l'appelant.
+func toClick(event models.ClickEvent, dedup services.ClickDeduplicator, geo services.CountryResolver) (*models.Click, bool) {
+	// Ignorer un clic répété du même visiteur dans la fenêtre de déduplication.
+	// Si le backend est indisponible, le clic est enregistré plutôt que perdu.
+	if dedup != nil {
+		duplicate, err := dedup.IsDuplicate(event)
+		if err != nil {
+			slog.Warn("Click deduplication unavailable, recording click", "component", "click_workers", "request_id", event.RequestID,
+				"link_id", event.LinkID, "error", err)
+		} else if duplicate {
+			return nil, false
+		}
+	}
+
+	// Convertir le 'ClickEvent' (reçu du channel) en un modèle 'models.Click'.
+	click := &models.Click{
+		LinkID:     event.LinkID,
+		Timestamp:  event.Timestamp,
+		UserAgent:  event.UserAgent,
+		IPAddress:  event.IPAddress,
+		ServedPath: event.ServedPath,
+		Referrer:   event.Referrer,
+	}
+	// Analyse du User-Agent et résolution du pays hors du chemin de redirection, dans le worker
+	click.Browser, click.OS = services.ParseUserAgent(event.UserAgent)
+	if geo != nil {
+		click.Country = geo.Country(event.IPAddress)
+	}
+	return click, true
+}
@@ -0,0 +1,141 @@
+package workers
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB ouvre une base SQLite en mémoire propre au test, migrée, avec un lien "abc123".
+func newTestDB(t *testing.T) (*gorm.DB, *models.Link) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("ouverture de la base de test: %v", err)
+	}
+	if err := conn.AutoMigrate(&models.Link{}, &models.Click{}, &models.ClickDaily{}); err != nil {
+		t.Fatalf("migration de la base de test: %v", err)
+	}
+	sqlDB, _ := conn.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	link := &models.Link{ShortCode: "abc123", LongURL: "https://example.com", CreatedAt: time.Now()}
+	if err := conn.Create(link).Error; err != nil {
+		t.Fatalf("création du lien: %v", err)
+	}
+	return conn, link
+}
+
+func TestDrainClickWorkersRecordsQueuedClicks(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchSize int
+	}{
+		{"unitaire", 1},
+		// Lot jamais plein ni vidé par le ticker : seul le vidage à l'arrêt enregistre les clics
+		{"par lots", 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, link := newTestDB(t)
+			events := make(chan models.ClickEvent, 10)
+			wg := StartClickWorkers(2, events, repository.NewClickRepository(conn), repository.NewLinkRepository(conn),
+				nil, nil, tt.batchSize, time.Hour)
+
+			// Événements mis en file avant l'arrêt
+			for i := 0; i < 3; i++ {
+				events <- models.ClickEvent{LinkID: link.ID, Timestamp: time.Now(), IPAddress: "192.0.2.1"}
+			}
+			if !DrainClickWorkers(events, wg, 5*time.Second) {
+				t.Fatal("DrainClickWorkers: délai expiré, attendu un vidage complet")
+			}
+
+			var count int64
+			conn.Model(&models.Click{}).Where("link_id = ?", link.ID).Count(&count)
+			if count != 3 {
+				t.Errorf("clics enregistrés = %d, attendu 3", count)
+			}
+		})
+	}
+}
+
+// blockingClickRepo bloque chaque insertion jusqu'à la fermeture de 'release'.
+type blockingClickRepo struct {
+	repository.ClickRepository
+	release chan struct{}
+}
+
+func (r *blockingClickRepo) CreateClick(click *models.Click) error {
+	<-r.release
+	return nil
+}
+
+func TestDrainClickWorkersTimeout(t *testing.T) {
+	conn, link := newTestDB(t)
+	release := make(chan struct{})
+	defer close(release)
+
+	events := make(chan models.ClickEvent, 1)
+	wg := StartClickWorkers(1, events, &blockingClickRepo{release: release}, repository.NewLinkRepository(conn),
+		nil, nil, 1, time.Hour)
+	events <- models.ClickEvent{LinkID: link.ID, Timestamp: time.Now()}
+
+	if DrainClickWorkers(events, wg, 50*time.Millisecond) {
+		t.Error("DrainClickWorkers: vidage signalé complet alors que l'insertion est bloquée")
+	}
+}
+
+// countingLinkRepo compte les vérifications d'existence faites par les workers.
+type countingLinkRepo struct {
+	repository.LinkRepository
+	single, grouped atomic.Int64
+}
+
+func (r *countingLinkRepo) LinkExists(linkID uint) (bool, error) {
+	r.single.Add(1)
+	return r.LinkRepository.LinkExists(linkID)
+}
+
+func (r *countingLinkRepo) ExistingLinkIDs(linkIDs []uint) (map[uint]bool, error) {
+	r.grouped.Add(1)
+	return r.LinkRepository.ExistingLinkIDs(linkIDs)
+}
+
+func TestBatchClickWorkerChecksLinksOncePerFlush(t *testing.T) {
+	conn, link := newTestDB(t)
+	linkRepo := &countingLinkRepo{LinkRepository: repository.NewLinkRepository(conn)}
+	events := make(chan models.ClickEvent, 10)
+	wg := StartClickWorkers(1, events, repository.NewClickRepository(conn), linkRepo, nil, nil, 100, time.Hour)
+
+	droppedBefore := DroppedOrphanClicks()
+	for i := 0; i < 3; i++ {
+		events <- models.ClickEvent{LinkID: link.ID, Timestamp: time.Now()}
+	}
+	// Clic d'un lien supprimé depuis la redirection
+	events <- models.ClickEvent{LinkID: link.ID + 100, Timestamp: time.Now()}
+	if !DrainClickWorkers(events, wg, 5*time.Second) {
+		t.Fatal("DrainClickWorkers: délai expiré, attendu un vidage complet")
+	}
+
+	var count int64
+	conn.Model(&models.Click{}).Count(&count)
+	if count != 3 {
+		t.Errorf("clics enregistrés = %d, attendu 3", count)
+	}
+	if dropped := DroppedOrphanClicks() - droppedBefore; dropped != 1 {
+		t.Errorf("clics orphelins écartés = %d, attendu 1", dropped)
+	}
+	if single, grouped := linkRepo.single.Load(), linkRepo.grouped.Load(); single != 0 || grouped != 1 {
+		t.Errorf("vérifications: %d unitaires, %d groupées, attendu 0 et 1", single, grouped)
+	}
+}
@@ -0,0 +1,34 @@
+package workers
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// StartExpiredLinkPurge lance périodiquement la purge des liens expirés (monitor.purge_expired).
+// Avec hardDelete, les liens sont supprimés ; sinon ils sont désactivés.
+// Cette fonction est conçue pour être lancée dans une goroutine séparée.
+func StartExpiredLinkPurge(linkService *services.LinkService, interval time.Duration, hardDelete bool) {
+	slog.Info("Démarrage de la purge des liens expirés", "component", "purge", "interval", interval.String(), "hard_delete", hardDelete)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Exécute une première purge immédiatement au démarrage
+	runExpiredLinkPurge(linkService, hardDelete)
+
+	for range ticker.C {
+		runExpiredLinkPurge(linkService, hardDelete)
+	}
+}
+
+// runExpiredLinkPurge exécute une purge et loggue son résultat.
+func runExpiredLinkPurge(linkService *services.LinkService, hardDelete bool) {
+	purged, err := linkService.PurgeExpiredLinks(hardDelete)
+	if err != nil {
+		slog.Error("Erreur lors de la purge des liens expirés", "component", "purge", "purged_before_error", purged, "error", err)
+		return
+	}
+	slog.Info("Purge effectuée", "component", "purge", "purged", purged, "hard_delete", hardDelete)
+}